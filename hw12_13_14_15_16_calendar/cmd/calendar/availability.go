@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+)
+
+const hoursUsage = `usage: calendar hours <show|set|clear> [flags]
+
+set replaces the working hours, e.g.
+  calendar hours set -tz Europe/Moscow -days monday=09:00-18:00,friday=09:00-15:00 -holidays 2024-01-01
+
+Connection flags and environment are the same as for calendar events.`
+
+func runHours(args []string) error {
+	if len(args) == 0 {
+		return errors.New(hoursUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	c := newEventsCmd(args[0])
+	c.fs.Init("hours "+args[0], flag.ContinueOnError)
+	tz := c.fs.String("tz", "", "IANA time zone, e.g. Europe/Moscow")
+	days := c.fs.String("days", "", "comma-separated day=HH:MM-HH:MM, missing days are days off")
+	holidays := c.fs.String("holidays", "", "comma-separated days off, 2006-01-02")
+	if err := c.fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	cl := c.client()
+
+	var (
+		hours internalhttp.WorkingHours
+		err   error
+	)
+	switch args[0] {
+	case "show":
+		hours, err = cl.WorkingHours(ctx)
+	case "set":
+		if *tz == "" {
+			return errors.New("-tz is required")
+		}
+		hours = internalhttp.WorkingHours{TimeZone: *tz, Holidays: splitList(*holidays)}
+		if hours.Days, err = parseDays(*days); err != nil {
+			return fmt.Errorf("-days: %w", err)
+		}
+		hours, err = cl.SetWorkingHours(ctx, hours)
+	case "clear":
+		return cl.DeleteWorkingHours(ctx)
+	default:
+		return errors.New(hoursUsage)
+	}
+	if err != nil {
+		return err
+	}
+	return c.printHours(hours)
+}
+
+// parseDays разбирает "monday=09:00-18:00,friday=09:00-15:00".
+func parseDays(s string) (map[string]internalhttp.WorkPeriod, error) {
+	res := map[string]internalhttp.WorkPeriod{}
+	for _, item := range splitList(s) {
+		day, period, ok := strings.Cut(item, "=")
+		start, end, ok2 := strings.Cut(period, "-")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("%q is not day=HH:MM-HH:MM", item)
+		}
+		res[day] = internalhttp.WorkPeriod{Start: start, End: end}
+	}
+	return res, nil
+}
+
+func (c *eventsCmd) printHours(hours internalhttp.WorkingHours) error {
+	if c.asJSON {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(hours)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TIME ZONE\t%s\n", hours.TimeZone)
+	for _, d := range []time.Weekday{
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+	} {
+		day := strings.ToLower(d.String())
+		p, ok := hours.Days[day]
+		if !ok {
+			fmt.Fprintf(w, "%s\toff\n", day)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s-%s\n", day, p.Start, p.End)
+	}
+	if len(hours.Holidays) > 0 {
+		holidays := append([]string(nil), hours.Holidays...)
+		sort.Strings(holidays)
+		fmt.Fprintf(w, "HOLIDAYS\t%s\n", strings.Join(holidays, ", "))
+	}
+	return w.Flush()
+}
+
+// runFreeBusy печатает занятость за период: calendar freebusy [-from TIME] [-to TIME] [-users a,b].
+func runFreeBusy(args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	c := newEventsCmd("freebusy")
+	c.fs.Init("freebusy", flag.ContinueOnError)
+	from := c.fs.String("from", time.Now().Format(internalhttp.DateLayout), "start, RFC3339 or \"2006-01-02 15:04\"")
+	to := c.fs.String("to", "", "end, one week after -from by default")
+	users := c.fs.String("users", "", "comma-separated users, empty for yourself")
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+
+	start, err := parseTime(*from)
+	if err != nil {
+		return fmt.Errorf("-from: %w", err)
+	}
+	end := start.AddDate(0, 0, 7)
+	if *to != "" {
+		if end, err = parseTime(*to); err != nil {
+			return fmt.Errorf("-to: %w", err)
+		}
+	}
+
+	list, err := c.client().FreeBusy(ctx, start, end, splitList(*users))
+	if err != nil {
+		return err
+	}
+	if c.asJSON {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tSTATE\tFROM\tTO")
+	row := func(user, state string, i internalhttp.Interval) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			user, state, i.Start.Local().Format("2006-01-02 15:04"), i.End.Local().Format("2006-01-02 15:04"))
+	}
+	for _, fb := range list {
+		for _, i := range fb.Busy {
+			row(fb.UserID, "busy", i)
+		}
+		for _, i := range fb.Free {
+			row(fb.UserID, "free", i)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/backup"
+)
+
+var errNoMemoryFile = errors.New("memory storage lives in the service process, set storage.file or use sql storage")
+
+// runBackup выгружает хранилище из конфига в архив JSON-строк.
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := fs.String("o", "-", "archive file, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	config, err := commandStorageConf()
+	if err != nil {
+		return err
+	}
+	s, closeStorage, err := openStorage(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStorage(ctx) //nolint:errcheck // хранилище только читали
+
+	var stats backup.Stats
+	if *out == "-" {
+		stats, err = backup.Write(ctx, os.Stdout, s, time.Now())
+	} else {
+		stats, err = saveArchive(ctx, *out, s)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "backed up "+stats.String())
+	return nil
+}
+
+// runRestore загружает архив в хранилище из конфига. Загрузка идёт одной транзакцией
+// и не перезаписывает существующие записи, поэтому хранилище должно быть пустым.
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	in := fs.String("i", "-", "archive file, - for stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	config, err := commandStorageConf()
+	if err != nil {
+		return err
+	}
+	s, closeStorage, err := openStorage(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStorage(ctx) //nolint:errcheck // всё записано до закрытия
+
+	stats, err := backup.Restore(ctx, r, s)
+	if err != nil {
+		return err
+	}
+	if _, err := persist(ctx, config, s); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "restored "+stats.String())
+	return nil
+}
+
+// commandStorageConf - хранилище для backup и restore. Хранилище в памяти без файла
+// живёт только в процессе сервиса, и командам с ним делать нечего.
+func commandStorageConf() (StorageConf, error) {
+	config, err := NewConfig(configFile)
+	if err == nil {
+		err = config.Validate()
+	}
+	if err != nil {
+		return StorageConf{}, err
+	}
+	if config.Storage.Type == "memory" && config.Storage.File == "" {
+		return StorageConf{}, errNoMemoryFile
+	}
+	return config.Storage, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+)
+
+const calendarsUsage = `usage: calendar calendars <list|add|rename|delete|share|unshare> [flags]
+
+Connection flags and environment are the same as for calendar events.`
+
+func runCalendars(args []string) error {
+	if len(args) == 0 {
+		return errors.New(calendarsUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	c := newEventsCmd(args[0])
+	c.fs.Init("calendars "+args[0], flag.ContinueOnError)
+	id := c.fs.String("id", "", "calendar ID")
+	name := c.fs.String("name", "", "calendar name")
+	with := c.fs.String("with", "", "user ID to share the calendar with")
+	access := c.fs.String("access", "read", "read or write")
+	if err := c.fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if args[0] != "list" && args[0] != "add" && *id == "" {
+		return errors.New("-id is required")
+	}
+	cl := c.client()
+
+	switch args[0] {
+	case "list":
+		calendars, err := cl.Calendars(ctx)
+		if err != nil {
+			return err
+		}
+		return c.printCalendars(calendars)
+	case "add":
+		calendar, err := cl.CreateCalendar(ctx, *name)
+		if err != nil {
+			return err
+		}
+		return c.printCalendars([]internalhttp.Calendar{calendar})
+	case "rename":
+		calendar, err := cl.RenameCalendar(ctx, *id, *name)
+		if err != nil {
+			return err
+		}
+		return c.printCalendars([]internalhttp.Calendar{calendar})
+	case "delete":
+		return cl.DeleteCalendar(ctx, *id)
+	case "share":
+		if *with == "" {
+			return errors.New("-with is required")
+		}
+		calendar, err := cl.ShareCalendar(ctx, *id, *with, *access)
+		if err != nil {
+			return err
+		}
+		return c.printCalendars([]internalhttp.Calendar{calendar})
+	case "unshare":
+		// Без -with пользователь отказывается от открытого ему календаря.
+		if *with == "" {
+			*with = c.userID
+		}
+		return cl.UnshareCalendar(ctx, *id, *with)
+	default:
+		return errors.New(calendarsUsage)
+	}
+}
+
+func (c *eventsCmd) printCalendars(calendars []internalhttp.Calendar) error {
+	if c.asJSON {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(calendars)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tOWNER\tACCESS\tSHARED WITH")
+	for _, cal := range calendars {
+		shares := make([]string, 0, len(cal.Shares))
+		for _, s := range cal.Shares {
+			shares = append(shares, s.UserID+":"+s.Access)
+		}
+		sort.Strings(shares)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", cal.ID, cal.Name, cal.OwnerID, cal.Access, strings.Join(shares, ","))
+	}
+	return w.Flush()
+}
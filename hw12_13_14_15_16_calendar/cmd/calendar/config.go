@@ -1,10 +1,28 @@
 package main
 
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/ratelimit"
+)
+
 // При желании конфигурацию можно вынести в internal/config.
 // Организация конфига в main принуждает нас сужать API компонентов, использовать
 // при их конструировании только необходимые параметры, а также уменьшает вероятность циклической зависимости.
 type Config struct {
-	Logger LoggerConf
+	Logger    LoggerConf
+	HTTP      HTTPConf
+	Auth      AuthConf
+	RateLimit RateLimitConf
+	Tracing   TracingConf
+	Scheduler SchedulerConf
+	Notify    NotifyConf
+	Storage   StorageConf
 	// TODO
 }
 
@@ -13,8 +31,218 @@ type LoggerConf struct {
 	// TODO
 }
 
-func NewConfig() Config {
-	return Config{}
+// HTTPConf - IdempotencyTTL: сколько хранить ответы на создание событий
+// с заголовком Idempotency-Key, 0 - заголовок не поддерживается.
+type HTTPConf struct {
+	Host           string
+	Port           string
+	IdempotencyTTL time.Duration
 }
 
-// TODO
+func (c HTTPConf) Addr() string {
+	return net.JoinHostPort(c.Host, c.Port)
+}
+
+// AuthConf - если Enabled, ID пользователя берётся из API-ключа или JWT.
+// Keys: sha256-хэш ключа -> ID пользователя, см. `calendar mint-key`.
+type AuthConf struct {
+	Enabled bool
+	Secret  string
+	Keys    map[string]string
+}
+
+// RateLimitConf - token bucket на IP и на пользователя отдельно для чтения
+// (GET/HEAD) и изменения событий. Capacity - сколько клиентов помнить на класс.
+type RateLimitConf struct {
+	Enabled  bool
+	Capacity int
+	Read     LimitConf
+	Write    LimitConf
+}
+
+type LimitConf struct {
+	Rate  float64
+	Burst int
+}
+
+// Limit - лимит для ratelimit.Limiter, выключенный лимит ничего не ограничивает.
+func (c RateLimitConf) Limit(l LimitConf) ratelimit.Limit {
+	if !c.Enabled {
+		return ratelimit.Limit{}
+	}
+	return ratelimit.Limit{Rate: l.Rate, Burst: l.Burst}
+}
+
+// TracingConf - Output: "stdout" или путь к файлу, куда спаны пишутся JSON-строками.
+type TracingConf struct {
+	Enabled bool
+	Output  string
+}
+
+// SchedulerConf - планировщик и хранитель уведомлений: в режиме all-in-one или
+// отдельными процессами `calendar scheduler` и `calendar storer` при sql-хранилище.
+// Retention и TrashRetention: 0 - не удалять. DeferToWorkingHours - напоминания вне
+// рабочего времени пользователя откладываются до его начала. LeaseTTL - аренда лидера
+// при sql-хранилище: если лидер пропал, сканировать начнёт другой планировщик.
+type SchedulerConf struct {
+	Topic               string
+	Interval            time.Duration
+	Retention           time.Duration
+	TrashRetention      time.Duration
+	DeferToWorkingHours bool
+	LeaseTTL            time.Duration
+}
+
+// NotifyConf - каналы доставки напоминаний хранителем: "log", "webhook" или "chatbot".
+// Default - канал для всех, Users - ID пользователя -> его канал.
+// Timeout ограничивает один запрос к webhook или боту.
+type NotifyConf struct {
+	Default string
+	Users   map[string]string
+	Timeout time.Duration
+	Webhook WebhookConf
+	ChatBot ChatBotConf
+}
+
+type WebhookConf struct {
+	URL string
+}
+
+// ChatBotConf - бот с API в формате Telegram. Chats: ID пользователя -> ID чата с ботом.
+type ChatBotConf struct {
+	URL   string
+	Token string
+	Chats map[string]string
+}
+
+// channels - все каналы, которые используются в конфиге.
+func (c NotifyConf) channels() map[string]bool {
+	res := map[string]bool{c.Default: true}
+	for _, ch := range c.Users {
+		res[ch] = true
+	}
+	return res
+}
+
+func (c NotifyConf) validate() error {
+	if c.Timeout <= 0 {
+		return errors.New("notify: timeout must be positive")
+	}
+	used := c.channels()
+	for ch := range used {
+		if ch != "log" && ch != "webhook" && ch != "chatbot" {
+			return fmt.Errorf("notify: unknown channel %q, want log, webhook or chatbot", ch)
+		}
+	}
+	if used["webhook"] && c.Webhook.URL == "" {
+		return errors.New("notify.webhook: url is required")
+	}
+	if !used["chatbot"] {
+		return nil
+	}
+	if c.ChatBot.URL == "" || c.ChatBot.Token == "" {
+		return errors.New("notify.chatbot: url and token are required")
+	}
+	for user, ch := range c.Users {
+		if ch == "chatbot" && c.ChatBot.Chats[user] == "" {
+			return fmt.Errorf("notify.chatbot: no chat for user %s", user)
+		}
+	}
+	return nil
+}
+
+// StorageConf - Type: "memory" или "sql". Для sql DSN - строка подключения PostgreSQL,
+// миграции из migrations применяются заранее. Хранилище в памяти с File загружается
+// из этого архива (см. `calendar backup`) при запуске и сохраняется в него при остановке.
+type StorageConf struct {
+	Type  string
+	DSN   string
+	File  string
+	Cache CacheConf
+}
+
+// CacheConf - кэш списков событий поверх хранилища: не больше Size списков,
+// каждый живёт не дольше TTL. Нужен прежде всего sql-хранилищу.
+// Раз в StatsInterval счётчики кэша пишутся в лог, 0 - только при остановке.
+type CacheConf struct {
+	Enabled       bool
+	Size          int
+	TTL           time.Duration
+	StatsInterval time.Duration
+}
+
+func NewConfig(path string) (Config, error) {
+	config := Config{
+		Logger: LoggerConf{Level: "INFO"},
+		HTTP:   HTTPConf{Host: "0.0.0.0", Port: "8888", IdempotencyTTL: 24 * time.Hour},
+		RateLimit: RateLimitConf{
+			Capacity: 10000,
+			Read:     LimitConf{Rate: 10, Burst: 20},
+			Write:    LimitConf{Rate: 2, Burst: 5},
+		},
+		Tracing:   TracingConf{Output: "stdout"},
+		Scheduler: SchedulerConf{Topic: "notifications", Interval: time.Minute, LeaseTTL: 15 * time.Second},
+		Notify: NotifyConf{
+			Default: "log",
+			Timeout: 5 * time.Second,
+			ChatBot: ChatBotConf{URL: "https://api.telegram.org"},
+		},
+		Storage: StorageConf{
+			Type:  "memory",
+			Cache: CacheConf{Size: 10000, TTL: 30 * time.Second, StatsInterval: time.Minute},
+		},
+	}
+	if _, err := toml.DecodeFile(path, &config); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// Validate проверяет то, что иначе всплыло бы только при работе сервиса.
+func (c Config) Validate() error {
+	if _, err := logger.ParseLevel(c.Logger.Level); err != nil {
+		return fmt.Errorf("logger: %w", err)
+	}
+	if c.HTTP.Port == "" {
+		return errors.New("http: port is required")
+	}
+	if c.HTTP.IdempotencyTTL < 0 {
+		return errors.New("http: idempotencyTTL must not be negative")
+	}
+	if c.RateLimit.Enabled {
+		if c.RateLimit.Capacity <= 0 {
+			return errors.New("ratelimit: capacity must be positive")
+		}
+		for name, l := range map[string]LimitConf{"read": c.RateLimit.Read, "write": c.RateLimit.Write} {
+			if l.Rate < 0 || l.Burst < 1 {
+				return fmt.Errorf("ratelimit.%s: rate must not be negative and burst must be at least 1", name)
+			}
+		}
+	}
+	if c.Auth.Enabled && c.Auth.Secret == "" && len(c.Auth.Keys) == 0 {
+		return errors.New("auth: secret or keys are required")
+	}
+	if c.Scheduler.Topic == "" || c.Scheduler.Interval <= 0 {
+		return errors.New("scheduler: topic and positive interval are required")
+	}
+	if c.Scheduler.Retention < 0 || c.Scheduler.TrashRetention < 0 {
+		return errors.New("scheduler: retention must not be negative")
+	}
+	if c.Scheduler.LeaseTTL <= 0 {
+		return errors.New("scheduler: leaseTTL must be positive")
+	}
+	if err := c.Notify.validate(); err != nil {
+		return err
+	}
+	switch {
+	case c.Storage.Type != "memory" && c.Storage.Type != "sql":
+		return fmt.Errorf("storage: unknown type %q, want memory or sql", c.Storage.Type)
+	case c.Storage.Type == "sql" && c.Storage.DSN == "":
+		return errors.New("storage: dsn is required for sql storage")
+	case c.Storage.Cache.Enabled && (c.Storage.Cache.Size <= 0 || c.Storage.Cache.TTL <= 0):
+		return errors.New("storage.cache: positive size and ttl are required")
+	case c.Storage.Cache.StatsInterval < 0:
+		return errors.New("storage.cache: statsInterval must not be negative")
+	}
+	return nil
+}
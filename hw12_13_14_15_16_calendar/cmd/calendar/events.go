@@ -0,0 +1,475 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/client"
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+)
+
+const eventsUsage = `usage: calendar events <add|quick|list|update|delete|import|export|history|restore|trash> [flags]
+
+Server address, user ID and token are taken from -addr, -user, -token
+or CALENDAR_ADDR, CALENDAR_USER, CALENDAR_TOKEN.`
+
+// timeLayouts - в чём можно передавать -start/-end, без зоны время считается локальным.
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", internalhttp.DateLayout}
+
+type eventsCmd struct {
+	fs     *flag.FlagSet
+	addr   string
+	userID string
+	token  string
+	asJSON bool
+	out    io.Writer
+}
+
+func newEventsCmd(name string) *eventsCmd {
+	c := &eventsCmd{fs: flag.NewFlagSet("events "+name, flag.ContinueOnError), out: os.Stdout}
+	c.fs.StringVar(&c.addr, "addr", envOr("CALENDAR_ADDR", "http://localhost:8888"), "calendar API address")
+	c.fs.StringVar(&c.userID, "user", os.Getenv("CALENDAR_USER"), "user ID")
+	c.fs.StringVar(&c.token, "token", os.Getenv("CALENDAR_TOKEN"), "API key or JWT")
+	c.fs.BoolVar(&c.asJSON, "json", false, "print JSON instead of a table")
+	return c
+}
+
+func (c *eventsCmd) client() *client.Client {
+	return client.New(c.addr, c.userID, c.token)
+}
+
+func runEvents(args []string) error {
+	if len(args) == 0 {
+		return errors.New(eventsUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "add":
+		return eventsAdd(ctx, args[1:])
+	case "quick":
+		return eventsQuick(ctx, args[1:])
+	case "list":
+		return eventsList(ctx, args[1:], false)
+	case "export":
+		return eventsList(ctx, args[1:], true)
+	case "update":
+		return eventsUpdate(ctx, args[1:])
+	case "delete":
+		return eventsDelete(ctx, args[1:])
+	case "import":
+		return eventsImport(ctx, args[1:])
+	case "history":
+		return eventsHistory(ctx, args[1:])
+	case "restore":
+		return eventsRestore(ctx, args[1:])
+	case "trash":
+		return eventsTrash(ctx, args[1:])
+	default:
+		return errors.New(eventsUsage)
+	}
+}
+
+type eventFlags struct {
+	title, description, start, end, notify string
+	category, labels, reminders, calendar  string
+	duration                               time.Duration
+}
+
+func (f *eventFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.title, "title", "", "event title")
+	fs.StringVar(&f.description, "description", "", "event description")
+	fs.StringVar(&f.start, "start", "", "start time, RFC3339 or \"2006-01-02 15:04\"")
+	fs.StringVar(&f.end, "end", "", "end time, alternative to -duration")
+	fs.DurationVar(&f.duration, "duration", time.Hour, "event duration")
+	fs.StringVar(&f.notify, "notify", "", "notify before the event, e.g. 15m")
+	fs.StringVar(&f.category, "category", "", "main label, the event takes its color")
+	fs.StringVar(&f.labels, "labels", "", "comma-separated labels, empty to clear")
+	fs.StringVar(&f.calendar, "calendar", "", "calendar ID, empty for the personal calendar")
+	fs.StringVar(&f.reminders, "reminders", "",
+		"comma-separated reminders: -15m, start-1d, end-5m or a time, empty to clear")
+}
+
+// apply переносит в event только явно заданные флаги.
+func (f *eventFlags) apply(fs *flag.FlagSet, event *internalhttp.Event) error {
+	set := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	if set["title"] {
+		event.Title = f.title
+	}
+	if set["description"] {
+		event.Description = f.description
+	}
+	if set["notify"] {
+		event.NotifyBefore = f.notify
+	}
+	if set["category"] {
+		event.Category = f.category
+	}
+	if set["labels"] {
+		event.Labels = splitList(f.labels)
+	}
+	if set["calendar"] {
+		event.CalendarID = f.calendar
+	}
+	if set["reminders"] {
+		event.Reminders = nil
+		for _, s := range splitList(f.reminders) {
+			r, err := parseReminder(s)
+			if err != nil {
+				return fmt.Errorf("-reminders: %w", err)
+			}
+			event.Reminders = append(event.Reminders, r)
+		}
+	}
+
+	duration := event.End.Sub(event.Start)
+	if set["start"] {
+		start, err := parseTime(f.start)
+		if err != nil {
+			return fmt.Errorf("-start: %w", err)
+		}
+		event.Start = start
+	}
+	if set["duration"] || duration <= 0 {
+		duration = f.duration
+	}
+	event.End = event.Start.Add(duration)
+	if set["end"] {
+		end, err := parseTime(f.end)
+		if err != nil {
+			return fmt.Errorf("-end: %w", err)
+		}
+		event.End = end
+	}
+	return nil
+}
+
+func eventsAdd(ctx context.Context, args []string) error {
+	c := newEventsCmd("add")
+	var f eventFlags
+	f.register(c.fs)
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if f.title == "" || f.start == "" {
+		return errors.New("-title and -start are required")
+	}
+
+	var event internalhttp.Event
+	if err := f.apply(c.fs, &event); err != nil {
+		return err
+	}
+	created, err := c.client().Create(ctx, event)
+	if err != nil {
+		return err
+	}
+	return c.print([]internalhttp.Event{created})
+}
+
+// eventsQuick создаёт событие из фразы: calendar events quick "Team sync tomorrow 10:00 for 30m".
+func eventsQuick(ctx context.Context, args []string) error {
+	c := newEventsCmd("quick")
+	tz := c.fs.String("tz", os.Getenv("TZ"), "IANA time zone of the phrase, e.g. Europe/Moscow, empty for UTC")
+	calendar := c.fs.String("calendar", "", "calendar ID, empty for the personal calendar")
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	text := strings.Join(c.fs.Args(), " ")
+	if text == "" {
+		return errors.New("event text is required")
+	}
+
+	req := internalhttp.QuickAddRequest{Text: text, TimeZone: *tz, CalendarID: *calendar}
+	created, err := c.client().QuickAdd(ctx, req)
+	if err != nil {
+		return err
+	}
+	return c.print([]internalhttp.Event{created})
+}
+
+func eventsUpdate(ctx context.Context, args []string) error {
+	c := newEventsCmd("update")
+	var f eventFlags
+	f.register(c.fs)
+	id := c.fs.String("id", "", "event ID")
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	cl := c.client()
+	event, err := cl.Get(ctx, *id)
+	if err != nil {
+		return err
+	}
+	if err := f.apply(c.fs, &event); err != nil {
+		return err
+	}
+	updated, err := cl.Update(ctx, *id, event)
+	if err != nil {
+		return err
+	}
+	return c.print([]internalhttp.Event{updated})
+}
+
+func eventsDelete(ctx context.Context, args []string) error {
+	c := newEventsCmd("delete")
+	id := c.fs.String("id", "", "event ID")
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+	return c.client().Delete(ctx, *id)
+}
+
+// eventsList печатает события за период, export - то же самое, но всегда JSON,
+// который потом принимает import, и по умолчанию все события пользователя.
+func eventsList(ctx context.Context, args []string, export bool) error {
+	name, defaultPeriod := "list", "day"
+	if export {
+		name, defaultPeriod = "export", "all"
+	}
+	c := newEventsCmd(name)
+	period := c.fs.String("period", defaultPeriod, "day, week, month or all")
+	date := c.fs.String("date", time.Now().Format(internalhttp.DateLayout), "first day of the period")
+	label := c.fs.String("label", "", "only events with this label")
+	calendar := c.fs.String("calendar", "", "only events of this calendar ID")
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	d, err := time.Parse(internalhttp.DateLayout, *date)
+	if err != nil {
+		return fmt.Errorf("-date: %w", err)
+	}
+
+	events, err := c.client().List(ctx, *period, d, client.Filter{Label: *label, Calendar: *calendar})
+	if err != nil {
+		return err
+	}
+	c.asJSON = c.asJSON || export
+	return c.print(events)
+}
+
+// importBatchSize - сколько событий импортируется одним запросом, не больше лимита сервера.
+const importBatchSize = 500
+
+func eventsImport(ctx context.Context, args []string) error {
+	c := newEventsCmd("import")
+	file := c.fs.String("file", "-", "JSON file produced by export, - for stdin")
+	atomic := c.fs.Bool("atomic", false, "import all events or none, at most 500")
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+
+	in := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var events []internalhttp.Event
+	if err := json.NewDecoder(in).Decode(&events); err != nil {
+		return fmt.Errorf("decode %s: %w", *file, err)
+	}
+	if len(events) == 0 {
+		return c.print([]internalhttp.Event{})
+	}
+	if *atomic && len(events) > importBatchSize {
+		return fmt.Errorf("-atomic imports at most %d events, got %d", importBatchSize, len(events))
+	}
+
+	mode := internalhttp.BatchBestEffort
+	if *atomic {
+		mode = internalhttp.BatchAtomic
+	}
+	cl := c.client()
+	imported := make([]internalhttp.Event, 0, len(events))
+	var failed int
+	for from := 0; from < len(events); from += importBatchSize {
+		to := from + importBatchSize
+		if to > len(events) {
+			to = len(events)
+		}
+		chunk := events[from:to]
+		req := internalhttp.BatchRequest{Mode: mode, Operations: make([]internalhttp.BatchOperation, 0, len(chunk))}
+		for i := range chunk {
+			req.Operations = append(req.Operations, internalhttp.BatchOperation{Op: "create", Event: &chunk[i]})
+		}
+
+		res, err := cl.Batch(ctx, req)
+		if err != nil {
+			return err
+		}
+		for i, r := range res.Results {
+			if r.Event == nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "%s %q: %s\n", chunk[i].ID, chunk[i].Title, r.Error)
+				continue
+			}
+			imported = append(imported, *r.Event)
+		}
+	}
+	if err := c.print(imported); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d events were not imported", failed, len(events))
+	}
+	return nil
+}
+
+func eventsHistory(ctx context.Context, args []string) error {
+	c := newEventsCmd("history")
+	id := c.fs.String("id", "", "event ID")
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	history, err := c.client().History(ctx, *id)
+	if err != nil {
+		return err
+	}
+	if c.asJSON {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(history)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAT\tACTOR\tACTION\tCHANGES")
+	for _, h := range history {
+		changes := make([]string, 0, len(h.Changes))
+		for _, ch := range h.Changes {
+			changes = append(changes, ch.Field)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			h.Version, h.At.Local().Format("2006-01-02 15:04:05"), h.Actor, h.Action, strings.Join(changes, ","))
+	}
+	return w.Flush()
+}
+
+func eventsRestore(ctx context.Context, args []string) error {
+	c := newEventsCmd("restore")
+	id := c.fs.String("id", "", "event ID")
+	version := c.fs.Int("version", 0, "version from events history, without it the event is restored from trash")
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	var (
+		restored internalhttp.Event
+		err      error
+	)
+	if *version == 0 {
+		restored, err = c.client().RestoreFromTrash(ctx, *id)
+	} else {
+		restored, err = c.client().Restore(ctx, *id, *version)
+	}
+	if err != nil {
+		return err
+	}
+	return c.print([]internalhttp.Event{restored})
+}
+
+func eventsTrash(ctx context.Context, args []string) error {
+	c := newEventsCmd("trash")
+	if err := c.fs.Parse(args); err != nil {
+		return err
+	}
+
+	events, err := c.client().Trash(ctx)
+	if err != nil {
+		return err
+	}
+	return c.print(events)
+}
+
+func (c *eventsCmd) print(events []internalhttp.Event) error {
+	if c.asJSON {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(events)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTART\tEND\tTITLE\tNOTIFY\tCATEGORY\tLABELS")
+	for _, e := range events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.ID, e.Start.Local().Format("2006-01-02 15:04"), e.End.Local().Format("2006-01-02 15:04"),
+			e.Title, e.NotifyBefore, e.Category, strings.Join(e.Labels, ","))
+	}
+	return w.Flush()
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q", s)
+}
+
+// parseReminder понимает смещение от начала ("-15m"), от края ("end-5m")
+// и абсолютное время в любом из timeLayouts.
+func parseReminder(s string) (internalhttp.Reminder, error) {
+	for _, anchor := range []string{"start", "end"} {
+		if rest := strings.TrimPrefix(s, anchor); rest != s {
+			if _, err := internalhttp.ParseOffset(rest); err != nil {
+				return internalhttp.Reminder{}, err
+			}
+			return internalhttp.Reminder{RelativeTo: anchor, Offset: rest}, nil
+		}
+	}
+	if _, err := internalhttp.ParseOffset(s); err == nil {
+		return internalhttp.Reminder{Offset: s}, nil
+	}
+	at, err := parseTime(s)
+	if err != nil {
+		return internalhttp.Reminder{}, err
+	}
+	return internalhttp.Reminder{At: &at}, nil
+}
+
+func splitList(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
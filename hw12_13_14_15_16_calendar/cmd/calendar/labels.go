@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+)
+
+const labelsUsage = `usage: calendar labels <list|add|update|delete> [flags]
+
+Connection flags and environment are the same as for calendar events.`
+
+func runLabels(args []string) error {
+	if len(args) == 0 {
+		return errors.New(labelsUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	c := newEventsCmd(args[0])
+	c.fs.Init("labels "+args[0], flag.ContinueOnError)
+	name := c.fs.String("name", "", "label name")
+	color := c.fs.String("color", "", "label color, #rrggbb")
+	if err := c.fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if args[0] != "list" && *name == "" {
+		return errors.New("-name is required")
+	}
+	cl := c.client()
+
+	switch args[0] {
+	case "list":
+		labels, err := cl.Labels(ctx)
+		if err != nil {
+			return err
+		}
+		return c.printLabels(labels)
+	case "add":
+		label, err := cl.CreateLabel(ctx, internalhttp.Label{Name: *name, Color: *color})
+		if err != nil {
+			return err
+		}
+		return c.printLabels([]internalhttp.Label{label})
+	case "update":
+		label, err := cl.UpdateLabel(ctx, internalhttp.Label{Name: *name, Color: *color})
+		if err != nil {
+			return err
+		}
+		return c.printLabels([]internalhttp.Label{label})
+	case "delete":
+		return cl.DeleteLabel(ctx, *name)
+	default:
+		return errors.New(labelsUsage)
+	}
+}
+
+func (c *eventsCmd) printLabels(labels []internalhttp.Label) error {
+	if c.asJSON {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(labels)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCOLOR")
+	for _, l := range labels {
+		fmt.Fprintf(w, "%s\t%s\n", l.Name, l.Color)
+	}
+	return w.Flush()
+}
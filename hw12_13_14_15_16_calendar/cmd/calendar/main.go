@@ -2,16 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
+	_ "time/tzdata" // часовые пояса для quickAdd в образе без tzdata
 
-	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
 	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/scheduler"
 	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
-	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	cachestorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/cache"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storer"
 )
 
 var configFile string
@@ -28,34 +31,200 @@ func main() {
 		return
 	}
 
-	config := NewConfig()
-	logg := logger.New(config.Logger.Level)
+	if flag.Arg(0) == "mint-key" {
+		if err := mintKey(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "mint-key: "+err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "events" {
+		if err := runEvents(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "calendars" {
+		if err := runCalendars(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "hours" {
+		if err := runHours(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "freebusy" {
+		if err := runFreeBusy(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
 
-	storage := memorystorage.New()
-	calendar := app.New(logg, storage)
+	if flag.Arg(0) == "backup" {
+		if err := runBackup(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "backup: "+err.Error())
+			os.Exit(1)
+		}
+		return
+	}
 
-	server := internalhttp.NewServer(logg, calendar)
+	if flag.Arg(0) == "restore" {
+		if err := runRestore(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "restore: "+err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "labels" {
+		if err := runLabels(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	if flag.Arg(0) == "notifications" {
+		if err := runNotifications(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	// all-in-one - API, планировщик и хранитель в одном процессе на общем хранилище
+	// и брокере в памяти, для небольших установок и демо. scheduler и storer - они же
+	// отдельными процессами, сообщения между ними идут через sql-хранилище.
+	// Без аргументов - только API.
+	mode := flag.Arg(0)
+	switch mode {
+	case "", "all-in-one", "scheduler", "storer":
+	default:
+		fmt.Fprintln(os.Stderr, "unknown command "+mode)
+		os.Exit(2)
+	}
+
+	config, err := NewConfig(configFile)
+	if err == nil {
+		err = config.Validate()
+	}
+	if err == nil && (mode == "scheduler" || mode == "storer") && config.Storage.Type != "sql" {
+		err = errors.New(mode + " needs sql storage to run as a separate process, use all-in-one instead")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to read config: "+err.Error())
+		os.Exit(1)
+	}
+	logg := logger.New(config.Logger.Level)
+
+	if config.Tracing.Enabled {
+		traces, err := setupTracing(config.Tracing)
+		if err != nil {
+			logg.Error("failed to set up tracing: " + err.Error())
+			os.Exit(1)
+		}
+		defer traces.Close()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	go func() {
-		<-ctx.Done()
+	storage, closeStorage, err := openStorage(ctx, config.Storage)
+	if err != nil {
+		logg.Error("failed to open storage: " + err.Error())
+		os.Exit(1) //nolint:gocritic
+	}
+	defer closeStorage(context.Background()) //nolint:errcheck
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
+	checks := map[string]internalhttp.HealthCheck{"storage": storage.Ping}
+	var b messageBroker
+	if mode != "" {
+		var closeBroker func(context.Context) error
+		b, closeBroker, err = openBroker(ctx, mode, config.Storage, logg)
+		if err != nil {
+			logg.Error("failed to open broker: " + err.Error())
+			os.Exit(1) //nolint:gocritic
+		}
+		defer closeBroker(context.Background()) //nolint:errcheck
+		checks["broker"] = b.Ping
+	}
 
-		if err := server.Stop(ctx); err != nil {
-			logg.Error("failed to stop http server: " + err.Error())
+	reload := newReloader(configFile, logg, config)
+	reload.onReload(func(c Config) {
+		if c.Logger.Level != reload.current.Logger.Level {
+			logg.SetLevel(c.Logger.Level) //nolint:errcheck // уровень проверен в Validate
+			logg.Info("log level is " + c.Logger.Level)
 		}
-	}()
+	})
+
+	services := map[string]service{}
+	if mode == "" || mode == "all-in-one" {
+		services["http server"] = serveHTTP(newServer(config, storage, checks, reload, logg))
+	}
+	if mode == "all-in-one" || mode == "scheduler" {
+		sc := config.Scheduler
+		lead, elect := electLeader(config, storage, logg)
+		if elect != nil {
+			services["leader election"] = elect
+		}
+		sched := scheduler.New(logg, storage, b, lead, scheduler.Config{
+			Topic:          sc.Topic,
+			Interval:       sc.Interval,
+			Retention:      sc.Retention,
+			TrashRetention: sc.TrashRetention,
+
+			DeferToWorkingHours: sc.DeferToWorkingHours,
+		})
+		reload.onReload(func(c Config) {
+			if c.Scheduler.Interval != reload.current.Scheduler.Interval {
+				sched.SetInterval(c.Scheduler.Interval)
+				logg.Info("scheduler interval is " + c.Scheduler.Interval.String())
+			}
+		})
+		services["scheduler"] = sched.Run
+	}
+	if mode == "all-in-one" || mode == "storer" {
+		notifier := newNotifier(config.Notify, logg)
+		services["storer"] = storer.New(logg, storage, b, config.Scheduler.Topic, notifier).Run
+	}
+	if cached, ok := storage.(*cachestorage.Storage); ok && config.Storage.Cache.StatsInterval > 0 {
+		services["cache stats"] = logCacheStats(cached, logg, config.Storage.Cache.StatsInterval)
+	}
+	go reload.watch(ctx)
 
-	logg.Info("calendar is running...")
+	if mode == "" {
+		logg.Info("calendar is running...")
+	} else {
+		logg.Info("calendar is running in " + mode + " mode...")
+	}
 
-	if err := server.Start(ctx); err != nil {
-		logg.Error("failed to start http server: " + err.Error())
+	runErr := runServices(ctx, services)
+	if cached, ok := storage.(*cachestorage.Storage); ok {
+		logg.Info("storage cache: " + cached.Stats().String())
+	}
+	// Хранилище в памяти сохраняется и после ошибки сервиса, иначе его данные пропадут.
+	if sc := config.Storage; sc.Type == "memory" && sc.File != "" {
+		if stats, err := persist(context.Background(), sc, storage); err != nil {
+			logg.Error("failed to save storage: " + err.Error())
+		} else {
+			logg.Info("storage saved to " + sc.File + ": " + stats.String())
+		}
+	}
+	if runErr != nil {
+		logg.Error("calendar stopped: " + runErr.Error())
 		cancel()
 		os.Exit(1) //nolint:gocritic
 	}
+	logg.Info("calendar stopped")
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/auth"
+)
+
+// mintKey выпускает API-ключ (или JWT с флагом -jwt) для пользователя.
+// Ключ печатается один раз, в конфиг кладётся только его хэш.
+func mintKey(args []string) error {
+	fs := flag.NewFlagSet("mint-key", flag.ContinueOnError)
+	userID := fs.String("user", "", "user ID the credential is issued for")
+	jwt := fs.Bool("jwt", false, "mint HS256 JWT signed with auth.secret instead of API key")
+	ttl := fs.Duration("ttl", 24*time.Hour, "JWT lifetime, 0 for no expiration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userID == "" {
+		return fmt.Errorf("-user is required")
+	}
+
+	if *jwt {
+		config, err := NewConfig(configFile)
+		if err != nil {
+			return err
+		}
+		var expiresAt time.Time
+		if *ttl > 0 {
+			expiresAt = time.Now().Add(*ttl)
+		}
+		token, err := auth.New(nil, config.Auth.Secret).NewToken(*userID, expiresAt)
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+		return nil
+	}
+
+	key, hash, err := auth.NewKey()
+	if err != nil {
+		return err
+	}
+	fmt.Println("api key: " + key)
+	fmt.Fprintf(os.Stderr, "add to [auth.keys] in config:\n%q = %q\n", hash, *userID)
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+)
+
+const notificationsUsage = `usage: calendar notifications <list|ack|snooze> [flags]
+
+Connection flags and environment are the same as for calendar events.`
+
+func runNotifications(args []string) error {
+	if len(args) == 0 {
+		return errors.New(notificationsUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	c := newEventsCmd(args[0])
+	c.fs.Init("notifications "+args[0], flag.ContinueOnError)
+	id := c.fs.String("id", "", "event ID of the notification")
+	d := c.fs.Duration("for", 10*time.Minute, "snooze duration")
+	if err := c.fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if args[0] != "list" && *id == "" {
+		return errors.New("-id is required")
+	}
+	cl := c.client()
+
+	var (
+		list []internalhttp.Notification
+		n    internalhttp.Notification
+		err  error
+	)
+	switch args[0] {
+	case "list":
+		list, err = cl.Notifications(ctx)
+	case "ack":
+		n, err = cl.AckNotification(ctx, *id)
+		list = []internalhttp.Notification{n}
+	case "snooze":
+		n, err = cl.SnoozeNotification(ctx, *id, *d)
+		list = []internalhttp.Notification{n}
+	default:
+		return errors.New(notificationsUsage)
+	}
+	if err != nil {
+		return err
+	}
+	return c.printNotifications(list)
+}
+
+func (c *eventsCmd) printNotifications(list []internalhttp.Notification) error {
+	if c.asJSON {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EVENT\tSTART\tTITLE\tSTATUS\tSNOOZED UNTIL")
+	for _, n := range list {
+		var until string
+		if n.SnoozedUntil != nil {
+			until = n.SnoozedUntil.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			n.EventID, n.Start.Local().Format("2006-01-02 15:04"), n.Title, n.Status, until)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storer"
+)
+
+// newNotifier собирает каналы доставки из конфига и выбирает их по пользователю.
+func newNotifier(c NotifyConf, logger storer.Logger) storer.Notifier {
+	client := &http.Client{Timeout: c.Timeout}
+	channels := map[string]storer.Notifier{
+		"log":     storer.NewLogNotifier(logger),
+		"webhook": storer.NewWebhookNotifier(c.Webhook.URL, client),
+		"chatbot": storer.NewChatBotNotifier(c.ChatBot.URL, c.ChatBot.Token, c.ChatBot.Chats, client),
+	}
+	users := make(map[string]storer.Notifier, len(c.Users))
+	for user, ch := range c.Users {
+		users[user] = channels[ch]
+	}
+	return storer.NewRouter(channels[c.Default], users)
+}
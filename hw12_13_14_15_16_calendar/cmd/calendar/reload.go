@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+)
+
+// reloader перечитывает конфиг по SIGHUP и применяет то, что можно поменять
+// без перезапуска. Неверный конфиг отклоняется, и остаётся прежний.
+type reloader struct {
+	path     string
+	logger   *logger.Logger
+	current  Config
+	appliers []func(Config)
+}
+
+func newReloader(path string, logg *logger.Logger, current Config) *reloader {
+	return &reloader{path: path, logger: logg, current: current}
+}
+
+// onReload добавляет применение настроек из нового конфига.
+func (r *reloader) onReload(apply func(Config)) {
+	r.appliers = append(r.appliers, apply)
+}
+
+func (r *reloader) watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload()
+		}
+	}
+}
+
+func (r *reloader) reload() {
+	config, err := NewConfig(r.path)
+	if err == nil {
+		err = config.Validate()
+	}
+	if err != nil {
+		r.logger.Error("config reload: " + err.Error() + ", keeping the current config")
+		return
+	}
+
+	for _, section := range restartRequired(r.current, config) {
+		r.logger.Warn("config reload: " + section + " changed, restart to apply")
+	}
+	// Пишем до применения: новый уровень логирования может скрыть это сообщение.
+	r.logger.Info("config reloaded from " + r.path)
+	for _, apply := range r.appliers {
+		apply(config)
+	}
+	r.current = config
+}
+
+// restartRequired - изменившиеся секции, которые применяются только при запуске.
+func restartRequired(old, config Config) []string {
+	var res []string
+	if old.HTTP != config.HTTP {
+		res = append(res, "http")
+	}
+	if !reflect.DeepEqual(old.Auth, config.Auth) {
+		res = append(res, "auth")
+	}
+	if old.Tracing != config.Tracing {
+		res = append(res, "tracing")
+	}
+	if old.RateLimit.Capacity != config.RateLimit.Capacity {
+		res = append(res, "ratelimit.capacity")
+	}
+	oldScheduler, scheduler := old.Scheduler, config.Scheduler
+	oldScheduler.Interval, scheduler.Interval = 0, 0
+	if oldScheduler != scheduler {
+		res = append(res, "scheduler")
+	}
+	if !reflect.DeepEqual(old.Notify, config.Notify) {
+		res = append(res, "notify")
+	}
+	if old.Storage != config.Storage {
+		res = append(res, "storage")
+	}
+	return res
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/auth"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+	memorybroker "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker/memory"
+	sqlbroker "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker/sql"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/leader"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/ratelimit"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/scheduler"
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+	cachestorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/cache"
+)
+
+// service работает до отмены контекста.
+type service func(ctx context.Context) error
+
+// runServices запускает сервисы с общим контекстом. Остановка любого из них
+// останавливает остальные, возвращается первая ошибка после завершения всех.
+func runServices(ctx context.Context, services map[string]service) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(services))
+	for name, run := range services {
+		name, run := name, run
+		go func() {
+			err := run(ctx)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+			}
+			cancel()
+			errs <- err
+		}()
+	}
+
+	var first error
+	for range services {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// newServer собирает HTTP API. Лимиты запросов применяются и при перезагрузке конфига.
+func newServer(
+	config Config, storage Storage, checks map[string]internalhttp.HealthCheck, reload *reloader, logg *logger.Logger,
+) *internalhttp.Server {
+	opts := []internalhttp.Option{
+		internalhttp.WithBuildInfo(buildInfo()),
+		internalhttp.WithReadinessChecks(checks),
+	}
+	if config.HTTP.IdempotencyTTL > 0 {
+		opts = append(opts, internalhttp.WithIdempotency(storage, config.HTTP.IdempotencyTTL))
+	}
+	if config.Auth.Enabled {
+		opts = append(opts, internalhttp.WithAuth(auth.New(config.Auth.Keys, config.Auth.Secret)))
+	}
+	// Лимитеры создаются и при выключенном ограничении, чтобы его можно было
+	// включить перезагрузкой конфига.
+	rl := config.RateLimit
+	readLimit := ratelimit.New(rl.Limit(rl.Read), rl.Capacity)
+	writeLimit := ratelimit.New(rl.Limit(rl.Write), rl.Capacity)
+	opts = append(opts, internalhttp.WithRateLimits(internalhttp.RateLimits{Read: readLimit, Write: writeLimit}))
+	reload.onReload(func(c Config) {
+		readLimit.SetLimit(c.RateLimit.Limit(c.RateLimit.Read))
+		writeLimit.SetLimit(c.RateLimit.Limit(c.RateLimit.Write))
+	})
+
+	return internalhttp.NewServer(logg, app.New(logg, storage), config.HTTP.Addr(), opts...)
+}
+
+// serveHTTP - HTTP-сервер как service, по отмене контекста дожидается текущих запросов.
+func serveHTTP(server *internalhttp.Server) service {
+	return func(ctx context.Context) error {
+		stopped := make(chan error, 1)
+		go func() {
+			<-ctx.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			defer cancel()
+			stopped <- server.Stop(ctx)
+		}()
+
+		if err := server.Start(ctx); err != nil {
+			return err
+		}
+		if err := <-stopped; err != nil {
+			return fmt.Errorf("stop: %w", err)
+		}
+		return nil
+	}
+}
+
+// messageBroker - очередь уведомлений от планировщика к хранителю.
+type messageBroker interface {
+	broker.Producer
+	broker.Consumer
+	Ping(ctx context.Context) error
+}
+
+// openBroker - в all-in-one планировщику и хранителю хватает брокера в памяти,
+// отдельные процессы обмениваются сообщениями через таблицу в sql-хранилище.
+func openBroker(
+	ctx context.Context, mode string, c StorageConf, logg *logger.Logger,
+) (messageBroker, func(context.Context) error, error) {
+	if mode == "all-in-one" {
+		return memorybroker.New(), func(context.Context) error { return nil }, nil
+	}
+	b := sqlbroker.New(logg, c.DSN)
+	if err := b.Connect(ctx); err != nil {
+		return nil, nil, fmt.Errorf("broker: %w", err)
+	}
+	return b, b.Close, nil
+}
+
+// electLeader - с sql-хранилищем планировщиков может быть несколько, сканирует только
+// держатель аренды, и выбор лидера работает как отдельный service. С хранилищем
+// в памяти планировщик один, лидер не нужен: возвращается nil.
+func electLeader(c Config, locker leader.Locker, logg *logger.Logger) (scheduler.Leader, service) {
+	if c.Storage.Type != "sql" {
+		return nil, nil
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "calendar"
+	}
+	e := leader.New(logg, locker, "scheduler", host+"-"+strconv.Itoa(os.Getpid()), c.Scheduler.LeaseTTL)
+	return e, e.Run
+}
+
+// logCacheStats раз в interval пишет в лог счётчики кэша хранилища.
+func logCacheStats(cached *cachestorage.Storage, logg *logger.Logger, interval time.Duration) service {
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				logg.Info("storage cache: " + cached.Stats().String())
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/backup"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/leader"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/scheduler"
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+	cachestorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/cache"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	sqlstorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/sql"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storer"
+)
+
+// Storage - то, что от хранилища нужно сервисам, ключам идемпотентности HTTP API,
+// выбору лидера планировщика и командам backup и restore.
+type Storage interface {
+	app.Storage
+	scheduler.Storage
+	storer.Storage
+	backup.Source
+	backup.Target
+	internalhttp.IdempotencyStore
+	leader.Locker
+	Ping(ctx context.Context) error
+}
+
+// openStorage открывает хранилище из конфига, closeStorage закрывает соединения.
+// Хранилище в памяти загружается из storage.file, сохраняет его persist.
+// Со storage.cache хранилище оборачивается кэшем списков событий.
+func openStorage(ctx context.Context, c StorageConf) (s Storage, closeStorage func(context.Context) error, err error) {
+	s, closeStorage, err = openBackend(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.Cache.Enabled {
+		s = cachestorage.New(s, c.Cache.Size, c.Cache.TTL)
+	}
+	return s, closeStorage, nil
+}
+
+func openBackend(ctx context.Context, c StorageConf) (Storage, func(context.Context) error, error) {
+	if c.Type == "sql" {
+		st := sqlstorage.New(c.DSN)
+		if err := st.Connect(ctx); err != nil {
+			return nil, nil, fmt.Errorf("storage: %w", err)
+		}
+		return st, st.Close, nil
+	}
+
+	st := memorystorage.New()
+	if c.File != "" {
+		if err := loadArchive(ctx, c.File, st); err != nil {
+			return nil, nil, fmt.Errorf("storage: %w", err)
+		}
+	}
+	return st, func(context.Context) error { return nil }, nil
+}
+
+// persist сохраняет хранилище в памяти в storage.file, sql-хранилищу это не нужно.
+func persist(ctx context.Context, c StorageConf, s Storage) (backup.Stats, error) {
+	if c.Type == "sql" || c.File == "" {
+		return backup.Stats{}, nil
+	}
+	return saveArchive(ctx, c.File, s)
+}
+
+// loadArchive загружает архив в хранилище, отсутствующий файл - пустое хранилище.
+func loadArchive(ctx context.Context, path string, dst backup.Target) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := backup.Restore(ctx, f, dst); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// saveArchive пишет архив во временный файл рядом и переименовывает его,
+// чтобы прерванная запись не испортила прежний архив.
+func saveArchive(ctx context.Context, path string, src backup.Source) (backup.Stats, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return backup.Stats{}, err
+	}
+	defer os.Remove(f.Name())
+
+	stats, err := backup.Write(ctx, f, src, time.Now())
+	if err != nil {
+		f.Close()
+		return backup.Stats{}, err
+	}
+	if err := f.Close(); err != nil {
+		return backup.Stats{}, err
+	}
+	return stats, os.Rename(f.Name(), path)
+}
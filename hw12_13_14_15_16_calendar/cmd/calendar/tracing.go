@@ -0,0 +1,22 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/tracing"
+)
+
+func setupTracing(conf TracingConf) (io.Closer, error) {
+	if conf.Output == "" || conf.Output == "stdout" {
+		tracing.SetExporter(tracing.NewJSONExporter(os.Stdout, "calendar"))
+		return io.NopCloser(nil), nil
+	}
+
+	f, err := os.OpenFile(conf.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	tracing.SetExporter(tracing.NewJSONExporter(f, "calendar"))
+	return f, nil
+}
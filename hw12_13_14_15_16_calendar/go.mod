@@ -1,3 +1,16 @@
-module github.com/fixme_my_friend/hw12_13_14_15_calendar
+module github.com/fixme_my_friend/hw12_13_14_15_16_calendar
 
 go 1.19
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -2,25 +2,239 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
 )
 
-type App struct { // TODO
+var ErrInvalidEvent = errors.New("invalid event")
+
+const maxReminders = 10
+
+type App struct {
+	logger  Logger
+	storage Storage
+	now     func() time.Time
 }
 
-type Logger interface { // TODO
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
 }
 
-type Storage interface { // TODO
+type Storage interface {
+	CreateEvent(ctx context.Context, event storage.Event) error
+	UpdateEvent(ctx context.Context, id string, event storage.Event) error
+	DeleteEvent(ctx context.Context, id string) error
+	GetEvent(ctx context.Context, id string) (storage.Event, error)
+	ListEvents(ctx context.Context, userID string, from, to time.Time) ([]storage.Event, error)
+	CreateLabel(ctx context.Context, label storage.Label) error
+	UpdateLabel(ctx context.Context, label storage.Label) error
+	DeleteLabel(ctx context.Context, userID, name string) error
+	ListLabels(ctx context.Context, userID string) ([]storage.Label, error)
+	SaveNotification(ctx context.Context, n storage.Notification) error
+	GetNotification(ctx context.Context, eventID string) (storage.Notification, error)
+	ListNotifications(ctx context.Context, userID string) ([]storage.Notification, error)
+	TrashEvent(ctx context.Context, id string, at time.Time) error
+	UntrashEvent(ctx context.Context, id string) error
+	ListTrash(ctx context.Context, userID string) ([]storage.Event, error)
+	AppendAudit(ctx context.Context, entry storage.AuditEntry) (storage.AuditEntry, error)
+	ListAudit(ctx context.Context, eventID string) ([]storage.AuditEntry, error)
+	CreateCalendar(ctx context.Context, c storage.Calendar) error
+	UpdateCalendar(ctx context.Context, c storage.Calendar) error
+	DeleteCalendar(ctx context.Context, id string) error
+	GetCalendar(ctx context.Context, id string) (storage.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error)
+	ShareCalendar(ctx context.Context, calendarID, userID string, access storage.Access) error
+	UnshareCalendar(ctx context.Context, calendarID, userID string) error
+	ListCalendarEvents(ctx context.Context, calendarIDs []string, from, to time.Time) ([]storage.Event, error)
+	GetWorkingHours(ctx context.Context, userID string) (storage.WorkingHours, error)
+	SaveWorkingHours(ctx context.Context, w storage.WorkingHours) error
+	DeleteWorkingHours(ctx context.Context, userID string) error
+	// Atomic выполняет fn в транзакции, вызовы хранилища с ctx из fn входят в неё.
+	Atomic(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 func New(logger Logger, storage Storage) *App {
-	return &App{}
+	return &App{
+		logger:  logger,
+		storage: storage,
+		now:     time.Now,
+	}
 }
 
-func (a *App) CreateEvent(ctx context.Context, id, title string) error {
-	// TODO
-	return nil
-	// return a.storage.CreateEvent(storage.Event{ID: id, Title: title})
+// CreateEvent создаёт событие от имени userID, ID генерируется, если не задан.
+// Событие в чужом календаре принадлежит владельцу календаря, userID остаётся в журнале как автор.
+func (a *App) CreateEvent(ctx context.Context, userID string, event storage.Event) (storage.Event, error) {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	owner, err := a.calendarOwner(ctx, userID, event.CalendarID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	event.UserID = owner
+	event.DeletedAt = time.Time{}
+	if err := validate(event); err != nil {
+		return storage.Event{}, err
+	}
+	if err := a.checkLabels(ctx, event); err != nil {
+		return storage.Event{}, err
+	}
+
+	err = a.audited(ctx, userID, storage.AuditCreate, nil, &event, func(ctx context.Context) error {
+		return a.storage.CreateEvent(ctx, event)
+	})
+	if err != nil {
+		return storage.Event{}, err
+	}
+	return event, nil
+}
+
+func (a *App) UpdateEvent(ctx context.Context, userID, id string, event storage.Event) (storage.Event, error) {
+	before, err := a.writableEvent(ctx, userID, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	owner, err := a.calendarOwner(ctx, userID, event.CalendarID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if owner != before.UserID {
+		return storage.Event{}, fmt.Errorf("%w: event cannot be moved to another owner's calendar", ErrInvalidEvent)
+	}
+
+	event.ID = id
+	event.UserID = owner
+	event.DeletedAt = time.Time{}
+	if err := validate(event); err != nil {
+		return storage.Event{}, err
+	}
+	if err := a.checkLabels(ctx, event); err != nil {
+		return storage.Event{}, err
+	}
+
+	err = a.audited(ctx, userID, storage.AuditUpdate, &before, &event, func(ctx context.Context) error {
+		return a.storage.UpdateEvent(ctx, id, event)
+	})
+	if err != nil {
+		return storage.Event{}, err
+	}
+	return event, nil
+}
+
+// DeleteEvent переносит событие в корзину, окончательно его удаляет планировщик.
+func (a *App) DeleteEvent(ctx context.Context, userID, id string) error {
+	before, err := a.writableEvent(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return a.audited(ctx, userID, storage.AuditDelete, &before, nil, func(ctx context.Context) error {
+		return a.storage.TrashEvent(ctx, id, a.now().UTC())
+	})
+}
+
+// GetEvent не отдаёт события в корзине и чужие события вне открытых пользователю
+// календарей, для них возвращается ErrEventNotFound.
+func (a *App) GetEvent(ctx context.Context, userID, id string) (storage.Event, error) {
+	event, err := a.storage.GetEvent(ctx, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if event.Trashed() {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	access, err := a.eventAccess(ctx, userID, event)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if !access.CanRead() {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	return event, nil
+}
+
+func (a *App) ListDay(ctx context.Context, userID string, date time.Time, filter Filter) ([]storage.Event, error) {
+	from := startOfDay(date)
+	return a.list(ctx, userID, from, from.AddDate(0, 0, 1), filter)
 }
 
-// TODO
+func (a *App) ListWeek(ctx context.Context, userID string, date time.Time, filter Filter) ([]storage.Event, error) {
+	from := startOfDay(date)
+	return a.list(ctx, userID, from, from.AddDate(0, 0, 7), filter)
+}
+
+func (a *App) ListMonth(ctx context.Context, userID string, date time.Time, filter Filter) ([]storage.Event, error) {
+	from := startOfDay(date)
+	return a.list(ctx, userID, from, from.AddDate(0, 1, 0), filter)
+}
+
+// ListAll - все события пользователя вместе с событиями открытых ему календарей, для экспорта.
+func (a *App) ListAll(ctx context.Context, userID string, filter Filter) ([]storage.Event, error) {
+	return a.list(ctx, userID, time.Time{}, endOfTime, filter)
+}
+
+// endOfTime - верхняя граница ListAll, позже неё событий не бывает.
+var endOfTime = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// list - события пользователя вместе с событиями открытых ему календарей.
+func (a *App) list(ctx context.Context, userID string, from, to time.Time, filter Filter) ([]storage.Event, error) {
+	events, err := a.storage.ListEvents(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := a.sharedEvents(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return filter.apply(mergeEvents(events, shared)), nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func validate(event storage.Event) error {
+	switch {
+	case event.UserID == "":
+		return fmt.Errorf("%w: user id is required", ErrInvalidEvent)
+	case event.Title == "":
+		return fmt.Errorf("%w: title is required", ErrInvalidEvent)
+	case event.Start.IsZero():
+		return fmt.Errorf("%w: start is required", ErrInvalidEvent)
+	case !event.End.After(event.Start):
+		return fmt.Errorf("%w: end must be after start", ErrInvalidEvent)
+	case event.NotifyBefore < 0:
+		return fmt.Errorf("%w: notify before must not be negative", ErrInvalidEvent)
+	case len(event.Reminders) > maxReminders:
+		return fmt.Errorf("%w: at most %d reminders are allowed", ErrInvalidEvent, maxReminders)
+	}
+	return validateReminders(event.Reminders)
+}
+
+func validateReminders(reminders []storage.Reminder) error {
+	seen := make(map[string]bool, len(reminders))
+	for _, r := range reminders {
+		switch r.Anchor {
+		case storage.AnchorStart, storage.AnchorEnd:
+			if !r.At.IsZero() {
+				return fmt.Errorf("%w: reminder is either relative or absolute", ErrInvalidEvent)
+			}
+		case "":
+			if r.At.IsZero() {
+				return fmt.Errorf("%w: absolute reminder needs a time", ErrInvalidEvent)
+			}
+		default:
+			return fmt.Errorf("%w: unknown reminder anchor %q", ErrInvalidEvent, r.Anchor)
+		}
+		if seen[r.ID()] {
+			return fmt.Errorf("%w: duplicate reminder %s", ErrInvalidEvent, r.ID())
+		}
+		seen[r.ID()] = true
+	}
+	return nil
+}
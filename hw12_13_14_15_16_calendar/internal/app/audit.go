@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// audited выполняет изменение события change и пишет запись о нём в журнал в одной
+// транзакции: если запись не удалась, изменение откатывается и возвращается ошибка.
+// Внутри атомарного пакета ошибка журнала так достаётся своей операции, а не следующей.
+func (a *App) audited(
+	ctx context.Context, actor string, action storage.AuditAction, before, after *storage.Event,
+	change func(ctx context.Context) error,
+) error {
+	entry := storage.AuditEntry{
+		Actor:  actor,
+		Action: action,
+		At:     a.now().UTC(),
+		Before: before,
+		After:  after,
+	}
+	if after != nil {
+		entry.EventID, entry.UserID = after.ID, after.UserID
+	} else {
+		entry.EventID, entry.UserID = before.ID, before.UserID
+	}
+
+	return a.storage.Atomic(ctx, func(ctx context.Context) error {
+		if err := change(ctx); err != nil {
+			return err
+		}
+		if _, err := a.storage.AppendAudit(ctx, entry); err != nil {
+			a.logger.Error("audit " + string(action) + " " + entry.EventID + ": " + err.Error())
+			return fmt.Errorf("audit: %w", err)
+		}
+		return nil
+	})
+}
+
+// History - журнал изменений события, в том числе уже удалённого.
+func (a *App) History(ctx context.Context, userID, id string) ([]storage.AuditEntry, error) {
+	history, _, err := a.history(ctx, userID, id)
+	return history, err
+}
+
+// history - журнал события и права userID на него по последнему состоянию события.
+func (a *App) history(ctx context.Context, userID, id string) ([]storage.AuditEntry, storage.Access, error) {
+	history, err := a.storage.ListAudit(ctx, id)
+	if err != nil {
+		return nil, storage.AccessNone, err
+	}
+	if len(history) == 0 {
+		return nil, storage.AccessNone, storage.ErrEventNotFound
+	}
+	access, err := a.eventAccess(ctx, userID, history[len(history)-1].Snapshot())
+	if err != nil {
+		return nil, storage.AccessNone, err
+	}
+	if !access.CanRead() {
+		return nil, storage.AccessNone, storage.ErrEventNotFound
+	}
+	return history, access, nil
+}
+
+// RestoreEvent возвращает событие к состоянию из записи журнала version.
+// Удалённое событие создаётся заново с тем же ID.
+func (a *App) RestoreEvent(ctx context.Context, userID, id string, version int) (storage.Event, error) {
+	history, access, err := a.history(ctx, userID, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if !access.CanWrite() {
+		return storage.Event{}, ErrForbidden
+	}
+	if version < 1 || version > len(history) {
+		return storage.Event{}, fmt.Errorf("%w: no version %d", storage.ErrEventNotFound, version)
+	}
+	event := history[version-1].Snapshot()
+	event.DeletedAt = time.Time{}
+	if event.CalendarID != "" {
+		// Календарь могли удалить, тогда событие вернётся в личный календарь владельца.
+		if _, err := a.storage.GetCalendar(ctx, event.CalendarID); errors.Is(err, storage.ErrCalendarNotFound) {
+			event.CalendarID = ""
+		}
+	}
+
+	if err := validate(event); err != nil {
+		return storage.Event{}, err
+	}
+	if err := a.checkLabels(ctx, event); err != nil {
+		return storage.Event{}, err
+	}
+
+	var before *storage.Event
+	current, err := a.storage.GetEvent(ctx, id)
+	switch {
+	case err == nil:
+		before = &current
+	case !errors.Is(err, storage.ErrEventNotFound):
+		return storage.Event{}, err
+	}
+	err = a.audited(ctx, userID, storage.AuditRestore, before, &event, func(ctx context.Context) error {
+		if before != nil {
+			return a.storage.UpdateEvent(ctx, id, event)
+		}
+		return a.storage.CreateEvent(ctx, event)
+	})
+	if err != nil {
+		return storage.Event{}, err
+	}
+	return event, nil
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+var errAudit = errors.New("audit is unavailable")
+
+// brokenAudit не пишет в журнал записи о событиях с названием "broken".
+type brokenAudit struct {
+	*memorystorage.Storage
+}
+
+func (s brokenAudit) AppendAudit(ctx context.Context, entry storage.AuditEntry) (storage.AuditEntry, error) {
+	if entry.Snapshot().Title == "broken" {
+		return storage.AuditEntry{}, errAudit
+	}
+	return s.Storage.AppendAudit(ctx, entry)
+}
+
+func TestAuditFailure(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	event := func(title string, hour int) storage.Event {
+		from := start.Add(time.Duration(hour) * time.Hour)
+		return storage.Event{Title: title, Start: from, End: from.Add(time.Hour)}
+	}
+	t.Run("change is rolled back", func(t *testing.T) {
+		st := memorystorage.New()
+		a := New(logger.Discard(), brokenAudit{st})
+
+		_, err := a.CreateEvent(ctx, "u", event("broken", 0))
+		require.ErrorIs(t, err, errAudit)
+		events, err := st.ListEvents(ctx, "u", start, start.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		require.Empty(t, events)
+
+		created, err := a.CreateEvent(ctx, "u", event("ok", 0))
+		require.NoError(t, err)
+		_, err = a.UpdateEvent(ctx, "u", created.ID, event("broken", 1))
+		require.ErrorIs(t, err, errAudit)
+		got, err := a.GetEvent(ctx, "u", created.ID)
+		require.NoError(t, err)
+		require.Equal(t, "ok", got.Title)
+	})
+
+	t.Run("atomic batch blames the right operation", func(t *testing.T) {
+		st := memorystorage.New()
+		a := New(logger.Discard(), brokenAudit{st})
+
+		results, err := a.Batch(ctx, "u", []BatchOp{
+			{Kind: BatchCreate, Event: event("first", 0)},
+			{Kind: BatchCreate, Event: event("broken", 1)},
+			{Kind: BatchCreate, Event: event("third", 2)},
+		}, true)
+		require.NoError(t, err)
+		require.ErrorIs(t, results[0].Err, ErrBatchAborted)
+		require.ErrorIs(t, results[1].Err, errAudit)
+		require.ErrorIs(t, results[2].Err, ErrBatchAborted)
+
+		events, err := st.ListEvents(ctx, "u", start, start.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+var (
+	ErrInvalidWorkingHours = errors.New("invalid working hours")
+	ErrInvalidRange        = errors.New("invalid time range")
+)
+
+const (
+	maxHolidays      = 366
+	maxFreeBusyRange = 62 * 24 * time.Hour
+	maxFreeBusyUsers = 20
+)
+
+// FreeBusy - занятость пользователя: Busy - время его событий, Free - рабочее время
+// без событий. Без настроенного рабочего времени (WorkingHours = false) свободно всё, что не занято.
+type FreeBusy struct {
+	UserID       string
+	WorkingHours bool
+	Busy         []storage.Interval
+	Free         []storage.Interval
+}
+
+func (a *App) GetWorkingHours(ctx context.Context, userID string) (storage.WorkingHours, error) {
+	return a.storage.GetWorkingHours(ctx, userID)
+}
+
+// SetWorkingHours задаёт рабочее время пользователя целиком, прежнее заменяется.
+func (a *App) SetWorkingHours(
+	ctx context.Context, userID string, w storage.WorkingHours,
+) (storage.WorkingHours, error) {
+	w.UserID = userID
+	if err := validateWorkingHours(w); err != nil {
+		return storage.WorkingHours{}, err
+	}
+	if err := a.storage.SaveWorkingHours(ctx, w); err != nil {
+		return storage.WorkingHours{}, err
+	}
+	return w, nil
+}
+
+func (a *App) DeleteWorkingHours(ctx context.Context, userID string) error {
+	return a.storage.DeleteWorkingHours(ctx, userID)
+}
+
+// FreeBusy считает занятость users в [from, to). Кроме себя можно смотреть только
+// владельцев календарей, открытых пользователю: им он и так назначает события.
+func (a *App) FreeBusy(ctx context.Context, userID string, users []string, from, to time.Time) ([]FreeBusy, error) {
+	switch {
+	case !to.After(from):
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidRange)
+	case to.Sub(from) > maxFreeBusyRange:
+		return nil, fmt.Errorf("%w: at most %s", ErrInvalidRange, maxFreeBusyRange)
+	case len(users) > maxFreeBusyUsers:
+		return nil, fmt.Errorf("%w: at most %d users", ErrInvalidRange, maxFreeBusyUsers)
+	}
+	if len(users) == 0 {
+		users = []string{userID}
+	}
+	if err := a.checkVisible(ctx, userID, users); err != nil {
+		return nil, err
+	}
+
+	res := make([]FreeBusy, 0, len(users))
+	for _, user := range users {
+		fb, err := a.freeBusy(ctx, user, from, to)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, fb)
+	}
+	return res, nil
+}
+
+func (a *App) checkVisible(ctx context.Context, userID string, users []string) error {
+	visible := map[string]bool{userID: true}
+	calendars, err := a.storage.ListCalendars(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, c := range calendars {
+		visible[c.OwnerID] = true
+	}
+	for _, user := range users {
+		if !visible[user] {
+			return fmt.Errorf("%w: free/busy of %s", ErrForbidden, user)
+		}
+	}
+	return nil
+}
+
+func (a *App) freeBusy(ctx context.Context, userID string, from, to time.Time) (FreeBusy, error) {
+	events, err := a.storage.ListEvents(ctx, userID, from, to)
+	if err != nil {
+		return FreeBusy{}, err
+	}
+	fb := FreeBusy{UserID: userID, Busy: make([]storage.Interval, 0, len(events))}
+	for _, e := range events {
+		fb.Busy = addInterval(fb.Busy, clip(storage.Interval{Start: e.Start, End: e.End}, from, to))
+	}
+
+	available := []storage.Interval{{Start: from, End: to}}
+	w, err := a.storage.GetWorkingHours(ctx, userID)
+	switch {
+	case err == nil:
+		fb.WorkingHours = true
+		available = w.Periods(from, to)
+	case !errors.Is(err, storage.ErrWorkingHoursNotFound):
+		return FreeBusy{}, err
+	}
+	fb.Free = subtract(available, fb.Busy)
+	return fb, nil
+}
+
+func clip(i storage.Interval, from, to time.Time) storage.Interval {
+	if i.Start.Before(from) {
+		i.Start = from
+	}
+	if i.End.After(to) {
+		i.End = to
+	}
+	return i
+}
+
+// addInterval добавляет интервал к упорядоченным по началу непересекающимся
+// интервалам, события приходят по времени начала, поэтому сливать нужно только с последним.
+func addInterval(list []storage.Interval, i storage.Interval) []storage.Interval {
+	if n := len(list); n > 0 && !i.Start.After(list[n-1].End) {
+		if i.End.After(list[n-1].End) {
+			list[n-1].End = i.End
+		}
+		return list
+	}
+	return append(list, i)
+}
+
+// subtract - части интервалов from, не покрытые busy. Оба списка упорядочены и без пересечений.
+func subtract(from, busy []storage.Interval) []storage.Interval {
+	res := make([]storage.Interval, 0, len(from))
+	for _, f := range from {
+		start := f.Start
+		for _, b := range busy {
+			if !b.End.After(start) || !b.Start.Before(f.End) {
+				continue
+			}
+			if b.Start.After(start) {
+				res = append(res, storage.Interval{Start: start, End: b.Start})
+			}
+			start = b.End
+		}
+		if start.Before(f.End) {
+			res = append(res, storage.Interval{Start: start, End: f.End})
+		}
+	}
+	return res
+}
+
+func validateWorkingHours(w storage.WorkingHours) error {
+	if _, err := time.LoadLocation(w.TimeZone); err != nil || w.TimeZone == "" {
+		return fmt.Errorf("%w: unknown time zone %q", ErrInvalidWorkingHours, w.TimeZone)
+	}
+	for d, p := range w.Week {
+		if p.Start < 0 || p.End > 24*time.Hour || (p.End < p.Start) {
+			return fmt.Errorf("%w: %s: period must be within the day", ErrInvalidWorkingHours, time.Weekday(d))
+		}
+	}
+	if len(w.Holidays) > maxHolidays {
+		return fmt.Errorf("%w: at most %d holidays", ErrInvalidWorkingHours, maxHolidays)
+	}
+	for _, h := range w.Holidays {
+		if _, err := time.Parse(storage.HolidayLayout, h); err != nil {
+			return fmt.Errorf("%w: holiday %q is not a date", ErrInvalidWorkingHours, h)
+		}
+	}
+	return nil
+}
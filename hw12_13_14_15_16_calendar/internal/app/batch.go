@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+var (
+	ErrInvalidBatch = errors.New("invalid batch")
+	// ErrBatchAborted - операция не выполнена, потому что в атомарном пакете упала другая.
+	ErrBatchAborted = errors.New("batch aborted")
+)
+
+const maxBatchSize = 500
+
+type BatchOpKind string
+
+const (
+	BatchCreate BatchOpKind = "create"
+	BatchUpdate BatchOpKind = "update"
+	BatchDelete BatchOpKind = "delete"
+)
+
+// BatchOp - операция пакета: для create и update нужно Event, для update и delete - ID.
+// Rejected - операция отклонена ещё при разборе запроса, эта ошибка и будет её итогом.
+type BatchOp struct {
+	Kind     BatchOpKind
+	ID       string
+	Event    storage.Event
+	Rejected error
+}
+
+// BatchResult - итог операции: Event созданного или изменённого события либо Err.
+type BatchResult struct {
+	Event storage.Event
+	Err   error
+}
+
+// Batch выполняет операции по порядку. В атомарном режиме всё происходит в одной
+// транзакции хранилища: при первой ошибке изменения откатываются, у упавшей операции
+// в результате её ошибка, у остальных - ErrBatchAborted. Иначе каждая операция
+// выполняется сама по себе.
+func (a *App) Batch(ctx context.Context, userID string, ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: no operations", ErrInvalidBatch)
+	}
+	if len(ops) > maxBatchSize {
+		return nil, fmt.Errorf("%w: at most %d operations are allowed", ErrInvalidBatch, maxBatchSize)
+	}
+
+	results := make([]BatchResult, len(ops))
+	if !atomic {
+		for i, op := range ops {
+			results[i] = a.apply(ctx, userID, op)
+		}
+		return results, nil
+	}
+
+	failed := -1
+	err := a.storage.Atomic(ctx, func(ctx context.Context) error {
+		for i, op := range ops {
+			results[i] = a.apply(ctx, userID, op)
+			if results[i].Err != nil {
+				failed = i
+				return results[i].Err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return results, nil
+	}
+	if failed < 0 {
+		// Упала сама транзакция, например commit.
+		return nil, err
+	}
+	for i := range results {
+		if i != failed {
+			results[i] = BatchResult{Err: ErrBatchAborted}
+		}
+	}
+	return results, nil
+}
+
+func (a *App) apply(ctx context.Context, userID string, op BatchOp) BatchResult {
+	var res BatchResult
+	if op.Rejected != nil {
+		res.Err = op.Rejected
+		return res
+	}
+	switch op.Kind {
+	case BatchCreate:
+		res.Event, res.Err = a.CreateEvent(ctx, userID, op.Event)
+	case BatchUpdate:
+		res.Event, res.Err = a.UpdateEvent(ctx, userID, op.ID, op.Event)
+	case BatchDelete:
+		res.Err = a.DeleteEvent(ctx, userID, op.ID)
+	default:
+		res.Err = fmt.Errorf("%w: unknown operation %q", ErrInvalidBatch, op.Kind)
+	}
+	return res
+}
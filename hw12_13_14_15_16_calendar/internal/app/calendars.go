@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCalendar = errors.New("invalid calendar")
+	// ErrForbidden - событие или календарь пользователю видны, но менять их он не может.
+	ErrForbidden = errors.New("permission denied")
+)
+
+const maxCalendarName = 64
+
+// CreateCalendar создаёт календарь пользователя, ID генерируется, если не задан.
+func (a *App) CreateCalendar(ctx context.Context, userID string, c storage.Calendar) (storage.Calendar, error) {
+	if c.ID == "" {
+		c.ID = uuid.NewString()
+	}
+	c.OwnerID = userID
+	c.Shares = map[string]storage.Access{}
+	if err := validateCalendar(c); err != nil {
+		return storage.Calendar{}, err
+	}
+	if err := a.storage.CreateCalendar(ctx, c); err != nil {
+		return storage.Calendar{}, err
+	}
+	return c, nil
+}
+
+// UpdateCalendar переименовывает календарь, это может только владелец.
+func (a *App) UpdateCalendar(ctx context.Context, userID, id string, c storage.Calendar) (storage.Calendar, error) {
+	current, err := a.ownCalendar(ctx, userID, id)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+	current.Name = c.Name
+	if err := validateCalendar(current); err != nil {
+		return storage.Calendar{}, err
+	}
+	if err := a.storage.UpdateCalendar(ctx, current); err != nil {
+		return storage.Calendar{}, err
+	}
+	return current, nil
+}
+
+// DeleteCalendar удаляет календарь, его события остаются у владельца в личном календаре.
+func (a *App) DeleteCalendar(ctx context.Context, userID, id string) error {
+	if _, err := a.ownCalendar(ctx, userID, id); err != nil {
+		return err
+	}
+	return a.storage.DeleteCalendar(ctx, id)
+}
+
+// GetCalendar не отдаёт календари, к которым у пользователя нет доступа.
+func (a *App) GetCalendar(ctx context.Context, userID, id string) (storage.Calendar, error) {
+	c, err := a.storage.GetCalendar(ctx, id)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+	if !c.Access(userID).CanRead() {
+		return storage.Calendar{}, storage.ErrCalendarNotFound
+	}
+	return c, nil
+}
+
+// ListCalendars - свои календари и открытые пользователю.
+func (a *App) ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error) {
+	return a.storage.ListCalendars(ctx, userID)
+}
+
+// CalendarEvents - события одного календаря, пересекающиеся с [from, to).
+// Пустой calendarID - личный календарь пользователя без событий его именованных календарей.
+func (a *App) CalendarEvents(
+	ctx context.Context, userID, calendarID string, from, to time.Time,
+) ([]storage.Event, error) {
+	if calendarID != "" {
+		if _, err := a.GetCalendar(ctx, userID, calendarID); err != nil {
+			return nil, err
+		}
+		return a.storage.ListCalendarEvents(ctx, []string{calendarID}, from, to)
+	}
+
+	events, err := a.storage.ListEvents(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]storage.Event, 0, len(events))
+	for _, e := range events {
+		if e.CalendarID == "" {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+// ShareCalendar открывает календарь пользователю shareWith на чтение или запись.
+func (a *App) ShareCalendar(
+	ctx context.Context, userID, id, shareWith string, access storage.Access,
+) (storage.Calendar, error) {
+	c, err := a.ownCalendar(ctx, userID, id)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+	switch {
+	case shareWith == "":
+		return storage.Calendar{}, fmt.Errorf("%w: user id is required", ErrInvalidCalendar)
+	case shareWith == c.OwnerID:
+		return storage.Calendar{}, fmt.Errorf("%w: calendar cannot be shared with its owner", ErrInvalidCalendar)
+	case access != storage.AccessRead && access != storage.AccessWrite:
+		return storage.Calendar{}, fmt.Errorf("%w: access must be read or write", ErrInvalidCalendar)
+	}
+
+	if err := a.storage.ShareCalendar(ctx, id, shareWith, access); err != nil {
+		return storage.Calendar{}, err
+	}
+	c.Shares[shareWith] = access
+	return c, nil
+}
+
+// UnshareCalendar закрывает доступ shareWith. Владелец закрывает его кому угодно,
+// остальные могут только отказаться от календаря, открытого им самим.
+func (a *App) UnshareCalendar(ctx context.Context, userID, id, shareWith string) error {
+	c, err := a.GetCalendar(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if c.OwnerID != userID && shareWith != userID {
+		return ErrForbidden
+	}
+	return a.storage.UnshareCalendar(ctx, id, shareWith)
+}
+
+// ownCalendar - календарь, который userID может менять как владелец.
+func (a *App) ownCalendar(ctx context.Context, userID, id string) (storage.Calendar, error) {
+	c, err := a.GetCalendar(ctx, userID, id)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+	if c.OwnerID != userID {
+		return storage.Calendar{}, ErrForbidden
+	}
+	return c, nil
+}
+
+// calendarOwner - чьим будет событие userID в календаре calendarID. Пустой
+// календарь - личный календарь самого userID, в чужой нужны права на запись.
+func (a *App) calendarOwner(ctx context.Context, userID, calendarID string) (string, error) {
+	if calendarID == "" {
+		return userID, nil
+	}
+	c, err := a.GetCalendar(ctx, userID, calendarID)
+	if err != nil {
+		return "", err
+	}
+	if !c.Access(userID).CanWrite() {
+		return "", ErrForbidden
+	}
+	return c.OwnerID, nil
+}
+
+// eventAccess - права userID на событие: владельцу всё, остальным - по календарю события.
+func (a *App) eventAccess(ctx context.Context, userID string, e storage.Event) (storage.Access, error) {
+	if e.UserID == userID {
+		return storage.AccessOwner, nil
+	}
+	if e.CalendarID == "" {
+		return storage.AccessNone, nil
+	}
+	c, err := a.storage.GetCalendar(ctx, e.CalendarID)
+	if errors.Is(err, storage.ErrCalendarNotFound) {
+		return storage.AccessNone, nil
+	}
+	if err != nil {
+		return storage.AccessNone, err
+	}
+	return c.Access(userID), nil
+}
+
+// writableEvent - событие, которое userID может менять. Невидимые события
+// дают ErrEventNotFound, доступные только на чтение - ErrForbidden.
+func (a *App) writableEvent(ctx context.Context, userID, id string) (storage.Event, error) {
+	event, err := a.GetEvent(ctx, userID, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	access, err := a.eventAccess(ctx, userID, event)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if !access.CanWrite() {
+		return storage.Event{}, ErrForbidden
+	}
+	return event, nil
+}
+
+// sharedEvents - события чужих календарей, открытых пользователю, пересекающиеся с [from, to).
+func (a *App) sharedEvents(ctx context.Context, userID string, from, to time.Time) ([]storage.Event, error) {
+	calendars, err := a.storage.ListCalendars(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(calendars))
+	for _, c := range calendars {
+		if c.OwnerID != userID {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return a.storage.ListCalendarEvents(ctx, ids, from, to)
+}
+
+// mergeEvents объединяет списки, упорядоченные по времени начала.
+func mergeEvents(own, shared []storage.Event) []storage.Event {
+	if len(shared) == 0 {
+		return own
+	}
+	res := make([]storage.Event, 0, len(own)+len(shared))
+	res = append(res, own...)
+	res = append(res, shared...)
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Start.Before(res[j].Start)
+	})
+	return res
+}
+
+func validateCalendar(c storage.Calendar) error {
+	switch {
+	case c.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidCalendar)
+	case len(c.Name) > maxCalendarName:
+		return fmt.Errorf("%w: name is longer than %d bytes", ErrInvalidCalendar, maxCalendarName)
+	}
+	return nil
+}
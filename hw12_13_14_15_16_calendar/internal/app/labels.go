@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+var ErrInvalidLabel = errors.New("invalid label")
+
+const maxLabelName = 32
+
+var colorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Filter сужает выборку списка событий, пустые поля не учитываются.
+type Filter struct {
+	Label    string
+	Calendar string
+}
+
+func (f Filter) apply(events []storage.Event) []storage.Event {
+	if f.Label == "" && f.Calendar == "" {
+		return events
+	}
+	res := make([]storage.Event, 0, len(events))
+	for _, e := range events {
+		if f.Label != "" && !e.HasLabel(f.Label) {
+			continue
+		}
+		if f.Calendar != "" && e.CalendarID != f.Calendar {
+			continue
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+func (a *App) CreateLabel(ctx context.Context, userID string, label storage.Label) (storage.Label, error) {
+	label.UserID = userID
+	if err := validateLabel(label); err != nil {
+		return storage.Label{}, err
+	}
+	if err := a.storage.CreateLabel(ctx, label); err != nil {
+		return storage.Label{}, err
+	}
+	return label, nil
+}
+
+// UpdateLabel меняет цвет метки, переименование не поддерживается.
+func (a *App) UpdateLabel(ctx context.Context, userID, name string, label storage.Label) (storage.Label, error) {
+	label.UserID = userID
+	label.Name = name
+	if err := validateLabel(label); err != nil {
+		return storage.Label{}, err
+	}
+	if err := a.storage.UpdateLabel(ctx, label); err != nil {
+		return storage.Label{}, err
+	}
+	return label, nil
+}
+
+// DeleteLabel удаляет метку и снимает её со всех событий пользователя.
+func (a *App) DeleteLabel(ctx context.Context, userID, name string) error {
+	return a.storage.DeleteLabel(ctx, userID, name)
+}
+
+func (a *App) ListLabels(ctx context.Context, userID string) ([]storage.Label, error) {
+	return a.storage.ListLabels(ctx, userID)
+}
+
+// checkLabels проверяет, что категория и метки события есть в наборе пользователя.
+func (a *App) checkLabels(ctx context.Context, event storage.Event) error {
+	if event.Category == "" && len(event.Labels) == 0 {
+		return nil
+	}
+
+	labels, err := a.storage.ListLabels(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		known[l.Name] = true
+	}
+
+	if event.Category != "" && !known[event.Category] {
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidEvent, event.Category)
+	}
+	seen := make(map[string]bool, len(event.Labels))
+	for _, name := range event.Labels {
+		if !known[name] {
+			return fmt.Errorf("%w: unknown label %q", ErrInvalidEvent, name)
+		}
+		if seen[name] {
+			return fmt.Errorf("%w: duplicate label %q", ErrInvalidEvent, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+func validateLabel(label storage.Label) error {
+	switch {
+	case label.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidLabel)
+	case len(label.Name) > maxLabelName:
+		return fmt.Errorf("%w: name is longer than %d bytes", ErrInvalidLabel, maxLabelName)
+	case !colorRe.MatchString(label.Color):
+		return fmt.Errorf("%w: color must be #rrggbb", ErrInvalidLabel)
+	}
+	return nil
+}
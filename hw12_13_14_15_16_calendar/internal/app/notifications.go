@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+var ErrInvalidSnooze = errors.New("invalid snooze")
+
+// maxSnooze - дальше откладывать смысла нет, проще перенести событие.
+const maxSnooze = 7 * 24 * time.Hour
+
+func (a *App) ListNotifications(ctx context.Context, userID string) ([]storage.Notification, error) {
+	return a.storage.ListNotifications(ctx, userID)
+}
+
+// AckNotification подтверждает уведомление, больше оно не отправляется.
+func (a *App) AckNotification(ctx context.Context, userID, id string) (storage.Notification, error) {
+	return a.transition(ctx, userID, id, storage.NotificationAcked, time.Time{})
+}
+
+// SnoozeNotification откладывает уведомление, планировщик отправит его снова через d.
+func (a *App) SnoozeNotification(
+	ctx context.Context, userID, id string, d time.Duration,
+) (storage.Notification, error) {
+	if d <= 0 || d > maxSnooze {
+		return storage.Notification{}, fmt.Errorf("%w: duration must be in (0, %s]", ErrInvalidSnooze, maxSnooze)
+	}
+	return a.transition(ctx, userID, id, storage.NotificationSnoozed, a.now().Add(d))
+}
+
+func (a *App) transition(
+	ctx context.Context, userID, id string, to storage.NotificationStatus, snoozedUntil time.Time,
+) (storage.Notification, error) {
+	n, err := a.storage.GetNotification(ctx, id)
+	if err != nil {
+		return storage.Notification{}, err
+	}
+	if n.UserID != userID {
+		return storage.Notification{}, storage.ErrNotificationNotFound
+	}
+	if !n.Status.CanTransition(to) {
+		return storage.Notification{}, fmt.Errorf("%w: %s -> %s", storage.ErrInvalidTransition, n.Status, to)
+	}
+
+	n.Status = to
+	n.SnoozedUntil = snoozedUntil
+	if err := a.storage.SaveNotification(ctx, n); err != nil {
+		return storage.Notification{}, err
+	}
+	return n, nil
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/quickadd"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// QuickAdd создаёт событие из фразы вроде "Team sync tomorrow 10:00 for 30m remind 15m",
+// дата и время понимаются в часовом поясе loc. Ошибка разбора - *quickadd.ParseError.
+func (a *App) QuickAdd(
+	ctx context.Context, userID, text string, loc *time.Location, calendarID string,
+) (storage.Event, error) {
+	event, err := quickadd.Parse(text, a.now().In(loc))
+	if err != nil {
+		return storage.Event{}, err
+	}
+	event.CalendarID = calendarID
+	return a.CreateEvent(ctx, userID, event)
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// ListTrash - удалённые события пользователя, которые ещё можно восстановить.
+func (a *App) ListTrash(ctx context.Context, userID string) ([]storage.Event, error) {
+	return a.storage.ListTrash(ctx, userID)
+}
+
+// RestoreFromTrash достаёт событие из корзины. Если его время уже заняли,
+// возвращается storage.ErrDateBusy.
+func (a *App) RestoreFromTrash(ctx context.Context, userID, id string) (storage.Event, error) {
+	trashed, err := a.storage.GetEvent(ctx, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if !trashed.Trashed() {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	access, err := a.eventAccess(ctx, userID, trashed)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	switch {
+	case !access.CanRead():
+		return storage.Event{}, storage.ErrEventNotFound
+	case !access.CanWrite():
+		return storage.Event{}, ErrForbidden
+	}
+
+	event := trashed
+	event.DeletedAt = time.Time{}
+	err = a.audited(ctx, userID, storage.AuditRestore, nil, &event, func(ctx context.Context) error {
+		return a.storage.UntrashEvent(ctx, id)
+	})
+	if err != nil {
+		return storage.Event{}, err
+	}
+	return event, nil
+}
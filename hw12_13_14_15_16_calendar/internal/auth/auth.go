@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoCredentials = errors.New("no credentials")
+	ErrUnknownKey    = errors.New("unknown api key")
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrTokenExpired  = errors.New("token expired")
+)
+
+const keyPrefix = "cal_"
+
+// Authenticator проверяет API-ключи и HS256 JWT и возвращает ID пользователя.
+// Ключи хранятся в конфиге только в виде sha256-хэшей.
+type Authenticator struct {
+	keys   map[string]string // hex(sha256(key)) -> user ID
+	secret []byte
+	now    func() time.Time
+}
+
+func New(keys map[string]string, secret string) *Authenticator {
+	normalized := make(map[string]string, len(keys))
+	for hash, userID := range keys {
+		normalized[strings.ToLower(hash)] = userID
+	}
+	return &Authenticator{
+		keys:   normalized,
+		secret: []byte(secret),
+		now:    time.Now,
+	}
+}
+
+// Authenticate принимает либо API-ключ, либо JWT (три части через точку).
+func (a *Authenticator) Authenticate(credential string) (string, error) {
+	if credential == "" {
+		return "", ErrNoCredentials
+	}
+	if strings.Count(credential, ".") == 2 {
+		return a.parseToken(credential)
+	}
+	return a.lookupKey(credential)
+}
+
+func (a *Authenticator) lookupKey(key string) (string, error) {
+	userID, ok := a.keys[HashKey(key)]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+	return userID, nil
+}
+
+// NewKey генерирует случайный API-ключ и его хэш для конфига.
+func NewKey() (key, hash string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = keyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, HashKey(key), nil
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+type claims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+// NewToken подписывает JWT для пользователя, нулевой expiresAt - бессрочный токен.
+func (a *Authenticator) NewToken(userID string, expiresAt time.Time) (string, error) {
+	if len(a.secret) == 0 {
+		return "", errors.New("jwt secret is not configured")
+	}
+
+	c := claims{Subject: userID, IssuedAt: a.now().Unix()}
+	if !expiresAt.IsZero() {
+		c.ExpiresAt = expiresAt.Unix()
+	}
+
+	h, err := json.Marshal(header{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	p, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := encode(h) + "." + encode(p)
+	return unsigned + "." + encode(a.sign(unsigned)), nil
+}
+
+func (a *Authenticator) parseToken(token string) (string, error) {
+	if len(a.secret) == 0 {
+		return "", ErrInvalidToken
+	}
+
+	parts := strings.Split(token, ".")
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	if subtle.ConstantTimeCompare(sig, a.sign(parts[0]+"."+parts[1])) != 1 {
+		return "", ErrInvalidToken
+	}
+
+	var h header
+	if err := decode(parts[0], &h); err != nil || h.Alg != "HS256" {
+		return "", ErrInvalidToken
+	}
+	var c claims
+	if err := decode(parts[1], &c); err != nil || c.Subject == "" {
+		return "", ErrInvalidToken
+	}
+
+	now := a.now().Unix()
+	if c.ExpiresAt != 0 && now >= c.ExpiresAt {
+		return "", ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now < c.NotBefore {
+		return "", ErrInvalidToken
+	}
+	return c.Subject, nil
+}
+
+func (a *Authenticator) sign(unsigned string) []byte {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
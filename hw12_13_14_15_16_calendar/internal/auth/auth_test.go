@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey(t *testing.T) {
+	key, hash, err := NewKey()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key, keyPrefix))
+
+	a := New(map[string]string{strings.ToUpper(hash): "user-1"}, "")
+
+	userID, err := a.Authenticate(key)
+	require.NoError(t, err)
+	require.Equal(t, "user-1", userID)
+
+	_, err = a.Authenticate(key + "x")
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = a.Authenticate("")
+	require.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestToken(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a := New(nil, "secret")
+	a.now = func() time.Time { return now }
+
+	token, err := a.NewToken("user-1", now.Add(time.Hour))
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		userID, err := a.Authenticate(token)
+		require.NoError(t, err)
+		require.Equal(t, "user-1", userID)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := New(nil, "secret")
+		expired.now = func() time.Time { return now.Add(time.Hour) }
+		_, err := expired.Authenticate(token)
+		require.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		_, err := New(nil, "other").Authenticate(token)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("no secret configured", func(t *testing.T) {
+		_, err := New(nil, "").Authenticate(token)
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		other, err := a.NewToken("admin", now.Add(time.Hour))
+		require.NoError(t, err)
+		parts[1] = strings.Split(other, ".")[1]
+
+		_, err = a.Authenticate(strings.Join(parts, "."))
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("alg none", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		unsigned := encode([]byte(`{"alg":"none"}`)) + "." + parts[1]
+
+		_, err := a.Authenticate(unsigned + ".")
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("without expiration", func(t *testing.T) {
+		token, err := a.NewToken("user-2", time.Time{})
+		require.NoError(t, err)
+
+		later := New(nil, "secret")
+		later.now = func() time.Time { return now.AddDate(10, 0, 0) }
+		userID, err := later.Authenticate(token)
+		require.NoError(t, err)
+		require.Equal(t, "user-2", userID)
+	})
+}
@@ -0,0 +1,204 @@
+// Package backup выгружает хранилище календаря в архив JSON-строк и загружает его
+// обратно в любое хранилище.
+//
+// Первая строка архива - заголовок с форматом и версией, затем по строке на запись
+// вида {"event": {...}}, последняя строка {"end": {...}} с числом записей каждого вида:
+// без неё архив считается обрезанным.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+const (
+	Format = "calendar-backup"
+	// Version - версия формата, архивы более новых версий не загружаются.
+	Version = 1
+
+	maxLine = 16 << 20
+)
+
+var ErrInvalidArchive = errors.New("invalid backup archive")
+
+type Source interface {
+	Export(ctx context.Context, fn func(storage.Record) error) error
+}
+
+type Target interface {
+	Atomic(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateCalendar(ctx context.Context, c storage.Calendar) error
+	CreateLabel(ctx context.Context, label storage.Label) error
+	SaveWorkingHours(ctx context.Context, w storage.WorkingHours) error
+	CreateEvent(ctx context.Context, event storage.Event) error
+	SaveNotification(ctx context.Context, n storage.Notification) error
+	AppendAudit(ctx context.Context, entry storage.AuditEntry) (storage.AuditEntry, error)
+}
+
+// Stats - сколько записей каждого вида в архиве.
+type Stats struct {
+	Calendars     int `json:"calendars"`
+	Labels        int `json:"labels"`
+	WorkingHours  int `json:"workingHours"`
+	Events        int `json:"events"`
+	Notifications int `json:"notifications"`
+	Audit         int `json:"audit"`
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d calendars, %d labels, %d working hours, %d events, %d notifications, %d audit entries",
+		s.Calendars, s.Labels, s.WorkingHours, s.Events, s.Notifications, s.Audit)
+}
+
+type header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// line - строка архива после заголовка, заполнено ровно одно поле.
+type line struct {
+	Calendar     *calendar             `json:"calendar,omitempty"`
+	Label        *label                `json:"label,omitempty"`
+	WorkingHours *workingHours         `json:"workingHours,omitempty"`
+	Event        *event                `json:"event,omitempty"`
+	Notification *storage.Notification `json:"notification,omitempty"`
+	Audit        *audit                `json:"audit,omitempty"`
+	End          *Stats                `json:"end,omitempty"`
+}
+
+// Write выгружает src в w, createdAt попадает в заголовок.
+func Write(ctx context.Context, w io.Writer, src Source, createdAt time.Time) (Stats, error) {
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	var stats Stats
+	if err := enc.Encode(header{Format: Format, Version: Version, CreatedAt: createdAt.UTC()}); err != nil {
+		return Stats{}, err
+	}
+	err := src.Export(ctx, func(r storage.Record) error {
+		return enc.Encode(toLine(r, &stats))
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+	if err := enc.Encode(line{End: &stats}); err != nil {
+		return Stats{}, err
+	}
+	return stats, buf.Flush()
+}
+
+func toLine(r storage.Record, stats *Stats) line {
+	var l line
+	switch {
+	case r.Calendar != nil:
+		stats.Calendars++
+		l.Calendar = toCalendar(*r.Calendar)
+	case r.Label != nil:
+		stats.Labels++
+		l.Label = &label{UserID: r.Label.UserID, Name: r.Label.Name, Color: r.Label.Color}
+	case r.WorkingHours != nil:
+		stats.WorkingHours++
+		l.WorkingHours = toWorkingHours(*r.WorkingHours)
+	case r.Event != nil:
+		stats.Events++
+		l.Event = toEvent(*r.Event)
+	case r.Notification != nil:
+		stats.Notifications++
+		l.Notification = r.Notification
+	case r.Audit != nil:
+		stats.Audit++
+		l.Audit = toAudit(*r.Audit)
+	}
+	return l
+}
+
+// Restore загружает архив в dst одной транзакцией: при любой ошибке dst не меняется.
+// Записи не перезаписываются, поэтому загружать стоит в пустое хранилище.
+func Restore(ctx context.Context, r io.Reader, dst Target) (Stats, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLine)
+
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return Stats{}, err
+		}
+		return Stats{}, fmt.Errorf("%w: empty", ErrInvalidArchive)
+	}
+	var h header
+	if err := json.Unmarshal(sc.Bytes(), &h); err != nil || h.Format != Format {
+		return Stats{}, fmt.Errorf("%w: not a %s file", ErrInvalidArchive, Format)
+	}
+	if h.Version < 1 || h.Version > Version {
+		return Stats{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidArchive, h.Version)
+	}
+
+	var stats Stats
+	err := dst.Atomic(ctx, func(ctx context.Context) error {
+		for n := 2; sc.Scan(); n++ {
+			var l line
+			if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+				return fmt.Errorf("%w: line %d: %s", ErrInvalidArchive, n, err.Error())
+			}
+			if l.End != nil {
+				if *l.End != stats {
+					return fmt.Errorf("%w: archive lists %s, read %s", ErrInvalidArchive, *l.End, stats)
+				}
+				return nil
+			}
+			if err := restore(ctx, dst, l, &stats); err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: truncated, no end record", ErrInvalidArchive)
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+	return stats, nil
+}
+
+func restore(ctx context.Context, dst Target, l line, stats *Stats) error {
+	switch {
+	case l.Calendar != nil:
+		stats.Calendars++
+		return dst.CreateCalendar(ctx, l.Calendar.toStorage())
+	case l.Label != nil:
+		stats.Labels++
+		return dst.CreateLabel(ctx, storage.Label{UserID: l.Label.UserID, Name: l.Label.Name, Color: l.Label.Color})
+	case l.WorkingHours != nil:
+		stats.WorkingHours++
+		return dst.SaveWorkingHours(ctx, l.WorkingHours.toStorage())
+	case l.Event != nil:
+		stats.Events++
+		return dst.CreateEvent(ctx, l.Event.toStorage())
+	case l.Notification != nil:
+		stats.Notifications++
+		return dst.SaveNotification(ctx, *l.Notification)
+	case l.Audit != nil:
+		stats.Audit++
+		// Версии назначает хранилище: записи идут по порядку, и номера должны совпасть.
+		entry, err := dst.AppendAudit(ctx, l.Audit.toStorage())
+		if err != nil {
+			return err
+		}
+		if entry.Version != l.Audit.Version {
+			return fmt.Errorf("%w: audit of event %s: version %d restored as %d",
+				ErrInvalidArchive, entry.EventID, l.Audit.Version, entry.Version)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown record", ErrInvalidArchive)
+	}
+}
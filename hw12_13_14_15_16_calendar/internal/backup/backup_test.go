@@ -0,0 +1,132 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+var day = time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+func filled(t *testing.T) *memorystorage.Storage {
+	t.Helper()
+	ctx := context.Background()
+	s := memorystorage.New()
+
+	require.NoError(t, s.CreateCalendar(ctx, storage.Calendar{
+		ID: "cal-1", OwnerID: "user-1", Name: "team",
+		Shares: map[string]storage.Access{"user-2": storage.AccessWrite},
+	}))
+	require.NoError(t, s.CreateLabel(ctx, storage.Label{UserID: "user-1", Name: "work", Color: "#ff0000"}))
+	w := storage.WorkingHours{UserID: "user-1", TimeZone: "Europe/Moscow", Holidays: []string{"2024-01-12"}}
+	w.Week[time.Monday] = storage.WorkPeriod{Start: 9 * time.Hour, End: 18 * time.Hour}
+	require.NoError(t, s.SaveWorkingHours(ctx, w))
+
+	event := storage.Event{
+		ID: "e-1", Title: "standup", Start: day.Add(10 * time.Hour), End: day.Add(11 * time.Hour),
+		Description: "daily", UserID: "user-1", NotifyBefore: 15 * time.Minute, Category: "work",
+		Labels: []string{"work"}, CalendarID: "cal-1",
+		Reminders: []storage.Reminder{
+			{Anchor: storage.AnchorEnd, Offset: -5 * time.Minute},
+			{At: day.Add(8 * time.Hour)},
+		},
+	}
+	require.NoError(t, s.CreateEvent(ctx, event))
+	trashed := storage.Event{
+		ID: "e-2", Title: "old", Start: day.Add(10 * time.Hour), End: day.Add(12 * time.Hour),
+		UserID: "user-1", DeletedAt: day,
+	}
+	require.NoError(t, s.CreateEvent(ctx, trashed))
+
+	require.NoError(t, s.SaveNotification(ctx, storage.Notification{
+		ID: "e-1:start-900s", EventID: "e-1", ReminderID: "start-900s", Title: "standup",
+		Start: event.Start, UserID: "user-1", Status: storage.NotificationSnoozed, SnoozedUntil: day.Add(9 * time.Hour),
+	}))
+	_, err := s.AppendAudit(ctx, storage.AuditEntry{
+		EventID: "e-1", UserID: "user-1", Actor: "user-1", Action: storage.AuditCreate, At: day, After: &event,
+	})
+	require.NoError(t, err)
+	_, err = s.AppendAudit(ctx, storage.AuditEntry{
+		EventID: "e-2", UserID: "user-1", Actor: "user-2", Action: storage.AuditDelete, At: day, Before: &trashed,
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func records(t *testing.T, s Source) []storage.Record {
+	t.Helper()
+	var res []storage.Record
+	require.NoError(t, s.Export(context.Background(), func(r storage.Record) error {
+		res = append(res, r)
+		return nil
+	}))
+	return res
+}
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	src := filled(t)
+
+	var buf bytes.Buffer
+	stats, err := Write(ctx, &buf, src, day)
+	require.NoError(t, err)
+	want := Stats{Calendars: 1, Labels: 1, WorkingHours: 1, Events: 2, Notifications: 1, Audit: 2}
+	require.Equal(t, want, stats)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 10)
+	require.JSONEq(t, `{"format":"calendar-backup","version":1,"createdAt":"2024-01-10T00:00:00Z"}`, lines[0])
+	require.Contains(t, lines[len(lines)-1], `{"end":`)
+
+	dst := memorystorage.New()
+	stats, err = Restore(ctx, &buf, dst)
+	require.NoError(t, err)
+	require.Equal(t, want, stats)
+	require.Equal(t, records(t, src), records(t, dst))
+}
+
+func TestRestoreErrors(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	_, err := Write(ctx, &buf, filled(t), day)
+	require.NoError(t, err)
+	archive := buf.String()
+	lines := strings.SplitAfter(archive, "\n")
+
+	tests := []struct {
+		name    string
+		archive string
+	}{
+		{"empty", ""},
+		{"not an archive", `{"hello":"world"}` + "\n"},
+		{"newer version", `{"format":"calendar-backup","version":2}` + "\n"},
+		{"truncated", strings.Join(lines[:len(lines)-3], "")},
+		{"counts mismatch", strings.Join(append(lines[:5:5], lines[len(lines)-2]), "")},
+		{"bad line", lines[0] + "{oops\n"},
+		{"unknown record", lines[0] + `{"user":{}}` + "\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := memorystorage.New()
+			_, err := Restore(ctx, strings.NewReader(tc.archive), dst)
+			require.ErrorIs(t, err, ErrInvalidArchive)
+			require.Empty(t, records(t, dst))
+		})
+	}
+
+	t.Run("existing data", func(t *testing.T) {
+		dst := memorystorage.New()
+		require.NoError(t, dst.CreateEvent(ctx, storage.Event{
+			ID: "e-2", Title: "taken", Start: day, End: day.Add(time.Hour), UserID: "user-3",
+		}))
+		_, err := Restore(ctx, strings.NewReader(archive), dst)
+		require.ErrorIs(t, err, storage.ErrEventExists)
+		require.Len(t, records(t, dst), 1)
+	})
+}
@@ -0,0 +1,213 @@
+package backup
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// Записи архива отделены от типов хранилища: формат меняется только вместе с Version.
+
+// duration пишется строкой вида "1h30m0s".
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	*d = duration(v)
+	return err
+}
+
+type calendar struct {
+	ID      string            `json:"id"`
+	OwnerID string            `json:"ownerId"`
+	Name    string            `json:"name"`
+	Shares  map[string]string `json:"shares,omitempty"`
+}
+
+func toCalendar(c storage.Calendar) *calendar {
+	res := &calendar{ID: c.ID, OwnerID: c.OwnerID, Name: c.Name}
+	if len(c.Shares) > 0 {
+		res.Shares = make(map[string]string, len(c.Shares))
+		for user, access := range c.Shares {
+			res.Shares[user] = string(access)
+		}
+	}
+	return res
+}
+
+func (c calendar) toStorage() storage.Calendar {
+	res := storage.Calendar{ID: c.ID, OwnerID: c.OwnerID, Name: c.Name, Shares: map[string]storage.Access{}}
+	for user, access := range c.Shares {
+		res.Shares[user] = storage.Access(access)
+	}
+	return res
+}
+
+type label struct {
+	UserID string `json:"userId"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+}
+
+// workingHours - Week индексируется днём недели с воскресенья, как time.Weekday.
+type workingHours struct {
+	UserID   string        `json:"userId"`
+	TimeZone string        `json:"timeZone"`
+	Week     [7]workPeriod `json:"week"`
+	Holidays []string      `json:"holidays,omitempty"`
+}
+
+type workPeriod struct {
+	Start duration `json:"start"`
+	End   duration `json:"end"`
+}
+
+func toWorkingHours(w storage.WorkingHours) *workingHours {
+	res := &workingHours{UserID: w.UserID, TimeZone: w.TimeZone, Holidays: w.Holidays}
+	for d, p := range w.Week {
+		res.Week[d] = workPeriod{Start: duration(p.Start), End: duration(p.End)}
+	}
+	return res
+}
+
+func (w workingHours) toStorage() storage.WorkingHours {
+	res := storage.WorkingHours{UserID: w.UserID, TimeZone: w.TimeZone, Holidays: w.Holidays}
+	for d, p := range w.Week {
+		res.Week[d] = storage.WorkPeriod{Start: time.Duration(p.Start), End: time.Duration(p.End)}
+	}
+	return res
+}
+
+type event struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title"`
+	Start        time.Time  `json:"start"`
+	End          time.Time  `json:"end"`
+	Description  string     `json:"description,omitempty"`
+	UserID       string     `json:"userId"`
+	NotifyBefore duration   `json:"notifyBefore,omitempty"`
+	Category     string     `json:"category,omitempty"`
+	Labels       []string   `json:"labels,omitempty"`
+	Reminders    []reminder `json:"reminders,omitempty"`
+	CalendarID   string     `json:"calendarId,omitempty"`
+	DeletedAt    *time.Time `json:"deletedAt,omitempty"`
+}
+
+// reminder - относительное напоминание (Anchor и Offset) или абсолютное (At).
+type reminder struct {
+	Anchor string     `json:"anchor,omitempty"`
+	Offset duration   `json:"offset,omitempty"`
+	At     *time.Time `json:"at,omitempty"`
+}
+
+func toEvent(e storage.Event) *event {
+	res := &event{
+		ID:           e.ID,
+		Title:        e.Title,
+		Start:        e.Start.UTC(),
+		End:          e.End.UTC(),
+		Description:  e.Description,
+		UserID:       e.UserID,
+		NotifyBefore: duration(e.NotifyBefore),
+		Category:     e.Category,
+		Labels:       e.Labels,
+		CalendarID:   e.CalendarID,
+	}
+	for _, r := range e.Reminders {
+		if r.Anchor == "" {
+			at := r.At.UTC()
+			res.Reminders = append(res.Reminders, reminder{At: &at})
+			continue
+		}
+		res.Reminders = append(res.Reminders, reminder{Anchor: string(r.Anchor), Offset: duration(r.Offset)})
+	}
+	if e.Trashed() {
+		deletedAt := e.DeletedAt.UTC()
+		res.DeletedAt = &deletedAt
+	}
+	return res
+}
+
+func (e event) toStorage() storage.Event {
+	res := storage.Event{
+		ID:           e.ID,
+		Title:        e.Title,
+		Start:        e.Start,
+		End:          e.End,
+		Description:  e.Description,
+		UserID:       e.UserID,
+		NotifyBefore: time.Duration(e.NotifyBefore),
+		Category:     e.Category,
+		Labels:       e.Labels,
+		CalendarID:   e.CalendarID,
+	}
+	for _, r := range e.Reminders {
+		rem := storage.Reminder{Anchor: storage.ReminderAnchor(r.Anchor), Offset: time.Duration(r.Offset)}
+		if r.At != nil {
+			rem.At = *r.At
+		}
+		res.Reminders = append(res.Reminders, rem)
+	}
+	if e.DeletedAt != nil {
+		res.DeletedAt = *e.DeletedAt
+	}
+	return res
+}
+
+// audit - Before пуст у создания, After - у удаления.
+type audit struct {
+	EventID string    `json:"eventId"`
+	Version int       `json:"version"`
+	UserID  string    `json:"userId"`
+	Actor   string    `json:"actor"`
+	Action  string    `json:"action"`
+	At      time.Time `json:"at"`
+	Before  *event    `json:"before,omitempty"`
+	After   *event    `json:"after,omitempty"`
+}
+
+func toAudit(a storage.AuditEntry) *audit {
+	res := &audit{
+		EventID: a.EventID,
+		Version: a.Version,
+		UserID:  a.UserID,
+		Actor:   a.Actor,
+		Action:  string(a.Action),
+		At:      a.At.UTC(),
+	}
+	if a.Before != nil {
+		res.Before = toEvent(*a.Before)
+	}
+	if a.After != nil {
+		res.After = toEvent(*a.After)
+	}
+	return res
+}
+
+func (a audit) toStorage() storage.AuditEntry {
+	res := storage.AuditEntry{
+		EventID: a.EventID,
+		UserID:  a.UserID,
+		Actor:   a.Actor,
+		Action:  storage.AuditAction(a.Action),
+		At:      a.At,
+	}
+	if a.Before != nil {
+		e := a.Before.toStorage()
+		res.Before = &e
+	}
+	if a.After != nil {
+		e := a.After.toStorage()
+		res.After = &e
+	}
+	return res
+}
@@ -0,0 +1,45 @@
+package broker
+
+import (
+	"context"
+	"time"
+)
+
+// Message - сообщение в топике. Headers переносят, например, traceparent.
+type Message struct {
+	Key     string
+	Value   []byte
+	Headers map[string]string
+}
+
+// Handler обрабатывает сообщение. Ошибка не останавливает чтение топика: брокер
+// доставляет сообщение снова через Backoff, а после MaxAttempts попыток отбрасывает.
+type Handler func(ctx context.Context, msg Message) error
+
+const (
+	MaxAttempts = 10
+	maxBackoff  = 5 * time.Minute
+)
+
+// Backoff - задержка повторной доставки после attempt неудачных попыток:
+// 1s, 2s, 4s и так далее, но не больше 5m.
+func Backoff(attempt int) time.Duration {
+	switch {
+	case attempt < 1:
+		return 0
+	case attempt > 9: // 2^9s уже больше maxBackoff
+		return maxBackoff
+	}
+	return time.Second << (attempt - 1)
+}
+
+// Producer и Consumer не зависят от конкретного брокера, чтобы планировщик
+// и хранитель не менялись при смене библиотеки (Kafka, память).
+type Producer interface {
+	Publish(ctx context.Context, topic string, msg Message) error
+}
+
+type Consumer interface {
+	// Consume читает топик до отмены контекста.
+	Consume(ctx context.Context, topic string, handler Handler) error
+}
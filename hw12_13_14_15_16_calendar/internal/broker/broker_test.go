@@ -0,0 +1,16 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, time.Duration(0), Backoff(0))
+	require.Equal(t, time.Second, Backoff(1))
+	require.Equal(t, 4*time.Second, Backoff(3))
+	require.Equal(t, 256*time.Second, Backoff(9))
+	require.Equal(t, 5*time.Minute, Backoff(MaxAttempts))
+}
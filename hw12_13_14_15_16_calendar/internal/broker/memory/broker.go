@@ -0,0 +1,94 @@
+package memorybroker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+)
+
+const queueSize = 1024
+
+// Broker - брокер внутри одного процесса, для тестов и запуска без Kafka.
+// Каждое сообщение топика получает один из его читателей. Сообщения живут
+// только в памяти: ожидающие повторной доставки пропадают при остановке.
+type Broker struct {
+	mu      sync.Mutex
+	topics  map[string]chan delivery
+	backoff func(attempt int) time.Duration
+}
+
+// delivery - сообщение и число неудачных попыток его обработать.
+type delivery struct {
+	msg      broker.Message
+	attempts int
+}
+
+func New() *Broker {
+	return &Broker{topics: make(map[string]chan delivery), backoff: broker.Backoff}
+}
+
+func (b *Broker) topic(name string) chan delivery {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch, ok := b.topics[name]
+	if !ok {
+		ch = make(chan delivery, queueSize)
+		b.topics[name] = ch
+	}
+	return ch
+}
+
+func (b *Broker) Publish(ctx context.Context, topic string, msg broker.Message) error {
+	select {
+	case b.topic(topic) <- delivery{msg: msg}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Broker) Consume(ctx context.Context, topic string, handler broker.Handler) error {
+	ch := b.topic(topic)
+	for {
+		select {
+		case d := <-ch:
+			if err := handler(ctx, d.msg); err != nil {
+				b.retry(ctx, ch, d)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// retry возвращает сообщение в топик через backoff, не блокируя читателя.
+func (b *Broker) retry(ctx context.Context, ch chan delivery, d delivery) {
+	d.attempts++
+	if d.attempts >= broker.MaxAttempts {
+		return
+	}
+	time.AfterFunc(b.backoff(d.attempts), func() {
+		select {
+		case ch <- d:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// Ping - брокер в процессе недоступен, только если читатель топика не успевает
+// и очередь заполнена: Publish тогда блокируется.
+func (b *Broker) Ping(context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for name, ch := range b.topics {
+		if len(ch) == cap(ch) {
+			return fmt.Errorf("topic %s is full", name)
+		}
+	}
+	return nil
+}
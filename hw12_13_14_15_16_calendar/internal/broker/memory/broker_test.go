@@ -0,0 +1,53 @@
+package memorybroker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	ctx := context.Background()
+	b := New()
+	require.NoError(t, b.Ping(ctx))
+
+	for i := 0; i < queueSize; i++ {
+		require.NoError(t, b.Publish(ctx, "notifications", broker.Message{Key: "1"}))
+	}
+	require.EqualError(t, b.Ping(ctx), "topic notifications is full")
+}
+
+func TestRedelivery(t *testing.T) {
+	b := New()
+	b.backoff = func(int) time.Duration { return time.Millisecond }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var flaky, broken atomic.Int64
+	go b.Consume(ctx, "notifications", func(_ context.Context, msg broker.Message) error {
+		if msg.Key == "broken" {
+			broken.Add(1)
+			return errors.New("always fails")
+		}
+		if flaky.Add(1) < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	require.NoError(t, b.Publish(ctx, "notifications", broker.Message{Key: "broken"}))
+	require.NoError(t, b.Publish(ctx, "notifications", broker.Message{Key: "flaky"}))
+	require.Eventually(t, func() bool {
+		return flaky.Load() == 3 && broken.Load() == broker.MaxAttempts
+	}, time.Second, 5*time.Millisecond)
+
+	// Отброшенное сообщение больше не приходит.
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, int64(3), flaky.Load())
+	require.Equal(t, int64(broker.MaxAttempts), broken.Load())
+}
@@ -0,0 +1,170 @@
+package sqlbroker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+	_ "github.com/lib/pq" // postgres driver
+)
+
+const (
+	// pollInterval - как часто читатель проверяет пустой топик.
+	pollInterval = 500 * time.Millisecond
+	// lease - на сколько читатель забирает сообщение: если он упадёт,
+	// сообщение после этого получит другой.
+	lease = time.Minute
+	// maxPause - дольше этого читатель не ждёт после ошибки базы.
+	maxPause = 30 * time.Second
+)
+
+type Logger interface {
+	Error(msg string)
+}
+
+// Broker - брокер поверх таблицы broker_messages в PostgreSQL (миграция 00011),
+// чтобы планировщик и хранитель работали отдельными процессами без Kafka.
+// Каждое сообщение топика получает один из его читателей, обработанное удаляется.
+type Broker struct {
+	logger  Logger
+	dsn     string
+	db      *sql.DB
+	poll    time.Duration
+	backoff func(attempt int) time.Duration
+}
+
+func New(logger Logger, dsn string) *Broker {
+	return &Broker{logger: logger, dsn: dsn, poll: pollInterval, backoff: broker.Backoff}
+}
+
+func (b *Broker) Connect(ctx context.Context) error {
+	db, err := sql.Open("postgres", b.dsn)
+	if err != nil {
+		return err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return err
+	}
+	b.db = db
+	return nil
+}
+
+func (b *Broker) Close(_ context.Context) error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Close()
+}
+
+func (b *Broker) Ping(ctx context.Context) error {
+	if b.db == nil {
+		return errors.New("not connected")
+	}
+	return b.db.PingContext(ctx)
+}
+
+func (b *Broker) Publish(ctx context.Context, topic string, msg broker.Message) error {
+	headers, err := json.Marshal(msg.Headers)
+	if err != nil {
+		return err
+	}
+	_, err = b.db.ExecContext(ctx,
+		`INSERT INTO broker_messages (topic, key, value, headers) VALUES ($1, $2, $3, $4)`,
+		topic, msg.Key, msg.Value, headers)
+	return err
+}
+
+// Consume читает топик до отмены контекста. Ошибка базы не останавливает чтение:
+// она пишется в лог, и читатель повторяет попытку после паузы. Сообщение, которое
+// не удалось удалить или отложить, придёт снова, когда истечёт аренда.
+func (b *Broker) Consume(ctx context.Context, topic string, handler broker.Handler) error {
+	failures := 0
+	for {
+		ok, err := b.next(ctx, topic, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		var pause time.Duration
+		switch {
+		case err != nil:
+			failures++
+			pause = b.backoff(failures)
+			if pause > maxPause {
+				pause = maxPause
+			}
+			b.logger.Error("sqlbroker: consume " + topic + ": " + err.Error() +
+				", retry in " + pause.String() + " (failure " + strconv.Itoa(failures) + ")")
+		case ok:
+			// Пока в топике есть сообщения, читаем без пауз.
+			failures = 0
+			continue
+		default:
+			failures = 0
+			pause = b.poll
+		}
+
+		timer := time.NewTimer(pause)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// next забирает и обрабатывает одно сообщение, false - доступных сообщений нет.
+func (b *Broker) next(ctx context.Context, topic string, handler broker.Handler) (bool, error) {
+	var (
+		id       int64
+		msg      broker.Message
+		headers  []byte
+		attempts int
+	)
+	err := b.db.QueryRowContext(ctx, `
+		UPDATE broker_messages SET available_at = now() + $2 * interval '1 millisecond'
+		WHERE id = (
+			SELECT id FROM broker_messages
+			WHERE topic = $1 AND available_at <= now()
+			ORDER BY available_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, key, value, headers, attempts`,
+		topic, lease.Milliseconds(),
+	).Scan(&id, &msg.Key, &msg.Value, &headers, &attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(headers, &msg.Headers); err != nil {
+		return false, err
+	}
+
+	if err := handler(ctx, msg); err != nil {
+		return true, b.retry(ctx, id, attempts+1)
+	}
+	_, err = b.db.ExecContext(ctx, `DELETE FROM broker_messages WHERE id = $1`, id)
+	return true, err
+}
+
+// retry откладывает сообщение на backoff, после MaxAttempts попыток удаляет его.
+func (b *Broker) retry(ctx context.Context, id int64, attempts int) error {
+	if attempts >= broker.MaxAttempts {
+		_, err := b.db.ExecContext(ctx, `DELETE FROM broker_messages WHERE id = $1`, id)
+		return err
+	}
+	_, err := b.db.ExecContext(ctx, `
+		UPDATE broker_messages SET attempts = $2, available_at = now() + $3 * interval '1 millisecond'
+		WHERE id = $1`,
+		id, attempts, b.backoff(attempts).Milliseconds())
+	return err
+}
@@ -0,0 +1,128 @@
+package sqlbroker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// Нужна PostgreSQL с применёнными миграциями, см. storagetest.DSN.
+func newBroker(t *testing.T) *Broker {
+	t.Helper()
+	dsn := storagetest.DSN(t)
+
+	ctx := context.Background()
+	b := New(logger.Discard(), dsn)
+	b.poll = 5 * time.Millisecond
+	b.backoff = func(int) time.Duration { return time.Millisecond }
+	require.NoError(t, b.Connect(ctx))
+	t.Cleanup(func() { b.Close(ctx) })
+	_, err := b.db.ExecContext(ctx, `TRUNCATE broker_messages`)
+	require.NoError(t, err)
+	return b
+}
+
+func TestBroker(t *testing.T) {
+	b := newBroker(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, b.Ping(ctx))
+
+	msg := broker.Message{Key: "1", Value: []byte(`{"id":"1"}`), Headers: map[string]string{"traceparent": "00-1"}}
+	require.NoError(t, b.Publish(ctx, "notifications", msg))
+	require.NoError(t, b.Publish(ctx, "other", broker.Message{Key: "2", Value: []byte("{}")}))
+
+	got := make(chan broker.Message, 2)
+	go b.Consume(ctx, "notifications", func(_ context.Context, msg broker.Message) error {
+		got <- msg
+		return nil
+	})
+
+	select {
+	case m := <-got:
+		require.Equal(t, msg, m)
+	case <-time.After(3 * time.Second):
+		t.Fatal("message was not delivered")
+	}
+	select {
+	case m := <-got:
+		t.Fatalf("unexpected message %q", m.Key)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRedelivery(t *testing.T) {
+	b := newBroker(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var flaky, broken atomic.Int64
+	go b.Consume(ctx, "notifications", func(_ context.Context, msg broker.Message) error {
+		if msg.Key == "broken" {
+			broken.Add(1)
+			return errors.New("always fails")
+		}
+		if flaky.Add(1) < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	require.NoError(t, b.Publish(ctx, "notifications", broker.Message{Key: "broken", Value: []byte("{}")}))
+	require.NoError(t, b.Publish(ctx, "notifications", broker.Message{Key: "flaky", Value: []byte("{}")}))
+	require.Eventually(t, func() bool {
+		var n int
+		err := b.db.QueryRowContext(ctx, `SELECT count(*) FROM broker_messages`).Scan(&n)
+		return err == nil && n == 0
+	}, 3*time.Second, 10*time.Millisecond)
+	require.Equal(t, int64(3), flaky.Load())
+	require.Equal(t, int64(broker.MaxAttempts), broken.Load())
+}
+
+type errorLog struct {
+	n atomic.Int64
+}
+
+func (l *errorLog) Error(string) { l.n.Add(1) }
+
+// База недоступна: Consume пишет ошибки в лог и продолжает, пока не отменят контекст.
+func TestConsumeSurvivesDBErrors(t *testing.T) {
+	logg := &errorLog{}
+	b := New(logg, "")
+	b.backoff = func(int) time.Duration { return time.Millisecond }
+	db, err := sql.Open("postgres", "postgres://calendar@127.0.0.1:1/calendar?sslmode=disable&connect_timeout=1")
+	require.NoError(t, err)
+	b.db = db
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Consume(ctx, "notifications", func(context.Context, broker.Message) error {
+			t.Error("unexpected message")
+			return nil
+		})
+	}()
+
+	require.Eventually(t, func() bool { return logg.n.Load() >= 3 }, 3*time.Second, 5*time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("Consume stopped: %v", err)
+	default:
+	}
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Consume did not stop after cancel")
+	}
+}
@@ -0,0 +1,300 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+)
+
+// Client ходит в HTTP API календаря от имени пользователя.
+// Если задан token, он передаётся как Bearer, иначе ID уходит в X-User-ID.
+type Client struct {
+	addr   string
+	userID string
+	token  string
+	http   *http.Client
+}
+
+// APIError - ответ сервера с кодом ошибки, Errors - ошибки отдельных полей запроса.
+type APIError struct {
+	Code    int
+	Message string
+	Errors  []internalhttp.FieldError
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Code, http.StatusText(e.Code), e.Message)
+}
+
+func New(addr, userID, token string) *Client {
+	return &Client{
+		addr:   strings.TrimRight(addr, "/"),
+		userID: userID,
+		token:  token,
+		http:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) Create(ctx context.Context, event internalhttp.Event) (internalhttp.Event, error) {
+	var res internalhttp.Event
+	err := c.do(ctx, http.MethodPost, "/events", event, &res)
+	return res, err
+}
+
+func (c *Client) Get(ctx context.Context, id string) (internalhttp.Event, error) {
+	var res internalhttp.Event
+	err := c.do(ctx, http.MethodGet, "/events/"+url.PathEscape(id), nil, &res)
+	return res, err
+}
+
+func (c *Client) Update(ctx context.Context, id string, event internalhttp.Event) (internalhttp.Event, error) {
+	var res internalhttp.Event
+	err := c.do(ctx, http.MethodPut, "/events/"+url.PathEscape(id), event, &res)
+	return res, err
+}
+
+func (c *Client) Delete(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/events/"+url.PathEscape(id), nil, nil)
+}
+
+// Filter сужает список событий, пустые поля не учитываются.
+type Filter struct {
+	Label    string
+	Calendar string
+}
+
+// List возвращает события за period (day, week, month), начиная с date,
+// включая события календарей, открытых пользователю.
+func (c *Client) List(ctx context.Context, period string, date time.Time, filter Filter) ([]internalhttp.Event, error) {
+	q := url.Values{}
+	q.Set("period", period)
+	q.Set("date", date.Format(internalhttp.DateLayout))
+	if filter.Label != "" {
+		q.Set("label", filter.Label)
+	}
+	if filter.Calendar != "" {
+		q.Set("calendar", filter.Calendar)
+	}
+
+	var res []internalhttp.Event
+	err := c.do(ctx, http.MethodGet, "/events?"+q.Encode(), nil, &res)
+	return res, err
+}
+
+func (c *Client) History(ctx context.Context, id string) ([]internalhttp.HistoryEntry, error) {
+	var res []internalhttp.HistoryEntry
+	err := c.do(ctx, http.MethodGet, "/events/"+url.PathEscape(id)+"/history", nil, &res)
+	return res, err
+}
+
+// Batch выполняет пакет операций над событиями. Если атомарный пакет отменён,
+// возвращается APIError со статусом упавшей операции.
+func (c *Client) Batch(ctx context.Context, req internalhttp.BatchRequest) (internalhttp.BatchResponse, error) {
+	var res internalhttp.BatchResponse
+	err := c.do(ctx, http.MethodPost, "/events:batch", req, &res)
+	return res, err
+}
+
+// QuickAdd создаёт событие из фразы, timeZone - имя IANA, пустое - UTC.
+func (c *Client) QuickAdd(ctx context.Context, req internalhttp.QuickAddRequest) (internalhttp.Event, error) {
+	var res internalhttp.Event
+	err := c.do(ctx, http.MethodPost, "/events:quickAdd", req, &res)
+	return res, err
+}
+
+// Restore возвращает событие к версии из журнала изменений.
+func (c *Client) Restore(ctx context.Context, id string, version int) (internalhttp.Event, error) {
+	var res internalhttp.Event
+	path := fmt.Sprintf("/events/%s/history/%d/restore", url.PathEscape(id), version)
+	err := c.do(ctx, http.MethodPost, path, nil, &res)
+	return res, err
+}
+
+func (c *Client) Trash(ctx context.Context) ([]internalhttp.Event, error) {
+	var res []internalhttp.Event
+	err := c.do(ctx, http.MethodGet, "/trash", nil, &res)
+	return res, err
+}
+
+// RestoreFromTrash возвращает удалённое событие из корзины.
+func (c *Client) RestoreFromTrash(ctx context.Context, id string) (internalhttp.Event, error) {
+	var res internalhttp.Event
+	err := c.do(ctx, http.MethodPost, "/events/"+url.PathEscape(id)+"/restore", nil, &res)
+	return res, err
+}
+
+func (c *Client) Calendars(ctx context.Context) ([]internalhttp.Calendar, error) {
+	var res []internalhttp.Calendar
+	err := c.do(ctx, http.MethodGet, "/calendars", nil, &res)
+	return res, err
+}
+
+func (c *Client) CreateCalendar(ctx context.Context, name string) (internalhttp.Calendar, error) {
+	var res internalhttp.Calendar
+	err := c.do(ctx, http.MethodPost, "/calendars", internalhttp.Calendar{Name: name}, &res)
+	return res, err
+}
+
+func (c *Client) RenameCalendar(ctx context.Context, id, name string) (internalhttp.Calendar, error) {
+	var res internalhttp.Calendar
+	err := c.do(ctx, http.MethodPut, "/calendars/"+url.PathEscape(id), internalhttp.Calendar{Name: name}, &res)
+	return res, err
+}
+
+func (c *Client) DeleteCalendar(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/calendars/"+url.PathEscape(id), nil, nil)
+}
+
+// ShareCalendar открывает календарь пользователю userID с правами access: read или write.
+func (c *Client) ShareCalendar(ctx context.Context, id, userID, access string) (internalhttp.Calendar, error) {
+	var res internalhttp.Calendar
+	path := "/calendars/" + url.PathEscape(id) + "/shares/" + url.PathEscape(userID)
+	err := c.do(ctx, http.MethodPut, path, internalhttp.Share{Access: access}, &res)
+	return res, err
+}
+
+func (c *Client) UnshareCalendar(ctx context.Context, id, userID string) error {
+	path := "/calendars/" + url.PathEscape(id) + "/shares/" + url.PathEscape(userID)
+	return c.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+func (c *Client) WorkingHours(ctx context.Context) (internalhttp.WorkingHours, error) {
+	var res internalhttp.WorkingHours
+	err := c.do(ctx, http.MethodGet, "/working-hours", nil, &res)
+	return res, err
+}
+
+// SetWorkingHours заменяет рабочее время пользователя целиком.
+func (c *Client) SetWorkingHours(ctx context.Context, w internalhttp.WorkingHours) (internalhttp.WorkingHours, error) {
+	var res internalhttp.WorkingHours
+	err := c.do(ctx, http.MethodPut, "/working-hours", w, &res)
+	return res, err
+}
+
+func (c *Client) DeleteWorkingHours(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, "/working-hours", nil, nil)
+}
+
+// FreeBusy - занятость users в [from, to), без users - своя.
+func (c *Client) FreeBusy(ctx context.Context, from, to time.Time, users []string) ([]internalhttp.FreeBusy, error) {
+	q := url.Values{}
+	q.Set("from", from.Format(time.RFC3339))
+	q.Set("to", to.Format(time.RFC3339))
+	if len(users) > 0 {
+		q.Set("users", strings.Join(users, ","))
+	}
+	var res []internalhttp.FreeBusy
+	err := c.do(ctx, http.MethodGet, "/freebusy?"+q.Encode(), nil, &res)
+	return res, err
+}
+
+func (c *Client) Labels(ctx context.Context) ([]internalhttp.Label, error) {
+	var res []internalhttp.Label
+	err := c.do(ctx, http.MethodGet, "/labels", nil, &res)
+	return res, err
+}
+
+func (c *Client) CreateLabel(ctx context.Context, label internalhttp.Label) (internalhttp.Label, error) {
+	var res internalhttp.Label
+	err := c.do(ctx, http.MethodPost, "/labels", label, &res)
+	return res, err
+}
+
+func (c *Client) UpdateLabel(ctx context.Context, label internalhttp.Label) (internalhttp.Label, error) {
+	var res internalhttp.Label
+	err := c.do(ctx, http.MethodPut, "/labels/"+url.PathEscape(label.Name), label, &res)
+	return res, err
+}
+
+func (c *Client) DeleteLabel(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/labels/"+url.PathEscape(name), nil, nil)
+}
+
+func (c *Client) Notifications(ctx context.Context) ([]internalhttp.Notification, error) {
+	var res []internalhttp.Notification
+	err := c.do(ctx, http.MethodGet, "/notifications", nil, &res)
+	return res, err
+}
+
+func (c *Client) AckNotification(ctx context.Context, id string) (internalhttp.Notification, error) {
+	var res internalhttp.Notification
+	err := c.do(ctx, http.MethodPost, "/notifications/"+url.PathEscape(id)+"/ack", nil, &res)
+	return res, err
+}
+
+func (c *Client) SnoozeNotification(
+	ctx context.Context, id string, d time.Duration,
+) (internalhttp.Notification, error) {
+	var res internalhttp.Notification
+	body := internalhttp.Snooze{Duration: d.String()}
+	err := c.do(ctx, http.MethodPost, "/notifications/"+url.PathEscape(id)+"/snooze", body, &res)
+	return res, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, res interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else {
+		req.Header.Set("X-User-ID", c.userID)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+	if res == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+func decodeError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	// Обычно это internalhttp.Problem, но отменённый пакет отвечает BatchResponse.
+	var e struct {
+		internalhttp.Problem
+		Error string `json:"error"`
+	}
+	res := &APIError{Code: resp.StatusCode, Message: strings.TrimSpace(string(b))}
+	if err := json.Unmarshal(b, &e); err != nil {
+		return res
+	}
+	switch {
+	case e.Detail != "":
+		res.Message = e.Detail
+	case e.Error != "":
+		res.Message = e.Error
+	}
+	res.Errors = e.Errors
+	return res
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	calendar := app.New(logger.Discard(), memorystorage.New())
+	srv := httptest.NewServer(internalhttp.NewServer(logger.Discard(), calendar, "").Handler())
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := New(srv.URL+"/", "user-1", "")
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	created, err := c.Create(ctx, internalhttp.Event{Title: "demo", Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.ID)
+
+	created.Title = "demo v2"
+	_, err = c.Update(ctx, created.ID, created)
+	require.NoError(t, err)
+
+	events, err := c.List(ctx, "month", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Filter{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "demo v2", events[0].Title)
+
+	require.NoError(t, c.Delete(ctx, created.ID))
+
+	_, err = c.Get(ctx, created.ID)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusNotFound, apiErr.Code)
+	require.Equal(t, "event not found", apiErr.Message)
+
+	_, err = c.Create(ctx, internalhttp.Event{Start: start, End: start.Add(time.Hour)})
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusBadRequest, apiErr.Code)
+	require.Equal(t, []internalhttp.FieldError{{Field: "title", Message: "is required"}}, apiErr.Errors)
+
+	t.Run("batch", func(t *testing.T) {
+		first := internalhttp.Event{Title: "first", Start: start, End: start.Add(time.Hour)}
+		res, err := c.Batch(ctx, internalhttp.BatchRequest{Operations: []internalhttp.BatchOperation{
+			{Op: "create", Event: &first},
+			{Op: "create", Event: &first},
+		}})
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, http.StatusConflict, apiErr.Code)
+		require.Equal(t, "operations[1]: date is busy", apiErr.Message)
+		require.Empty(t, res.Results)
+
+		res, err = c.Batch(ctx, internalhttp.BatchRequest{
+			Mode:       internalhttp.BatchBestEffort,
+			Operations: []internalhttp.BatchOperation{{Op: "create", Event: &first}, {Op: "create", Event: &first}},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Results, 2)
+		require.Equal(t, "first", res.Results[0].Event.Title)
+		require.Equal(t, "date is busy", res.Results[1].Error)
+	})
+}
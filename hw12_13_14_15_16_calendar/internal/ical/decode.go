@@ -0,0 +1,158 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// Decode разбирает VCALENDAR с одним VEVENT. Заполняются ID (из UID), Title, Start, End,
+// Description и Reminders, остальные поля события остаются пустыми. Время с TZID
+// переводится в UTC, событие на весь день (VALUE=DATE) начинается в полночь UTC.
+func Decode(data []byte) (storage.Event, error) {
+	props, err := lines(data)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	var (
+		stack    []string
+		event    map[string]property
+		triggers []property
+	)
+	for _, p := range props {
+		switch p.name {
+		case "BEGIN":
+			comp := strings.ToUpper(p.value)
+			switch {
+			case len(stack) == 0 && comp != "VCALENDAR":
+				return storage.Event{}, fmt.Errorf("%w: VCALENDAR expected", ErrInvalid)
+			case len(stack) == 1 && comp == "VEVENT" && event != nil:
+				return storage.Event{}, fmt.Errorf("%w: more than one VEVENT", ErrUnsupported)
+			case len(stack) == 1 && comp == "VEVENT":
+				event = map[string]property{}
+			case len(stack) == 1 && (comp == "VTODO" || comp == "VJOURNAL"):
+				return storage.Event{}, fmt.Errorf("%w: %s", ErrUnsupported, comp)
+			}
+			stack = append(stack, comp)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1] != strings.ToUpper(p.value) {
+				return storage.Event{}, fmt.Errorf("%w: unexpected END:%s", ErrInvalid, p.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			switch {
+			case len(stack) == 2 && stack[1] == "VEVENT":
+				if _, ok := event[p.name]; !ok {
+					event[p.name] = p
+				}
+			case len(stack) == 3 && stack[1] == "VEVENT" && stack[2] == "VALARM" && p.name == "TRIGGER":
+				triggers = append(triggers, p)
+			}
+		}
+	}
+	switch {
+	case len(stack) > 0:
+		return storage.Event{}, fmt.Errorf("%w: END:%s expected", ErrInvalid, stack[len(stack)-1])
+	case event == nil:
+		return storage.Event{}, fmt.Errorf("%w: no VEVENT", ErrInvalid)
+	}
+	return toEvent(event, triggers)
+}
+
+func toEvent(props map[string]property, triggers []property) (storage.Event, error) {
+	for _, name := range []string{"RRULE", "RDATE", "RECURRENCE-ID"} {
+		if _, ok := props[name]; ok {
+			return storage.Event{}, fmt.Errorf("%w: recurring events (%s)", ErrUnsupported, name)
+		}
+	}
+
+	e := storage.Event{
+		ID:          unescape(props["UID"].value),
+		Title:       unescape(props["SUMMARY"].value),
+		Description: unescape(props["DESCRIPTION"].value),
+	}
+	start, ok := props["DTSTART"]
+	if !ok {
+		return storage.Event{}, fmt.Errorf("%w: DTSTART is required", ErrInvalid)
+	}
+	var (
+		allDay bool
+		err    error
+	)
+	if e.Start, allDay, err = parseTime(start); err != nil {
+		return storage.Event{}, err
+	}
+
+	if end, ok := props["DTEND"]; ok {
+		if e.End, _, err = parseTime(end); err != nil {
+			return storage.Event{}, err
+		}
+	} else if d, ok := props["DURATION"]; ok {
+		dur, err := parseDuration(d.value)
+		if err != nil {
+			return storage.Event{}, err
+		}
+		e.End = e.Start.Add(dur)
+	} else if allDay {
+		e.End = e.Start.AddDate(0, 0, 1)
+	} else {
+		// Событие без длительности хранилище не примет.
+		return storage.Event{}, fmt.Errorf("%w: DTEND or DURATION is required", ErrUnsupported)
+	}
+
+	seen := make(map[string]bool, len(triggers))
+	for _, p := range triggers {
+		r, err := parseTrigger(p)
+		if err != nil {
+			return storage.Event{}, err
+		}
+		if !seen[r.ID()] {
+			seen[r.ID()] = true
+			e.Reminders = append(e.Reminders, r)
+		}
+	}
+	return e, nil
+}
+
+// parseTime разбирает DATE-TIME в UTC, с TZID или плавающее (считается UTC) и DATE.
+func parseTime(p property) (t time.Time, allDay bool, err error) {
+	v := p.value
+	switch {
+	case p.params["VALUE"] == "DATE" || len(v) == len(date):
+		t, err = time.ParseInLocation(date, v, time.UTC)
+		allDay = true
+	case strings.HasSuffix(v, "Z"):
+		t, err = time.Parse(dateTimeUTC, v)
+	default:
+		loc := time.UTC
+		if tzid := p.params["TZID"]; tzid != "" {
+			if loc, err = time.LoadLocation(strings.TrimPrefix(tzid, "/")); err != nil {
+				return time.Time{}, false, fmt.Errorf("%w: time zone %q", ErrUnsupported, tzid)
+			}
+		}
+		t, err = time.ParseInLocation(dateTime, v, loc)
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %s %q", ErrInvalid, p.name, v)
+	}
+	return t.UTC(), allDay, nil
+}
+
+func parseTrigger(p property) (storage.Reminder, error) {
+	if p.params["VALUE"] == "DATE-TIME" {
+		at, _, err := parseTime(p)
+		return storage.Reminder{At: at}, err
+	}
+	offset, err := parseDuration(p.value)
+	if err != nil {
+		return storage.Reminder{}, err
+	}
+	anchor := storage.AnchorStart
+	if strings.EqualFold(p.params["RELATED"], "END") {
+		anchor = storage.AnchorEnd
+	}
+	return storage.Reminder{Anchor: anchor, Offset: offset}, nil
+}
@@ -0,0 +1,302 @@
+// Package ical переводит события в iCalendar (RFC 5545) и обратно: один VCALENDAR
+// с одним VEVENT, напоминания - VALARM. Повторяющихся событий в календаре нет,
+// поэтому RRULE и подобные им свойства не поддерживаются.
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+var (
+	ErrInvalid = errors.New("invalid iCalendar data")
+	// ErrUnsupported - данные корректны, но такое событие в календаре не сохранить.
+	ErrUnsupported = errors.New("unsupported iCalendar data")
+)
+
+const (
+	dateTimeUTC = "20060102T150405Z"
+	dateTime    = "20060102T150405"
+	date        = "20060102"
+
+	maxLine = 75
+)
+
+// Encode - событие в виде VCALENDAR, UID - ID события. Результат зависит только
+// от события, поэтому годится для вычисления ETag.
+func Encode(e storage.Event) []byte {
+	var b bytes.Buffer
+	w := func(line string) {
+		writeFolded(&b, line)
+	}
+
+	w("BEGIN:VCALENDAR")
+	w("VERSION:2.0")
+	w("PRODID:-//fixme_my_friend//calendar//EN")
+	w("BEGIN:VEVENT")
+	w("UID:" + escape(e.ID))
+	// Время изменения события не хранится, а DTSTAMP обязателен.
+	w("DTSTAMP:" + e.Start.UTC().Format(dateTimeUTC))
+	w("DTSTART:" + e.Start.UTC().Format(dateTimeUTC))
+	w("DTEND:" + e.End.UTC().Format(dateTimeUTC))
+	w("SUMMARY:" + escape(e.Title))
+	if e.Description != "" {
+		w("DESCRIPTION:" + escape(e.Description))
+	}
+	if categories := eventCategories(e); len(categories) > 0 {
+		w("CATEGORIES:" + strings.Join(categories, ","))
+	}
+	for _, r := range e.Triggers() {
+		w("BEGIN:VALARM")
+		w("ACTION:DISPLAY")
+		w("DESCRIPTION:" + escape(e.Title))
+		switch r.Anchor {
+		case storage.AnchorStart:
+			w("TRIGGER:" + formatDuration(r.Offset))
+		case storage.AnchorEnd:
+			w("TRIGGER;RELATED=END:" + formatDuration(r.Offset))
+		default:
+			w("TRIGGER;VALUE=DATE-TIME:" + r.At.UTC().Format(dateTimeUTC))
+		}
+		w("END:VALARM")
+	}
+	w("END:VEVENT")
+	w("END:VCALENDAR")
+	return b.Bytes()
+}
+
+func eventCategories(e storage.Event) []string {
+	var res []string
+	if e.Category != "" {
+		res = append(res, escape(e.Category))
+	}
+	for _, l := range e.Labels {
+		if l != e.Category {
+			res = append(res, escape(l))
+		}
+	}
+	return res
+}
+
+// writeFolded пишет строку содержимого, перенося её по 75 байт и не разрывая символы.
+func writeFolded(b *bytes.Buffer, line string) {
+	limit := maxLine
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLine - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// formatDuration - длительность в виде "-PT1H30M", секунды дробятся до целых.
+func formatDuration(d time.Duration) string {
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteString("PT")
+	if d == 0 {
+		b.WriteString("0S")
+		return b.String()
+	}
+	h, m, s := d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second
+	if h > 0 {
+		b.WriteString(strconv.FormatInt(int64(h), 10) + "H")
+	}
+	if m > 0 {
+		b.WriteString(strconv.FormatInt(int64(m), 10) + "M")
+	}
+	if s > 0 {
+		b.WriteString(strconv.FormatInt(int64(s), 10) + "S")
+	}
+	return b.String()
+}
+
+// parseDuration разбирает длительность вида "-P1DT2H", "P2W".
+func parseDuration(s string) (time.Duration, error) {
+	rest := s
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(rest, "-"):
+		sign = -1
+		rest = rest[1:]
+	case strings.HasPrefix(rest, "+"):
+		rest = rest[1:]
+	}
+	if !strings.HasPrefix(rest, "P") || len(rest) < 3 {
+		return 0, fmt.Errorf("%w: duration %q", ErrInvalid, s)
+	}
+	rest = rest[1:]
+
+	var d time.Duration
+	inTime := false
+	for rest != "" {
+		if rest[0] == 'T' {
+			inTime = true
+			rest = rest[1:]
+			continue
+		}
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(rest) {
+			return 0, fmt.Errorf("%w: duration %q", ErrInvalid, s)
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return 0, fmt.Errorf("%w: duration %q", ErrInvalid, s)
+		}
+		unit, ok := durationUnit(rest[i], inTime)
+		if !ok {
+			return 0, fmt.Errorf("%w: duration %q", ErrInvalid, s)
+		}
+		d += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+	return sign * d, nil
+}
+
+func durationUnit(c byte, inTime bool) (time.Duration, bool) {
+	switch {
+	case !inTime && c == 'W':
+		return 7 * 24 * time.Hour, true
+	case !inTime && c == 'D':
+		return 24 * time.Hour, true
+	case inTime && c == 'H':
+		return time.Hour, true
+	case inTime && c == 'M':
+		return time.Minute, true
+	case inTime && c == 'S':
+		return time.Second, true
+	default:
+		return 0, false
+	}
+}
+
+// property - строка содержимого "NAME;PARAM=value:VALUE", имена в верхнем регистре.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// lines разворачивает перенесённые строки и разбирает их на свойства.
+func lines(data []byte) ([]property, error) {
+	var (
+		res  []property
+		line strings.Builder
+		n    int
+	)
+	flush := func() error {
+		if line.Len() == 0 {
+			return nil
+		}
+		p, err := parseLine(line.String())
+		if err != nil {
+			return fmt.Errorf("%w: line %d", err, n)
+		}
+		res = append(res, p)
+		line.Reset()
+		return nil
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 4096), len(data)+1)
+	for sc.Scan() {
+		raw := strings.TrimSuffix(sc.Text(), "\r")
+		if raw != "" && (raw[0] == ' ' || raw[0] == '\t') {
+			line.WriteString(raw[1:])
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		n++
+		line.WriteString(raw)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return res, flush()
+}
+
+func parseLine(s string) (property, error) {
+	i := strings.IndexAny(s, ";:")
+	if i <= 0 {
+		return property{}, ErrInvalid
+	}
+	p := property{name: strings.ToUpper(s[:i]), params: map[string]string{}}
+	for s[i] == ';' {
+		s = s[i+1:]
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return property{}, ErrInvalid
+		}
+		name, value := strings.ToUpper(s[:eq]), s[eq+1:]
+		// Значение в кавычках может содержать ";" и ":", из нескольких значений берём первое.
+		if strings.HasPrefix(value, `"`) {
+			end := strings.IndexByte(value[1:], '"')
+			if end < 0 {
+				return property{}, ErrInvalid
+			}
+			value, s = value[1:end+1], value[end+2:]
+			i = strings.IndexAny(s, ";:")
+		} else {
+			s = value
+			i = strings.IndexAny(s, ";:")
+			if i >= 0 {
+				value = s[:i]
+			}
+		}
+		if i < 0 {
+			return property{}, ErrInvalid
+		}
+		p.params[name] = value
+	}
+	p.value = s[i+1:]
+	return p, nil
+}
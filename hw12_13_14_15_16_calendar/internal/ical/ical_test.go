@@ -0,0 +1,203 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+func crlf(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+func TestEncode(t *testing.T) {
+	e := storage.Event{
+		ID:           "standup",
+		Title:        "Standup, daily; " + strings.Repeat("очень ", 10) + "long",
+		Start:        start,
+		End:          start.Add(30 * time.Minute),
+		Description:  "line 1\nline 2",
+		NotifyBefore: 15 * time.Minute,
+		Category:     "work",
+		Labels:       []string{"work", "team"},
+		Reminders: []storage.Reminder{
+			{Anchor: storage.AnchorEnd, Offset: -90 * time.Second},
+			{At: start.Add(-time.Hour)},
+		},
+	}
+
+	want := crlf(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//fixme_my_friend//calendar//EN
+BEGIN:VEVENT
+UID:standup
+DTSTAMP:20240110T100000Z
+DTSTART:20240110T100000Z
+DTEND:20240110T103000Z
+SUMMARY:Standup\, daily\; очень очень очень очень оч
+ ень очень очень очень очень очень long
+DESCRIPTION:line 1\nline 2
+CATEGORIES:work,team
+BEGIN:VALARM
+ACTION:DISPLAY
+DESCRIPTION:Standup\, daily\; очень очень очень очень` + " " + `
+ очень очень очень очень очень очень long
+TRIGGER;RELATED=END:-PT1M30S
+END:VALARM
+BEGIN:VALARM
+ACTION:DISPLAY
+DESCRIPTION:Standup\, daily\; очень очень очень очень` + " " + `
+ очень очень очень очень очень очень long
+TRIGGER;VALUE=DATE-TIME:20240110T090000Z
+END:VALARM
+BEGIN:VALARM
+ACTION:DISPLAY
+DESCRIPTION:Standup\, daily\; очень очень очень очень` + " " + `
+ очень очень очень очень очень очень long
+TRIGGER:-PT15M
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`)
+	require.Equal(t, want, string(Encode(e)))
+
+	got, err := Decode(Encode(e))
+	require.NoError(t, err)
+	require.Equal(t, e.ID, got.ID)
+	require.Equal(t, e.Title, got.Title)
+	require.Equal(t, e.Description, got.Description)
+	require.Equal(t, e.Triggers(), got.Reminders)
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want storage.Event
+	}{
+		{
+			name: "time zone and alarm",
+			data: `BEGIN:VCALENDAR
+PRODID:-//Mozilla.org/NONSGML Mozilla Calendar V1.1//EN
+VERSION:2.0
+BEGIN:VTIMEZONE
+TZID:Europe/Moscow
+BEGIN:STANDARD
+TZOFFSETFROM:+0300
+TZOFFSETTO:+0300
+TZNAME:MSK
+DTSTART:19700101T000000
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+CREATED:20240105T120000Z
+UID:5f1c2a
+SUMMARY:Planning
+DTSTART;TZID=Europe/Moscow:20240110T130000
+DTEND;TZID=Europe/Moscow:20240110T140000
+DESCRIPTION:Bring the\, notes
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;VALUE=DURATION:-PT15M
+DESCRIPTION:Default Mozilla Description
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`,
+			want: storage.Event{
+				ID: "5f1c2a", Title: "Planning", Start: start, End: start.Add(time.Hour),
+				Description: "Bring the, notes",
+				Reminders:   []storage.Reminder{{Anchor: storage.AnchorStart, Offset: -15 * time.Minute}},
+			},
+		},
+		{
+			name: "all day",
+			data: `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:holiday
+DTSTART;VALUE=DATE:20240110
+SUMMARY:Day off
+END:VEVENT
+END:VCALENDAR
+`,
+			want: storage.Event{
+				ID: "holiday", Title: "Day off",
+				Start: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "duration, folding and alarms",
+			data: `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:long
+DTSTART:20240110T100000Z
+DURATION:PT1H30M
+SUMMARY:Very long
+	 title
+BEGIN:VALARM
+TRIGGER;RELATED=END:PT0S
+END:VALARM
+BEGIN:VALARM
+TRIGGER:-P1D
+END:VALARM
+BEGIN:VALARM
+TRIGGER:-PT24H
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`,
+			want: storage.Event{
+				ID: "long", Title: "Very long title", Start: start, End: start.Add(90 * time.Minute),
+				Reminders: []storage.Reminder{
+					{Anchor: storage.AnchorEnd},
+					{Anchor: storage.AnchorStart, Offset: -24 * time.Hour},
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Decode([]byte(crlf(tc.data)))
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	event := func(props string) string {
+		return "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:x\nSUMMARY:x\n" + props + "END:VEVENT\nEND:VCALENDAR\n"
+	}
+	tests := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{"empty", "", ErrInvalid},
+		{"not a calendar", "BEGIN:VCARD\nEND:VCARD\n", ErrInvalid},
+		{"no event", "BEGIN:VCALENDAR\nEND:VCALENDAR\n", ErrInvalid},
+		{"unterminated", "BEGIN:VCALENDAR\nBEGIN:VEVENT\n", ErrInvalid},
+		{"bad line", event("DTSTART\n"), ErrInvalid},
+		{"no start", event(""), ErrInvalid},
+		{"bad start", event("DTSTART:2024-01-10\n"), ErrInvalid},
+		{"bad duration", event("DTSTART:20240110T100000Z\nDURATION:1h\n"), ErrInvalid},
+		{"no end", event("DTSTART:20240110T100000Z\n"), ErrUnsupported},
+		{"recurring", event("DTSTART:20240110T100000Z\nDURATION:PT1H\nRRULE:FREQ=DAILY\n"), ErrUnsupported},
+		{"unknown zone", event("DTSTART;TZID=Moscow Standard Time:20240110T100000\nDURATION:PT1H\n"), ErrUnsupported},
+		{"todo", "BEGIN:VCALENDAR\nBEGIN:VTODO\nEND:VTODO\nEND:VCALENDAR\n", ErrUnsupported},
+		{"two events", "BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VEVENT\nBEGIN:VEVENT\nEND:VEVENT\nEND:VCALENDAR\n", ErrUnsupported},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Decode([]byte(tc.data))
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Locker хранит аренду (lease) с ограниченным временем жизни.
+// TryAcquire захватывает свободную или просроченную аренду либо продлевает уже свою,
+// возвращает false, если аренда занята другим экземпляром.
+type Locker interface {
+	TryAcquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, holder string) error
+}
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
+
+// Elector периодически продлевает аренду и сообщает, является ли экземпляр лидером.
+// Если лидер умирает, аренда истекает через ttl и её забирает другой экземпляр.
+type Elector struct {
+	logger Logger
+	locker Locker
+	name   string
+	holder string
+	ttl    time.Duration
+
+	leading atomic.Bool
+	changes atomic.Int64
+}
+
+func New(logger Logger, locker Locker, name, holder string, ttl time.Duration) *Elector {
+	return &Elector{
+		logger: logger,
+		locker: locker,
+		name:   name,
+		holder: holder,
+		ttl:    ttl,
+	}
+}
+
+// Run продлевает аренду каждые ttl/3 до отмены контекста, после чего отпускает её.
+func (e *Elector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.release()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) tick(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+
+	ok, err := e.locker.TryAcquire(ctx, e.name, e.holder, e.ttl)
+	if err != nil {
+		// Не смогли подтвердить аренду - считаем, что лидерство потеряно,
+		// иначе два экземпляра могут работать одновременно.
+		e.logger.Error("leader election: " + err.Error())
+		ok = false
+	}
+	e.set(ok)
+}
+
+func (e *Elector) release() {
+	if !e.leading.Load() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl/3)
+	defer cancel()
+
+	if err := e.locker.Release(ctx, e.name, e.holder); err != nil {
+		e.logger.Error("leader election: release: " + err.Error())
+	}
+	e.set(false)
+}
+
+func (e *Elector) set(leading bool) {
+	if e.leading.Swap(leading) == leading {
+		return
+	}
+	e.changes.Add(1)
+
+	if leading {
+		e.logger.Info("leader election: " + e.holder + " became leader of " + e.name)
+	} else {
+		e.logger.Info("leader election: " + e.holder + " lost leadership of " + e.name)
+	}
+}
+
+// IsLeader сообщает, владеет ли экземпляр арендой в данный момент.
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Changes - число смен лидерства этого экземпляра, для метрик.
+func (e *Elector) Changes() int64 {
+	return e.changes.Load()
+}
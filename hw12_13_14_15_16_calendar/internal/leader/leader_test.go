@@ -0,0 +1,86 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type failingLocker struct{}
+
+func (failingLocker) TryAcquire(context.Context, string, string, time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func (failingLocker) Release(context.Context, string, string) error { return nil }
+
+const ttl = 60 * time.Millisecond
+
+func TestElector(t *testing.T) {
+	t.Run("only one leader", func(t *testing.T) {
+		storage := memorystorage.New()
+		a := New(logger.Discard(), storage, "scheduler", "a", ttl)
+		b := New(logger.Discard(), storage, "scheduler", "b", ttl)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go a.Run(ctx)
+		require.Eventually(t, a.IsLeader, time.Second, 5*time.Millisecond)
+
+		go b.Run(ctx)
+		time.Sleep(3 * ttl)
+		require.True(t, a.IsLeader())
+		require.False(t, b.IsLeader())
+	})
+
+	t.Run("failover on shutdown", func(t *testing.T) {
+		storage := memorystorage.New()
+		a := New(logger.Discard(), storage, "scheduler", "a", ttl)
+		b := New(logger.Discard(), storage, "scheduler", "b", ttl)
+
+		ctxA, cancelA := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			a.Run(ctxA)
+			close(done)
+		}()
+		require.Eventually(t, a.IsLeader, time.Second, 5*time.Millisecond)
+
+		ctxB, cancelB := context.WithCancel(context.Background())
+		defer cancelB()
+		go b.Run(ctxB)
+
+		cancelA()
+		<-done
+		require.False(t, a.IsLeader())
+		require.Eventually(t, b.IsLeader, time.Second, 5*time.Millisecond)
+		require.Equal(t, int64(2), a.Changes())
+	})
+
+	t.Run("failover on expired lease", func(t *testing.T) {
+		storage := memorystorage.New()
+		ok, err := storage.TryAcquire(context.Background(), "scheduler", "dead", ttl)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		b := New(logger.Discard(), storage, "scheduler", "b", ttl)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go b.Run(ctx)
+
+		require.Eventually(t, b.IsLeader, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("locker error drops leadership", func(t *testing.T) {
+		e := New(logger.Discard(), failingLocker{}, "scheduler", "a", ttl)
+		e.set(true)
+
+		e.tick(context.Background())
+		require.False(t, e.IsLeader())
+	})
+}
@@ -1,20 +1,103 @@
 package logger
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
-type Logger struct { // TODO
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel принимает DEBUG, INFO, WARN или ERROR в любом регистре.
+func ParseLevel(s string) (Level, error) {
+	for level, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+// Logger пишет сообщения не ниже заданного уровня, уровень можно менять на ходу.
+type Logger struct {
+	level atomic.Int32
+	mu    sync.Mutex
+	out   io.Writer
+	now   func() time.Time
 }
 
+// New - неизвестный уровень считается INFO, конфиг проверяет его заранее через ParseLevel.
 func New(level string) *Logger {
-	return &Logger{}
+	l := &Logger{out: os.Stdout, now: time.Now}
+	lvl, _ := ParseLevel(level)
+	l.level.Store(int32(lvl))
+	return l
+}
+
+// Discard - логгер, который ничего не пишет, для тестов.
+func Discard() *Logger {
+	l := New("ERROR")
+	l.out = io.Discard
+	return l
 }
 
-func (l Logger) Info(msg string) {
-	fmt.Println(msg)
+// SetLevel меняет уровень, при неизвестном уровне остаётся прежний.
+func (l *Logger) SetLevel(level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.Store(int32(lvl))
+	return nil
 }
 
-func (l Logger) Error(msg string) {
-	// TODO
+func (l *Logger) Level() Level {
+	return Level(l.level.Load())
 }
 
-// TODO
+func (l *Logger) Debug(msg string) {
+	l.log(LevelDebug, msg)
+}
+
+func (l *Logger) Info(msg string) {
+	l.log(LevelInfo, msg)
+}
+
+func (l *Logger) Warn(msg string) {
+	l.log(LevelWarn, msg)
+}
+
+func (l *Logger) Error(msg string) {
+	l.log(LevelError, msg)
+}
+
+func (l *Logger) log(level Level, msg string) {
+	if level < l.Level() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "%s %-5s %s\n", l.now().Format(time.RFC3339), level, msg)
+}
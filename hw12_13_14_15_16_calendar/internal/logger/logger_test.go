@@ -1,7 +1,32 @@
 package logger
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
 
 func TestLogger(t *testing.T) {
-	// TODO
+	var buf bytes.Buffer
+	l := New("warn")
+	l.out = &buf
+	l.now = func() time.Time { return time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC) }
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	require.Equal(t, "2024-01-10T12:00:00Z WARN  warn\n2024-01-10T12:00:00Z ERROR error\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, l.SetLevel("DEBUG"))
+	l.Debug("debug")
+	require.Equal(t, "2024-01-10T12:00:00Z DEBUG debug\n", buf.String())
+
+	require.Error(t, l.SetLevel("verbose"))
+	require.Equal(t, LevelDebug, l.Level(), "unknown level keeps the old one")
+
+	require.Equal(t, LevelInfo, New("").Level())
 }
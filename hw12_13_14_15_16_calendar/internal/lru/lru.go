@@ -0,0 +1,81 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache - потокобезопасный LRU-кэш фиксированного размера,
+// при переполнении вытесняется давно не использованный элемент.
+type Cache struct {
+	capacity int
+	queue    *list.List
+	items    map[string]*list.Element
+	mutex    sync.Mutex
+}
+
+type cacheItem struct {
+	key   string
+	value interface{}
+}
+
+func New(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		queue:    list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+func (c *Cache) Set(key string, value interface{}) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value = cacheItem{key, value}
+		c.queue.MoveToFront(el)
+		return true
+	}
+	if c.queue.Len() >= c.capacity {
+		c.removeOldest()
+	}
+	c.items[key] = c.queue.PushFront(cacheItem{key, value})
+	return false
+}
+
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.queue.MoveToFront(el)
+	return el.Value.(cacheItem).value, true
+}
+
+func (c *Cache) Remove(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		delete(c.items, key)
+		c.queue.Remove(el)
+	}
+}
+
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.queue.Len()
+}
+
+func (c *Cache) removeOldest() {
+	el := c.queue.Back()
+	if el == nil {
+		return
+	}
+	delete(c.items, el.Value.(cacheItem).key)
+	c.queue.Remove(el)
+}
@@ -0,0 +1,63 @@
+package lru
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		c := New(5)
+
+		require.False(t, c.Set("aaa", 100))
+		require.True(t, c.Set("aaa", 200))
+
+		val, ok := c.Get("aaa")
+		require.True(t, ok)
+		require.Equal(t, 200, val)
+
+		_, ok = c.Get("bbb")
+		require.False(t, ok)
+
+		c.Remove("aaa")
+		_, ok = c.Get("aaa")
+		require.False(t, ok)
+		require.Equal(t, 0, c.Len())
+	})
+
+	t.Run("purge logic", func(t *testing.T) {
+		c := New(3)
+		c.Set("aaa", 1)
+		c.Set("bbb", 2)
+		c.Set("ccc", 3)
+		c.Get("aaa")
+		c.Set("ddd", 4)
+
+		_, ok := c.Get("bbb")
+		require.False(t, ok)
+		_, ok = c.Get("aaa")
+		require.True(t, ok)
+		require.Equal(t, 3, c.Len())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		c := New(10)
+		wg := &sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					key := strconv.Itoa(i*1000 + j)
+					c.Set(key, j)
+					c.Get(key)
+				}
+			}(i)
+		}
+		wg.Wait()
+		require.Equal(t, 10, c.Len())
+	})
+}
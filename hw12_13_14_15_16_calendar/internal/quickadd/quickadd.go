@@ -0,0 +1,502 @@
+// Package quickadd разбирает событие из короткой фразы на английском или русском:
+// "Team sync tomorrow 10:00 for 30m remind 15m", "Созвон завтра в 10:00 на 30 минут напомнить за 15м".
+package quickadd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// DefaultDuration - длительность события, если она не указана.
+const DefaultDuration = time.Hour
+
+// ParseError - ошибка разбора. Pos и Len - смещение и длина фрагмента в символах (рунах) от начала текста.
+type ParseError struct {
+	Pos int
+	Len int
+	Msg string
+
+	// soft - после предлога или ключевого слова не то, что ожидалось. Внутри названия
+	// такое слово считается его частью: "Meet at office", "Встреча в офисе".
+	soft bool
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+type token struct {
+	text string // как в исходном тексте
+	word string // в нижнем регистре, без завершающей пунктуации
+	pos  int    // в рунах
+	end  int    // байтовое смещение конца в исходном тексте
+	off  int    // байтовое смещение начала
+}
+
+type parser struct {
+	text   string
+	tokens []token
+	now    time.Time
+
+	date    time.Time // полночь выбранного дня
+	hasDate bool
+	clock
+	hasTime     bool
+	duration    time.Duration
+	hasDuration bool
+	end         *clock
+	in          time.Duration
+	hasIn       bool
+	reminders   []storage.Reminder
+}
+
+type clock struct {
+	hour, minute int
+}
+
+// Parse разбирает text относительно now, дата и время понимаются в часовом поясе now.
+// Название - нераспознанные слова подряд в любом месте текста, других нераспознанных слов быть не должно.
+// Без даты событие ставится на ближайшее указанное время: сегодня или, если оно прошло, завтра.
+func Parse(text string, now time.Time) (storage.Event, error) {
+	p := &parser{text: text, tokens: tokenize(text), now: now, duration: DefaultDuration}
+
+	titleStart, titleEnd := -1, -1
+	for i := 0; i < len(p.tokens); i++ {
+		n, err := p.phrase(i)
+		switch {
+		case n > 0:
+			i += n - 1
+		case titleStart >= 0 && titleEnd+1 == i && (err == nil || err.soft):
+			titleEnd = i
+		case titleStart < 0 && (err == nil || err.soft && i == 0):
+			titleStart, titleEnd = i, i
+		case err != nil:
+			return storage.Event{}, err
+		default:
+			return storage.Event{}, p.errorAt(i, fmt.Sprintf("unexpected %q", p.tokens[i].text))
+		}
+	}
+
+	if titleStart < 0 {
+		return storage.Event{}, &ParseError{Pos: 0, Msg: "title is required"}
+	}
+	title := strings.TrimRight(p.text[p.tokens[titleStart].off:p.tokens[titleEnd].end], ",;:-–— ")
+	return p.event(title)
+}
+
+func (p *parser) event(title string) (storage.Event, error) {
+	loc := p.now.Location()
+	var start time.Time
+	switch {
+	case p.hasIn:
+		start = p.now.Add(p.in).Truncate(time.Minute)
+	case !p.hasTime:
+		return storage.Event{}, &ParseError{Pos: utf8.RuneCountInString(p.text), Msg: "time is required"}
+	case p.hasDate:
+		y, m, d := p.date.Date()
+		start = time.Date(y, m, d, p.hour, p.minute, 0, 0, loc)
+	default:
+		y, m, d := p.now.Date()
+		start = time.Date(y, m, d, p.hour, p.minute, 0, 0, loc)
+		if start.Before(p.now) {
+			start = start.AddDate(0, 0, 1)
+		}
+	}
+
+	end := start.Add(p.duration)
+	if p.end != nil {
+		y, m, d := start.Date()
+		end = time.Date(y, m, d, p.end.hour, p.end.minute, 0, 0, loc)
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+	return storage.Event{Title: title, Start: start, End: end, Reminders: p.reminders}, nil
+}
+
+// phrase разбирает фразу, начинающуюся с токена i, и возвращает число её токенов.
+// 0 - фраза не распознана, err объясняет почему, если это похоже на начало фразы.
+func (p *parser) phrase(i int) (int, *ParseError) {
+	w := p.word(i)
+	switch {
+	case w == "on":
+		return p.prefixed(i, p.dateAt)
+	case w == "at":
+		return p.prefixed(i, p.timeAt)
+	case w == "в" || w == "во":
+		return p.prefixed(i, func(i int) (int, *ParseError) {
+			if n, err := p.dateAt(i); n > 0 || err != nil {
+				return n, err
+			}
+			return p.timeAt(i)
+		})
+	case w == "for" || w == "на":
+		return p.durationPhrase(i)
+	case w == "in" || w == "через":
+		return p.inPhrase(i)
+	case reminderWords[w]:
+		return p.reminderPhrase(i)
+	}
+	if n, err := p.dateAt(i); n > 0 || err != nil {
+		return n, err
+	}
+	return p.clockAt(i, false)
+}
+
+// prefixed - предлог и следующая за ним фраза.
+func (p *parser) prefixed(i int, next func(i int) (int, *ParseError)) (int, *ParseError) {
+	n, err := next(i + 1)
+	if n == 0 && err == nil {
+		err = p.expected(i, "date or time")
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n + 1, nil
+}
+
+func (p *parser) timeAt(i int) (int, *ParseError) {
+	return p.clockAt(i, true)
+}
+
+var (
+	clockRe      = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	clockRangeRe = regexp.MustCompile(`^(\d{1,2}(?::\d{2})?(?:am|pm)?)[-–](\d{1,2}(?::\d{2})?(?:am|pm)?)$`)
+)
+
+// clockAt - время "10:00", "9am", "3:30pm" или интервал "10:00-11:30".
+// Просто число считается часом только после предлога (bare).
+func (p *parser) clockAt(i int, bare bool) (int, *ParseError) {
+	w := p.word(i)
+	from, to := w, ""
+	if m := clockRangeRe.FindStringSubmatch(w); m != nil {
+		from, to = m[1], m[2]
+	}
+	start, ok, err := parseClock(from, bare || to != "")
+	if !ok {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, p.errorAt(i, err.Error())
+	}
+	if p.hasTime || p.hasIn {
+		return 0, p.errorAt(i, "time is already set")
+	}
+	if to != "" {
+		if p.hasDuration {
+			return 0, p.errorAt(i, "duration is already set")
+		}
+		end, _, err := parseClock(to, true)
+		if err != nil {
+			return 0, p.errorAt(i, err.Error())
+		}
+		p.end = &end
+	}
+	p.clock, p.hasTime = start, true
+	return 1, nil
+}
+
+// parseClock: ok - s похоже на время, err - но такого времени нет.
+func parseClock(s string, bare bool) (clock, bool, error) {
+	m := clockRe.FindStringSubmatch(s)
+	if m == nil || (!bare && m[2] == "" && m[3] == "") {
+		return clock{}, false, nil
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if m[3] != "" {
+		if hour < 1 || hour > 12 {
+			return clock{}, true, fmt.Errorf("invalid time %q", s)
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return clock{}, true, fmt.Errorf("invalid time %q", s)
+	}
+	return clock{hour: hour, minute: minute}, true, nil
+}
+
+var (
+	relativeDays = map[string]int{
+		"today": 0, "tomorrow": 1,
+		"сегодня": 0, "завтра": 1, "послезавтра": 2,
+	}
+	weekdays = map[string]time.Weekday{
+		"monday": time.Monday, "mon": time.Monday,
+		"tuesday": time.Tuesday, "tue": time.Tuesday,
+		"wednesday": time.Wednesday, "wed": time.Wednesday,
+		"thursday": time.Thursday, "thu": time.Thursday,
+		"friday": time.Friday, "fri": time.Friday,
+		"saturday": time.Saturday, "sat": time.Saturday,
+		"sunday": time.Sunday, "sun": time.Sunday,
+		"понедельник": time.Monday, "пн": time.Monday,
+		"вторник": time.Tuesday, "вт": time.Tuesday,
+		"среда": time.Wednesday, "среду": time.Wednesday, "ср": time.Wednesday,
+		"четверг": time.Thursday, "чт": time.Thursday,
+		"пятница": time.Friday, "пятницу": time.Friday, "пт": time.Friday,
+		"суббота": time.Saturday, "субботу": time.Saturday, "сб": time.Saturday,
+		"воскресенье": time.Sunday, "вс": time.Sunday,
+	}
+	nextWords = map[string]bool{
+		"next": true, "следующий": true, "следующую": true, "следующее": true, "следующая": true,
+	}
+	isoDateRe = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	dotDateRe = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})(?:\.(\d{4}))?$`)
+)
+
+// dateAt - "tomorrow", "friday", "next monday", "2024-03-15", "15.03" или "15.03.2024".
+// День недели - ближайший, считая сегодня, с "next" - не раньше завтра.
+// Дата без года - ближайшая, не раньше сегодня.
+func (p *parser) dateAt(i int) (int, *ParseError) {
+	w := p.word(i)
+	today := startOfDay(p.now)
+	var (
+		date time.Time
+		n    = 1
+	)
+	if days, ok := relativeDays[w]; ok {
+		date = today.AddDate(0, 0, days)
+	} else if wd, ok := weekdays[w]; ok {
+		date = today.AddDate(0, 0, (int(wd)-int(today.Weekday())+7)%7)
+	} else if nextWords[w] && i+1 < len(p.tokens) {
+		wd, ok := weekdays[p.word(i+1)]
+		if !ok {
+			return 0, nil
+		}
+		date = today.AddDate(0, 0, (int(wd)-int(today.Weekday())+6)%7+1)
+		n = 2
+	} else {
+		var ok bool
+		var err error
+		date, ok, err = parseDate(w, today)
+		if !ok {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, p.errorAt(i, err.Error())
+		}
+	}
+
+	if p.hasDate || p.hasIn {
+		return 0, p.errorAt(i, "date is already set")
+	}
+	p.date, p.hasDate = date, true
+	return n, nil
+}
+
+func parseDate(w string, today time.Time) (time.Time, bool, error) {
+	var y, m, d int
+	if match := isoDateRe.FindStringSubmatch(w); match != nil {
+		y, _ = strconv.Atoi(match[1])
+		m, _ = strconv.Atoi(match[2])
+		d, _ = strconv.Atoi(match[3])
+	} else if match := dotDateRe.FindStringSubmatch(w); match != nil {
+		d, _ = strconv.Atoi(match[1])
+		m, _ = strconv.Atoi(match[2])
+		if match[3] != "" {
+			y, _ = strconv.Atoi(match[3])
+		}
+	} else {
+		return time.Time{}, false, nil
+	}
+
+	year := y
+	if year == 0 {
+		year = today.Year()
+	}
+	date := time.Date(year, time.Month(m), d, 0, 0, 0, 0, today.Location())
+	// time.Date нормализует 31.02 в март, такую дату не принимаем.
+	if date.Day() != d || int(date.Month()) != m {
+		return time.Time{}, true, fmt.Errorf("invalid date %q", w)
+	}
+	if y == 0 && date.Before(today) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date, true, nil
+}
+
+func (p *parser) durationPhrase(i int) (int, *ParseError) {
+	d, n, err := p.needDuration(i + 1)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, p.errorAt(i+1, "duration must be positive")
+	}
+	if p.end != nil || p.hasDuration {
+		return 0, p.errorAt(i, "duration is already set")
+	}
+	p.duration, p.hasDuration = d, true
+	return n + 1, nil
+}
+
+// inPhrase - "in 2h", "через 30 минут": начало относительно текущего момента.
+func (p *parser) inPhrase(i int) (int, *ParseError) {
+	d, n, err := p.needDuration(i + 1)
+	if err != nil {
+		return 0, err
+	}
+	if p.hasIn || p.hasDate || p.hasTime {
+		return 0, p.errorAt(i, "time is already set")
+	}
+	p.in, p.hasIn = d, true
+	return n + 1, nil
+}
+
+var reminderWords = map[string]bool{
+	"remind": true, "reminder": true, "напомнить": true, "напомни": true, "напоминание": true,
+}
+
+// reminderPhrase - "remind [me] 15m [before]", "напомнить [мне] за 15 минут".
+func (p *parser) reminderPhrase(i int) (int, *ParseError) {
+	n := 1
+	for _, skip := range []map[string]bool{{"me": true, "мне": true}, {"за": true}} {
+		if skip[p.word(i+n)] {
+			n++
+		}
+	}
+	d, dn, err := p.needDuration(i + n)
+	if err != nil {
+		return 0, err
+	}
+	n += dn
+	if p.word(i+n) == "before" {
+		n++
+	}
+	p.reminders = append(p.reminders, storage.Reminder{Anchor: storage.AnchorStart, Offset: -d})
+	return n, nil
+}
+
+var (
+	units = map[string]time.Duration{
+		"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+		"м": time.Minute, "мин": time.Minute, "минута": time.Minute, "минуту": time.Minute, "минуты": time.Minute,
+		"минут": time.Minute,
+		"h":     time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+		"ч": time.Hour, "час": time.Hour, "часа": time.Hour, "часов": time.Hour,
+		"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+		"д": 24 * time.Hour, "день": 24 * time.Hour, "дня": 24 * time.Hour, "дней": 24 * time.Hour,
+	}
+	durationPartRe = regexp.MustCompile(`(\d+)([^\d]+)`)
+	durationRe     = regexp.MustCompile(`^(?:\d+[^\d]+)+$`)
+)
+
+// needDuration - длительность после ключевого слова в токене i-1.
+func (p *parser) needDuration(i int) (time.Duration, int, *ParseError) {
+	d, n, err := p.durationAt(i)
+	if n == 0 && err == nil {
+		err = p.expected(i-1, "duration")
+	}
+	return d, n, err
+}
+
+// durationAt - "30m", "1h30m", "2ч", "30 минут", "an hour", "час".
+// n - число токенов, 0 - длительности нет, err - есть, но с ошибкой.
+func (p *parser) durationAt(i int) (time.Duration, int, *ParseError) {
+	w := p.word(i)
+
+	if durationRe.MatchString(w) {
+		var d time.Duration
+		for _, m := range durationPartRe.FindAllStringSubmatch(w, -1) {
+			unit, ok := units[m[2]]
+			if !ok {
+				return 0, 0, p.errorAt(i, fmt.Sprintf("unknown unit %q", m[2]))
+			}
+			k, err := strconv.Atoi(m[1])
+			if err != nil {
+				return 0, 0, p.errorAt(i, fmt.Sprintf("invalid duration %q", w))
+			}
+			d += time.Duration(k) * unit
+		}
+		return d, 1, nil
+	}
+
+	if k, err := strconv.Atoi(w); err == nil {
+		unit, ok := units[p.word(i+1)]
+		if !ok {
+			err := p.errorAt(i, fmt.Sprintf("unit expected after %q", w))
+			err.soft = true
+			return 0, 0, err
+		}
+		return time.Duration(k) * unit, 2, nil
+	}
+
+	n := 0
+	if w == "a" || w == "an" {
+		n++
+	}
+	// Единица без числа - одна такая единица: "an hour", "на час".
+	if unit, ok := units[p.word(i+n)]; ok && utf8.RuneCountInString(p.word(i+n)) > 2 {
+		return unit, n + 1, nil
+	}
+	return 0, 0, nil
+}
+
+func (p *parser) word(i int) string {
+	if i < 0 || i >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[i].word
+}
+
+// expected - после ключевого слова в токене i нет what.
+func (p *parser) expected(i int, what string) *ParseError {
+	at := i + 1
+	if at >= len(p.tokens) {
+		at = i
+	}
+	err := p.errorAt(at, fmt.Sprintf("%s expected after %q", what, p.tokens[i].text))
+	err.soft = true
+	return err
+}
+
+func (p *parser) errorAt(i int, msg string) *ParseError {
+	t := p.tokens[i]
+	return &ParseError{Pos: t.pos, Len: utf8.RuneCountInString(t.text), Msg: msg}
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+	pos, start, startPos := 0, -1, 0
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		raw := text[start:end]
+		word := strings.ToLower(strings.TrimRight(raw, ",;.!?"))
+		word = strings.ReplaceAll(word, "ё", "е")
+		if word == "" {
+			word = strings.ToLower(raw)
+		}
+		tokens = append(tokens, token{text: raw, word: word, pos: startPos, off: start, end: end})
+		start = -1
+	}
+	for off, r := range text {
+		if unicode.IsSpace(r) {
+			flush(off)
+		} else if start < 0 {
+			start, startPos = off, pos
+		}
+		pos++
+	}
+	flush(len(text))
+	return tokens
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,98 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	// Среда, 14:30 по Москве.
+	now := time.Date(2024, 1, 10, 14, 30, 0, 0, moscow)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, moscow)
+	}
+	before := func(d time.Duration) storage.Reminder {
+		return storage.Reminder{Anchor: storage.AnchorStart, Offset: -d}
+	}
+
+	tests := []struct {
+		text      string
+		title     string
+		start     time.Time
+		end       time.Time
+		reminders []storage.Reminder
+	}{
+		{
+			text: "Team sync tomorrow 10:00 for 30m remind 15m", title: "Team sync",
+			start: at(11, 10, 0), end: at(11, 10, 30), reminders: []storage.Reminder{before(15 * time.Minute)},
+		},
+		{
+			text: "Созвон завтра в 10:00 на 30 минут напомнить за 15м", title: "Созвон",
+			start: at(11, 10, 0), end: at(11, 10, 30), reminders: []storage.Reminder{before(15 * time.Minute)},
+		},
+		{text: "Lunch 13:00", title: "Lunch", start: at(11, 13, 0), end: at(11, 14, 0)},
+		{text: "Retro 16:00", title: "Retro", start: at(10, 16, 0), end: at(10, 17, 0)},
+		{text: "Demo at 3pm for an hour", title: "Demo", start: at(10, 15, 0), end: at(10, 16, 0)},
+		{text: "Meet at office on friday at 9", title: "Meet at office", start: at(12, 9, 0), end: at(12, 10, 0)},
+		{text: "Planning next wednesday 11:00-12:30", title: "Planning", start: at(17, 11, 0), end: at(17, 12, 30)},
+		{text: "Planning wednesday 11:00", title: "Planning", start: at(10, 11, 0), end: at(10, 12, 0)},
+		{text: "tomorrow 9:15 Standup", title: "Standup", start: at(11, 9, 15), end: at(11, 10, 15)},
+		{text: "Release 2024-02-01 18:00 for 2 hours", title: "Release", start: at(32, 18, 0), end: at(32, 20, 0)},
+		{text: "Отчёт 05.01 в 10", title: "Отчёт", start: time.Date(2025, 1, 5, 10, 0, 0, 0, moscow), end: time.Date(2025, 1, 5, 11, 0, 0, 0, moscow)},
+		{text: "Встреча в офисе в пятницу в 9:00 на час", title: "Встреча в офисе", start: at(12, 9, 0), end: at(12, 10, 0)},
+		{text: "Звонок через 2 часа", title: "Звонок", start: at(10, 16, 30), end: at(10, 17, 30)},
+		{text: "Party 23:00-1:00", title: "Party", start: at(10, 23, 0), end: at(11, 1, 0)},
+		{
+			text: "Review, tomorrow 10:00, remind me 1h before, remind 1d", title: "Review",
+			start: at(11, 10, 0), end: at(11, 11, 0),
+			reminders: []storage.Reminder{before(time.Hour), before(24 * time.Hour)},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.text, func(t *testing.T) {
+			event, err := Parse(tc.text, now)
+			require.NoError(t, err)
+			require.Equal(t, tc.title, event.Title)
+			require.True(t, tc.start.Equal(event.Start), event.Start.String())
+			require.True(t, tc.end.Equal(event.End), event.End.String())
+			require.Equal(t, tc.reminders, event.Reminders)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2024, 1, 10, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		text string
+		pos  int
+		len  int
+		msg  string
+	}{
+		{text: "Team sync tomorrow", pos: 18, msg: "time is required"},
+		{text: "tomorrow 10:00", pos: 0, msg: "title is required"},
+		{text: "Team sync tomorrow 25:00", pos: 19, len: 5, msg: `invalid time "25:00"`},
+		{text: "Sync tomorrow 10:00 for ever", pos: 24, len: 4, msg: `duration expected after "for"`},
+		{text: "Sync tomorrow 10:00 for 5 parsecs", pos: 24, len: 1, msg: `unit expected after "5"`},
+		{text: "Sync tomorrow 10:00 friday", pos: 20, len: 6, msg: "date is already set"},
+		{text: "Sync tomorrow 10:00 with Bob", pos: 20, len: 4, msg: `unexpected "with"`},
+		{text: "Синк 31.02 в 10:00", pos: 5, len: 5, msg: `invalid date "31.02"`},
+		{text: "Синк завтра в 10:00 напомнить", pos: 20, len: 9, msg: `duration expected after "напомнить"`},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.text, func(t *testing.T) {
+			_, err := Parse(tc.text, now)
+			var perr *ParseError
+			require.ErrorAs(t, err, &perr)
+			require.Equal(t, tc.msg, perr.Msg)
+			require.Equal(t, tc.pos, perr.Pos)
+			require.Equal(t, tc.len, perr.Len)
+		})
+	}
+}
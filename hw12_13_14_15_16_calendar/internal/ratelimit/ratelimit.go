@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/lru"
+)
+
+// Limit - пополнение Rate токенов в секунду, не больше Burst в корзине.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Limiter - token bucket на каждый ключ (пользователь, IP).
+// Корзины лежат в LRU ограниченного размера: вытесненный клиент просто
+// начинает с полной корзины, зато память не растёт от числа клиентов.
+type Limiter struct {
+	mu      sync.Mutex
+	limit   Limit
+	buckets *lru.Cache
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func New(limit Limit, capacity int) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		buckets: lru.New(capacity),
+		now:     time.Now,
+	}
+}
+
+// SetLimit меняет лимит на ходу, накопленные корзины клиентов сохраняются.
+func (l *Limiter) SetLimit(limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+}
+
+// Allow списывает токен для key. Если токенов нет, возвращает время,
+// через которое запрос можно повторить.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit.Rate <= 0 {
+		return true, 0
+	}
+
+	now := l.now()
+	b := bucket{tokens: float64(l.limit.Burst), last: now}
+	if v, ok := l.buckets.Get(key); ok {
+		b = v.(bucket)
+		elapsed := now.Sub(b.last).Seconds()
+		b.tokens = math.Min(float64(l.limit.Burst), b.tokens+elapsed*l.limit.Rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		l.buckets.Set(key, b)
+		wait := (1 - b.tokens) / l.limit.Rate
+		return false, time.Duration(wait * float64(time.Second))
+	}
+
+	b.tokens--
+	l.buckets.Set(key, b)
+	return true, 0
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newLimiter := func(limit Limit, capacity int) *Limiter {
+		l := New(limit, capacity)
+		l.now = func() time.Time { return now }
+		return l
+	}
+
+	t.Run("burst then refill", func(t *testing.T) {
+		l := newLimiter(Limit{Rate: 2, Burst: 3}, 10)
+
+		for i := 0; i < 3; i++ {
+			ok, _ := l.Allow("user")
+			require.True(t, ok)
+		}
+		ok, retryAfter := l.Allow("user")
+		require.False(t, ok)
+		require.Equal(t, 500*time.Millisecond, retryAfter)
+
+		ok, _ = l.Allow("other")
+		require.True(t, ok)
+
+		now = now.Add(500 * time.Millisecond)
+		ok, _ = l.Allow("user")
+		require.True(t, ok)
+		ok, _ = l.Allow("user")
+		require.False(t, ok)
+	})
+
+	t.Run("set limit", func(t *testing.T) {
+		l := newLimiter(Limit{Rate: 1, Burst: 1}, 10)
+		ok, _ := l.Allow("user")
+		require.True(t, ok)
+		ok, _ = l.Allow("user")
+		require.False(t, ok)
+
+		l.SetLimit(Limit{})
+		ok, _ = l.Allow("user")
+		require.True(t, ok, "zero rate disables the limit")
+
+		l.SetLimit(Limit{Rate: 1, Burst: 1})
+		ok, _ = l.Allow("user")
+		require.False(t, ok, "bucket state survives the change")
+	})
+
+	t.Run("zero rate is unlimited", func(t *testing.T) {
+		l := newLimiter(Limit{}, 1)
+		for i := 0; i < 100; i++ {
+			ok, _ := l.Allow("user")
+			require.True(t, ok)
+		}
+	})
+
+	t.Run("bounded state", func(t *testing.T) {
+		l := newLimiter(Limit{Rate: 1, Burst: 1}, 100)
+		for i := 0; i < 1000; i++ {
+			l.Allow(strconv.Itoa(i))
+		}
+		require.Equal(t, 100, l.buckets.Len())
+	})
+}
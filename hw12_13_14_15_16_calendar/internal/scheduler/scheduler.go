@@ -0,0 +1,249 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/tracing"
+)
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
+
+type Storage interface {
+	ListDueNotifications(ctx context.Context, from, to time.Time) ([]storage.Notification, error)
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	DeleteIdempotencyKeysBefore(ctx context.Context, before time.Time) (int, error)
+	ListSnoozedNotifications(ctx context.Context, from, to time.Time) ([]storage.Notification, error)
+	GetNotification(ctx context.Context, id string) (storage.Notification, error)
+	SaveNotification(ctx context.Context, n storage.Notification) error
+	GetWorkingHours(ctx context.Context, userID string) (storage.WorkingHours, error)
+}
+
+// Leader - см. leader.Elector, сканирует только лидер.
+type Leader interface {
+	IsLeader() bool
+}
+
+type Config struct {
+	Topic    string
+	Interval time.Duration
+	// Retention - события, закончившиеся раньше, удаляются, 0 - не удалять.
+	Retention time.Duration
+	// TrashRetention - сколько событие лежит в корзине до окончательного удаления, 0 - вечно.
+	TrashRetention time.Duration
+	// DeferToWorkingHours - уведомления вне рабочего времени пользователя откладываются
+	// до начала его следующего рабочего периода.
+	DeferToWorkingHours bool
+}
+
+// Scheduler раз в Interval выбирает события, по которым пора отправить уведомление,
+// и кладёт уведомления в топик хранителю.
+type Scheduler struct {
+	logger   Logger
+	storage  Storage
+	producer broker.Producer
+	leader   Leader
+	config   Config
+	now      func() time.Time
+	interval atomic.Int64
+	reset    chan struct{}
+}
+
+// New - leader может быть nil, если экземпляр планировщика один.
+func New(logger Logger, storage Storage, producer broker.Producer, leader Leader, config Config) *Scheduler {
+	s := &Scheduler{
+		logger:   logger,
+		storage:  storage,
+		producer: producer,
+		leader:   leader,
+		config:   config,
+		now:      time.Now,
+		reset:    make(chan struct{}, 1),
+	}
+	s.interval.Store(int64(config.Interval))
+	return s
+}
+
+// SetInterval меняет период сканирования, в том числе у уже запущенного планировщика.
+func (s *Scheduler) SetInterval(d time.Duration) {
+	s.interval.Store(int64(d))
+	select {
+	case s.reset <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(time.Duration(s.interval.Load()))
+	defer ticker.Stop()
+
+	last := s.now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.reset:
+			ticker.Reset(time.Duration(s.interval.Load()))
+			continue
+		case <-ticker.C:
+		}
+
+		if s.leader != nil && !s.leader.IsLeader() {
+			// Окно сдвигаем и у ведомых: при смене лидера новый не будет
+			// заново рассылать то, что уже отправил прежний.
+			last = s.now()
+			continue
+		}
+
+		now := s.now()
+		if err := s.Notify(ctx, last, now); err != nil {
+			s.logger.Error("scheduler: notify: " + err.Error())
+			continue
+		}
+		last = now
+
+		if err := s.Cleanup(ctx, now); err != nil {
+			s.logger.Error("scheduler: cleanup: " + err.Error())
+		}
+	}
+}
+
+// Notify отправляет по уведомлению на каждое напоминание, срабатывающее в [from, to),
+// и повторно - отложенные пользователем до момента из этого же окна.
+func (s *Scheduler) Notify(ctx context.Context, from, to time.Time) error {
+	due, err := s.storage.ListDueNotifications(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	snoozed, err := s.storage.ListSnoozedNotifications(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	var sent, deferred int
+	hours := map[string]*storage.WorkingHours{}
+	for _, n := range append(due, snoozed...) {
+		if s.config.DeferToWorkingHours {
+			ok, err := s.deferred(ctx, n, to, hours)
+			if err != nil {
+				return err
+			}
+			if ok {
+				deferred++
+				continue
+			}
+		}
+		if err := s.send(ctx, n); err != nil {
+			return err
+		}
+		sent++
+	}
+	if sent > 0 {
+		s.logger.Info("scheduler: sent " + strconv.Itoa(sent) + " notifications")
+	}
+	if deferred > 0 {
+		s.logger.Info("scheduler: deferred " + strconv.Itoa(deferred) + " notifications to working hours")
+	}
+	return nil
+}
+
+// deferred откладывает уведомление, если now - нерабочее время получателя, и сообщает, отложено ли оно.
+// hours - рабочее время уже встреченных пользователей, nil - не настроено.
+func (s *Scheduler) deferred(
+	ctx context.Context, n storage.Notification, now time.Time, hours map[string]*storage.WorkingHours,
+) (bool, error) {
+	w, ok := hours[n.UserID]
+	if !ok {
+		got, err := s.storage.GetWorkingHours(ctx, n.UserID)
+		switch {
+		case err == nil:
+			w = &got
+		case !errors.Is(err, storage.ErrWorkingHoursNotFound):
+			return false, err
+		}
+		hours[n.UserID] = w
+	}
+	if w == nil {
+		return false, nil
+	}
+	next := w.Next(now)
+	if !next.After(now) {
+		return false, nil
+	}
+
+	prev, err := s.storage.GetNotification(ctx, n.ID)
+	switch {
+	case errors.Is(err, storage.ErrNotificationNotFound):
+	case err != nil:
+		return false, err
+	case !prev.Status.CanTransition(storage.NotificationSnoozed):
+		// Уже в доставке или подтверждено - решит хранитель.
+		return false, nil
+	}
+	n.Status = storage.NotificationSnoozed
+	n.SnoozedUntil = next.UTC()
+	return true, s.storage.SaveNotification(ctx, n)
+}
+
+func (s *Scheduler) send(ctx context.Context, n storage.Notification) error {
+	ctx, span := tracing.Start(ctx, "scheduler.send")
+	defer span.Finish()
+	span.SetAttribute("event.id", n.EventID)
+	span.SetAttribute("notification.id", n.ID)
+
+	value, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	// Ключ - стабильный ID уведомления, по нему хранитель отбрасывает дубли.
+	msg := broker.Message{Key: n.ID, Value: value, Headers: map[string]string{}}
+	tracing.Inject(ctx, tracing.MapCarrier(msg.Headers))
+
+	err = s.producer.Publish(ctx, s.config.Topic, msg)
+	span.RecordError(err)
+	return err
+}
+
+// Cleanup удаляет события старше Retention, очищает корзину от событий старше TrashRetention
+// и удаляет истёкшие ключи идемпотентности.
+func (s *Scheduler) Cleanup(ctx context.Context, now time.Time) error {
+	if s.config.Retention > 0 {
+		n, err := s.storage.DeleteEventsBefore(ctx, now.Add(-s.config.Retention))
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			s.logger.Info("scheduler: deleted " + strconv.Itoa(n) + " old events")
+		}
+	}
+
+	if s.config.TrashRetention > 0 {
+		n, err := s.storage.PurgeTrash(ctx, now.Add(-s.config.TrashRetention))
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			s.logger.Info("scheduler: purged " + strconv.Itoa(n) + " events from trash")
+		}
+	}
+
+	n, err := s.storage.DeleteIdempotencyKeysBefore(ctx, now)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		s.logger.Info("scheduler: deleted " + strconv.Itoa(n) + " expired idempotency keys")
+	}
+	return nil
+}
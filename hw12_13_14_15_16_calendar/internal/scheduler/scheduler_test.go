@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type producer struct {
+	messages []broker.Message
+}
+
+func (p *producer) Publish(_ context.Context, _ string, msg broker.Message) error {
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	st := memorystorage.New()
+	for _, e := range []storage.Event{
+		{ID: "soon", Title: "soon", UserID: "u", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour), NotifyBefore: time.Hour},
+		{ID: "later", Title: "later", UserID: "u", Start: now.Add(5 * time.Hour), End: now.Add(6 * time.Hour), NotifyBefore: time.Hour},
+		{ID: "silent", Title: "silent", UserID: "u", Start: now.Add(-time.Hour), End: now},
+		{ID: "old", Title: "old", UserID: "u", Start: now.AddDate(-2, 0, 0), End: now.AddDate(-2, 0, 0).Add(time.Hour)},
+	} {
+		require.NoError(t, st.CreateEvent(ctx, e))
+	}
+
+	p := &producer{}
+	s := New(logger.Discard(), st, p, nil, Config{Topic: "notifications", Retention: 365 * 24 * time.Hour})
+
+	require.NoError(t, s.Notify(ctx, now.Add(-time.Minute), now.Add(time.Minute)))
+	require.Len(t, p.messages, 1)
+	require.Equal(t, "soon:start-3600s", p.messages[0].Key)
+
+	var n storage.Notification
+	require.NoError(t, json.Unmarshal(p.messages[0].Value, &n))
+	require.Equal(t, storage.Notification{
+		ID: "soon:start-3600s", EventID: "soon", ReminderID: "start-3600s",
+		Title: "soon", Start: now.Add(time.Hour), UserID: "u",
+	}, n)
+	require.NotEmpty(t, p.messages[0].Headers["traceparent"])
+
+	require.NoError(t, s.Cleanup(ctx, now))
+	_, err := st.GetEvent(ctx, "old")
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+	_, err = st.GetEvent(ctx, "silent")
+	require.NoError(t, err)
+}
+
+type follower struct{}
+
+func (follower) IsLeader() bool { return false }
+
+func TestSchedulerFollowerDoesNotSend(t *testing.T) {
+	st := memorystorage.New()
+	start := time.Now().Add(time.Hour)
+	require.NoError(t, st.CreateEvent(context.Background(), storage.Event{
+		ID: "1", Title: "t", UserID: "u", Start: start, End: start.Add(time.Hour), NotifyBefore: time.Hour,
+	}))
+
+	p := &producer{}
+	s := New(logger.Discard(), st, p, follower{}, Config{Interval: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.NoError(t, s.Run(ctx))
+	require.Empty(t, p.messages)
+}
+
+// ticks считает такты планировщика: IsLeader спрашивается на каждом.
+type ticks struct {
+	n atomic.Int64
+}
+
+func (t *ticks) IsLeader() bool {
+	t.n.Add(1)
+	return false
+}
+
+func TestSchedulerSetInterval(t *testing.T) {
+	l := &ticks{}
+	s := New(logger.Discard(), memorystorage.New(), &producer{}, l, Config{Interval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	s.SetInterval(10 * time.Millisecond)
+	require.Eventually(t, func() bool { return l.n.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestSchedulerResendsSnoozed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	st := memorystorage.New()
+	snoozed := storage.Notification{
+		ID: "1:r", EventID: "1", ReminderID: "r", Title: "t", Start: now.Add(time.Hour), UserID: "u",
+		Status: storage.NotificationSnoozed, SnoozedUntil: now,
+	}
+	require.NoError(t, st.SaveNotification(ctx, snoozed))
+	require.NoError(t, st.SaveNotification(ctx, storage.Notification{
+		ID: "2:r", EventID: "2", ReminderID: "r", Title: "t", Start: now.Add(time.Hour), UserID: "u",
+		Status: storage.NotificationSnoozed, SnoozedUntil: now.Add(time.Hour),
+	}))
+
+	p := &producer{}
+	s := New(logger.Discard(), st, p, nil, Config{Topic: "notifications"})
+
+	require.NoError(t, s.Notify(ctx, now.Add(-time.Minute), now.Add(time.Minute)))
+	require.Len(t, p.messages, 1)
+	require.Equal(t, "1:r", p.messages[0].Key)
+
+	var n storage.Notification
+	require.NoError(t, json.Unmarshal(p.messages[0].Value, &n))
+	require.Equal(t, snoozed, n)
+}
+
+func TestSchedulerMultipleReminders(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	st := memorystorage.New()
+	require.NoError(t, st.CreateEvent(ctx, storage.Event{
+		ID: "1", Title: "t", UserID: "u", Start: now.Add(24 * time.Hour), End: now.Add(25 * time.Hour),
+		Reminders: []storage.Reminder{
+			{Anchor: storage.AnchorStart, Offset: -24 * time.Hour},
+			{Anchor: storage.AnchorStart, Offset: -15 * time.Minute},
+			{At: now.Add(30 * time.Second)},
+		},
+	}))
+
+	p := &producer{}
+	s := New(logger.Discard(), st, p, nil, Config{Topic: "notifications"})
+
+	require.NoError(t, s.Notify(ctx, now.Add(-time.Minute), now.Add(time.Minute)))
+	keys := make([]string, 0, len(p.messages))
+	for _, m := range p.messages {
+		keys = append(keys, m.Key)
+	}
+	require.Equal(t, []string{"1:start-86400s", "1:at-" + strconv.FormatInt(now.Add(30*time.Second).Unix(), 10)}, keys)
+}
+
+func TestSchedulerPurgesTrash(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	st := memorystorage.New()
+	for _, e := range []storage.Event{
+		{ID: "stale", UserID: "u", Start: now, End: now.Add(time.Hour)},
+		{ID: "fresh", UserID: "u", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)},
+		{ID: "alive", UserID: "u", Start: now.Add(2 * time.Hour), End: now.Add(3 * time.Hour)},
+	} {
+		require.NoError(t, st.CreateEvent(ctx, e))
+	}
+	require.NoError(t, st.TrashEvent(ctx, "stale", now.AddDate(0, 0, -31)))
+	require.NoError(t, st.TrashEvent(ctx, "fresh", now.AddDate(0, 0, -1)))
+
+	s := New(logger.Discard(), st, &producer{}, nil, Config{Topic: "notifications", TrashRetention: 30 * 24 * time.Hour})
+	require.NoError(t, s.Cleanup(ctx, now))
+
+	_, err := st.GetEvent(ctx, "stale")
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+	trash, err := st.ListTrash(ctx, "u")
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	require.Equal(t, "fresh", trash[0].ID)
+	_, err = st.GetEvent(ctx, "alive")
+	require.NoError(t, err)
+}
+
+func TestSchedulerDefersToWorkingHours(t *testing.T) {
+	ctx := context.Background()
+	// Среда, 20:00 UTC.
+	now := time.Date(2024, 1, 10, 20, 0, 0, 0, time.UTC)
+
+	st := memorystorage.New()
+	w := storage.WorkingHours{UserID: "worker", TimeZone: "UTC"}
+	for d := time.Monday; d <= time.Friday; d++ {
+		w.Week[d] = storage.WorkPeriod{Start: 9 * time.Hour, End: 18 * time.Hour}
+	}
+	require.NoError(t, st.SaveWorkingHours(ctx, w))
+	for _, user := range []string{"worker", "anytime"} {
+		require.NoError(t, st.CreateEvent(ctx, storage.Event{
+			ID: user, Title: "t", UserID: user, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour),
+			NotifyBefore: time.Hour,
+		}))
+	}
+
+	p := &producer{}
+	s := New(logger.Discard(), st, p, nil, Config{Topic: "notifications", DeferToWorkingHours: true})
+
+	// Без рабочего времени уведомление уходит сразу, у worker - откладывается до 9 утра четверга.
+	require.NoError(t, s.Notify(ctx, now.Add(-time.Minute), now.Add(time.Minute)))
+	require.Len(t, p.messages, 1)
+	require.Equal(t, "anytime:start-3600s", p.messages[0].Key)
+
+	tomorrow := time.Date(2024, 1, 11, 9, 0, 0, 0, time.UTC)
+	n, err := st.GetNotification(ctx, "worker:start-3600s")
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationSnoozed, n.Status)
+	require.True(t, tomorrow.Equal(n.SnoozedUntil), n.SnoozedUntil.String())
+
+	require.NoError(t, s.Notify(ctx, tomorrow.Add(-time.Minute), tomorrow.Add(time.Minute)))
+	require.Len(t, p.messages, 2)
+	require.Equal(t, "worker:start-3600s", p.messages[1].Key)
+}
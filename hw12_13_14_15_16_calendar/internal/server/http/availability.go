@@ -0,0 +1,198 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// WorkingHours - рабочее время пользователя. Days - по дням недели ("monday"...),
+// день без периода - выходной. Holidays - нерабочие даты "2006-01-02".
+type WorkingHours struct {
+	TimeZone string                `json:"timeZone"`
+	Days     map[string]WorkPeriod `json:"days"`
+	Holidays []string              `json:"holidays,omitempty"`
+}
+
+// WorkPeriod - рабочий период дня, время вида "09:00", конец дня - "24:00".
+type WorkPeriod struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Interval - промежуток времени [Start, End).
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// FreeBusy - занятость пользователя: Busy - время событий, Free - свободное
+// рабочее время, а без настроенного рабочего времени (WorkingHours = false) - всё незанятое.
+type FreeBusy struct {
+	UserID       string     `json:"userId"`
+	WorkingHours bool       `json:"workingHours"`
+	Busy         []Interval `json:"busy"`
+	Free         []Interval `json:"free"`
+}
+
+func toWorkingHoursDTO(w storage.WorkingHours) WorkingHours {
+	dto := WorkingHours{TimeZone: w.TimeZone, Days: map[string]WorkPeriod{}, Holidays: w.Holidays}
+	for d, p := range w.Week {
+		if !p.Off() {
+			day := strings.ToLower(time.Weekday(d).String())
+			dto.Days[day] = WorkPeriod{Start: formatClock(p.Start), End: formatClock(p.End)}
+		}
+	}
+	return dto
+}
+
+func fromWorkingHoursDTO(dto WorkingHours) (storage.WorkingHours, error) {
+	w := storage.WorkingHours{TimeZone: dto.TimeZone, Holidays: dto.Holidays}
+	for name, p := range dto.Days {
+		d, ok := weekdayByName(name)
+		if !ok {
+			return storage.WorkingHours{}, fmt.Errorf("%w: unknown day %q", app.ErrInvalidWorkingHours, name)
+		}
+		start, err := parseClock(p.Start)
+		if err != nil {
+			return storage.WorkingHours{}, fmt.Errorf("%w: %s: %s", app.ErrInvalidWorkingHours, name, err.Error())
+		}
+		end, err := parseClock(p.End)
+		if err != nil {
+			return storage.WorkingHours{}, fmt.Errorf("%w: %s: %s", app.ErrInvalidWorkingHours, name, err.Error())
+		}
+		w.Week[d] = storage.WorkPeriod{Start: start, End: end}
+	}
+	return w, nil
+}
+
+func weekdayByName(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// parseClock разбирает "09:30" в смещение от полуночи, допускается "24:00".
+func parseClock(s string) (time.Duration, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || len(s) != 5 {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m > 0) {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+func toIntervalDTOs(list []storage.Interval) []Interval {
+	res := make([]Interval, 0, len(list))
+	for _, i := range list {
+		res = append(res, Interval{Start: i.Start.UTC(), End: i.End.UTC()})
+	}
+	return res
+}
+
+// workingHours обслуживает /working-hours: GET, PUT - заменить целиком, DELETE - убрать ограничения.
+func (s *Server) workingHours(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		hours, err := s.app.GetWorkingHours(r.Context(), userID)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toWorkingHoursDTO(hours))
+	case http.MethodPut:
+		var dto WorkingHours
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid json: %w", err))
+			return
+		}
+		hours, err := fromWorkingHoursDTO(dto)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		saved, err := s.app.SetWorkingHours(r.Context(), userID, hours)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toWorkingHoursDTO(saved))
+	case http.MethodDelete:
+		if err := s.app.DeleteWorkingHours(r.Context(), userID); err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// freeBusy обслуживает GET /freebusy?from=...&to=...&users=a,b, время в RFC 3339,
+// без users - занятость самого пользователя.
+func (s *Server) freeBusy(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	q := r.URL.Query()
+	from, err := time.Parse(time.RFC3339, q.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid from: %w", err))
+		return
+	}
+	to, err := time.Parse(time.RFC3339, q.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid to: %w", err))
+		return
+	}
+	var users []string
+	if v := q.Get("users"); v != "" {
+		users = strings.Split(v, ",")
+	}
+
+	list, err := s.app.FreeBusy(r.Context(), userID, users, from, to)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	res := make([]FreeBusy, 0, len(list))
+	for _, fb := range list {
+		res = append(res, FreeBusy{
+			UserID:       fb.UserID,
+			WorkingHours: fb.WorkingHours,
+			Busy:         toIntervalDTOs(fb.Busy),
+			Free:         toIntervalDTOs(fb.Free),
+		})
+	}
+	writeJSON(w, http.StatusOK, res)
+}
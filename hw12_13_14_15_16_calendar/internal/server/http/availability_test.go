@@ -0,0 +1,144 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkingHours(t *testing.T) {
+	h := newTestHandler()
+
+	w := doJSON(t, h, http.MethodGet, "/working-hours", "user-1", nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	hours := WorkingHours{
+		TimeZone: "Europe/Moscow",
+		Days: map[string]WorkPeriod{
+			"monday": {Start: "09:00", End: "18:00"}, "Friday": {Start: "10:00", End: "24:00"},
+		},
+		Holidays: []string{"2024-01-01"},
+	}
+	w = doJSON(t, h, http.MethodPut, "/working-hours", "user-1", hours)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(t, h, http.MethodGet, "/working-hours", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var got WorkingHours
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	hours.Days["friday"] = hours.Days["Friday"]
+	delete(hours.Days, "Friday")
+	require.Equal(t, hours, got)
+
+	for name, bad := range map[string]WorkingHours{
+		"time zone":        {TimeZone: "Mars/Olympus"},
+		"day":              {TimeZone: "UTC", Days: map[string]WorkPeriod{"someday": {Start: "09:00", End: "18:00"}}},
+		"clock":            {TimeZone: "UTC", Days: map[string]WorkPeriod{"monday": {Start: "9", End: "18:00"}}},
+		"end before start": {TimeZone: "UTC", Days: map[string]WorkPeriod{"monday": {Start: "18:00", End: "09:00"}}},
+		"holiday":          {TimeZone: "UTC", Holidays: []string{"01.01.2024"}},
+	} {
+		w = doJSON(t, h, http.MethodPut, "/working-hours", "user-1", bad)
+		require.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+
+	w = doJSON(t, h, http.MethodDelete, "/working-hours", "user-1", nil)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	w = doJSON(t, h, http.MethodDelete, "/working-hours", "user-1", nil)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func TestFreeBusy(t *testing.T) {
+	h := newTestHandler()
+	// Среда 10 января 2024.
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	at := func(hour int) time.Time { return day.Add(time.Duration(hour) * time.Hour) }
+	freeBusy := func(t *testing.T, userID string, users string) (int, []FreeBusy) {
+		t.Helper()
+		q := url.Values{"from": {at(0).Format(time.RFC3339)}, "to": {at(24).Format(time.RFC3339)}}
+		if users != "" {
+			q.Set("users", users)
+		}
+		w := doJSON(t, h, http.MethodGet, "/freebusy?"+q.Encode(), userID, nil)
+		var res []FreeBusy
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		}
+		return w.Code, res
+	}
+
+	for _, e := range []*Event{
+		{Title: "standup", Start: at(10), End: at(11)},
+		{Title: "lunch", Start: at(13), End: at(14)},
+		{Title: "late call", Start: at(20), End: at(21)},
+	} {
+		w := doJSON(t, h, http.MethodPost, "/events", "user-1", e)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	t.Run("without working hours", func(t *testing.T) {
+		code, res := freeBusy(t, "user-1", "")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, []FreeBusy{{
+			UserID: "user-1",
+			Busy:   []Interval{{at(10), at(11)}, {at(13), at(14)}, {at(20), at(21)}},
+			Free:   []Interval{{at(0), at(10)}, {at(11), at(13)}, {at(14), at(20)}, {at(21), at(24)}},
+		}}, res)
+	})
+
+	t.Run("free only in working hours", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPut, "/working-hours", "user-1", WorkingHours{
+			TimeZone: "UTC", Days: map[string]WorkPeriod{"wednesday": {Start: "09:00", End: "18:00"}},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		code, res := freeBusy(t, "user-1", "")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, []FreeBusy{{
+			UserID:       "user-1",
+			WorkingHours: true,
+			Busy:         []Interval{{at(10), at(11)}, {at(13), at(14)}, {at(20), at(21)}},
+			Free:         []Interval{{at(9), at(10)}, {at(11), at(13)}, {at(14), at(18)}},
+		}}, res)
+
+		// Праздник - свободного времени нет.
+		w = doJSON(t, h, http.MethodPut, "/working-hours", "user-1", WorkingHours{
+			TimeZone: "UTC", Days: map[string]WorkPeriod{"wednesday": {Start: "09:00", End: "18:00"}},
+			Holidays: []string{"2024-01-10"},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		_, res = freeBusy(t, "user-1", "")
+		require.Empty(t, res[0].Free)
+	})
+
+	t.Run("other users", func(t *testing.T) {
+		code, _ := freeBusy(t, "user-2", "user-1")
+		require.Equal(t, http.StatusForbidden, code)
+
+		// Открытый календарь делает занятость владельца видимой.
+		w := doJSON(t, h, http.MethodPost, "/calendars", "user-1", Calendar{Name: "team"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var c Calendar
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &c))
+		w = doJSON(t, h, http.MethodPut, "/calendars/"+c.ID+"/shares/user-2", "user-1", Share{Access: "read"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		code, res := freeBusy(t, "user-2", "user-1,user-2")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, res, 2)
+		require.Equal(t, "user-1", res[0].UserID)
+		require.Len(t, res[0].Busy, 3)
+		require.Equal(t, []Interval{{at(0), at(24)}}, res[1].Free)
+	})
+
+	t.Run("bad range", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodGet, "/freebusy?from="+at(5).Format(time.RFC3339)+"&to="+at(4).Format(time.RFC3339),
+			"user-1", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		w = doJSON(t, h, http.MethodGet, "/freebusy?from=yesterday", "user-1", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
@@ -0,0 +1,160 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/validator"
+)
+
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "bestEffort"
+)
+
+// BatchRequest - тело POST /events:batch. Mode - atomic (по умолчанию):
+// всё или ничего, или bestEffort: каждая операция сама по себе.
+type BatchRequest struct {
+	Mode       string           `json:"mode,omitempty"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation - create с Event, update с ID и Event или delete с ID.
+type BatchOperation struct {
+	Op    string `json:"op"`
+	ID    string `json:"id,omitempty"`
+	Event *Event `json:"event,omitempty"`
+}
+
+// BatchResponse - результаты в порядке операций, Error - почему отменён атомарный пакет.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// BatchResult - итог операции с тем же номером: статус как у одиночного запроса,
+// 424 - операция не выполнена из-за ошибки другой в атомарном пакете.
+// Errors - ошибки полей события, если оно не прошло проверку.
+type BatchResult struct {
+	Status int          `json:"status"`
+	Event  *Event       `json:"event,omitempty"`
+	Error  string       `json:"error,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// batch обслуживает POST /events:batch. Ошибки разбора запроса отклоняют весь пакет,
+// а неверные события и бизнес-ошибки попадают в результаты операций. Ответ 200,
+// если пакет применён, а для отменённого атомарного пакета - статус упавшей операции.
+func (s *Server) batch(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid json: %w", err))
+		return
+	}
+	var atomic bool
+	switch req.Mode {
+	case "", BatchAtomic:
+		atomic = true
+	case BatchBestEffort:
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("mode must be atomic or bestEffort"))
+		return
+	}
+	ops := make([]app.BatchOp, 0, len(req.Operations))
+	for i, dto := range req.Operations {
+		op, err := fromBatchDTO(dto)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("operations[%d]: %w", i, err))
+			return
+		}
+		ops = append(ops, op)
+	}
+
+	results, err := s.app.Batch(r.Context(), userID, ops, atomic)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+
+	colors := s.colors(r.Context(), userID)
+	code := http.StatusOK
+	res := BatchResponse{Results: make([]BatchResult, 0, len(results))}
+	for i, result := range results {
+		item := BatchResult{Status: batchStatus(ops[i].Kind)}
+		if result.Err != nil {
+			item.Status = appErrorStatus(result.Err)
+			item.Error = result.Err.Error()
+			item.Errors = newProblem(item.Status, result.Err).Errors
+			if item.Status == http.StatusInternalServerError {
+				s.logger.Error("internal error: " + result.Err.Error())
+				item.Error = "internal error"
+			}
+			if atomic && item.Status != http.StatusFailedDependency {
+				code = item.Status
+				res.Error = fmt.Sprintf("operations[%d]: %s", i, item.Error)
+			}
+		} else if ops[i].Kind != app.BatchDelete {
+			event := toDTO(result.Event, colors)
+			item.Event = &event
+		}
+		res.Results = append(res.Results, item)
+	}
+	writeJSON(w, code, res)
+}
+
+// fromBatchDTO возвращает ошибку, только если операция составлена неверно,
+// а неверное событие отклоняет одну операцию через Rejected.
+func fromBatchDTO(dto BatchOperation) (app.BatchOp, error) {
+	op := app.BatchOp{Kind: app.BatchOpKind(dto.Op), ID: dto.ID}
+	switch op.Kind {
+	case app.BatchCreate:
+	case app.BatchUpdate, app.BatchDelete:
+		if dto.ID == "" {
+			return app.BatchOp{}, fmt.Errorf("%w: id is required for %s", app.ErrInvalidBatch, dto.Op)
+		}
+	default:
+		return app.BatchOp{}, fmt.Errorf("%w: op must be create, update or delete", app.ErrInvalidBatch)
+	}
+
+	if op.Kind == app.BatchDelete {
+		return op, nil
+	}
+	if dto.Event == nil {
+		return app.BatchOp{}, fmt.Errorf("%w: event is required for %s", app.ErrInvalidBatch, dto.Op)
+	}
+	err := validator.Validate(dto.Event)
+	if err == nil {
+		op.Event, err = fromDTO(*dto.Event)
+	}
+	if err != nil {
+		op.Rejected = nested("event", err)
+	}
+	return op, nil
+}
+
+// batchStatus - статус успешной операции, как у одиночного запроса.
+func batchStatus(kind app.BatchOpKind) int {
+	switch kind {
+	case app.BatchCreate:
+		return http.StatusCreated
+	case app.BatchDelete:
+		return http.StatusNoContent
+	case app.BatchUpdate:
+		return http.StatusOK
+	}
+	return http.StatusOK
+}
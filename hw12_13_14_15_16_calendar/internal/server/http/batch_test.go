@@ -0,0 +1,139 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	h := newTestHandler()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	event := func(title string, hour int) *Event {
+		from := start.Add(time.Duration(hour) * time.Hour)
+		return &Event{Title: title, Start: from, End: from.Add(time.Hour)}
+	}
+	list := func(t *testing.T) []Event {
+		t.Helper()
+		var events []Event
+		w := doJSON(t, h, http.MethodGet, "/events?period=day&date=2024-01-10", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		return events
+	}
+
+	w := doJSON(t, h, http.MethodPost, "/events", "user-1", event("existing", 0))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var existing Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &existing))
+
+	t.Run("atomic rolls back", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/events:batch", "user-1", BatchRequest{Operations: []BatchOperation{
+			{Op: "create", Event: event("first", 2)},
+			{Op: "delete", ID: existing.ID},
+			{Op: "create", Event: event("overlaps first", 2)},
+			{Op: "create", Event: event("never tried", 4)},
+		}})
+		require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+		var res BatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Results, 4)
+		require.Equal(t, http.StatusFailedDependency, res.Results[0].Status)
+		require.Nil(t, res.Results[0].Event)
+		require.Equal(t, http.StatusFailedDependency, res.Results[1].Status)
+		require.Equal(t, http.StatusConflict, res.Results[2].Status)
+		require.Equal(t, http.StatusFailedDependency, res.Results[3].Status)
+		require.Contains(t, res.Error, "operations[2]")
+
+		events := list(t)
+		require.Len(t, events, 1)
+		require.Equal(t, existing.ID, events[0].ID)
+	})
+
+	t.Run("best effort", func(t *testing.T) {
+		moved := event("existing (moved)", 6)
+		w := doJSON(t, h, http.MethodPost, "/events:batch", "user-1", BatchRequest{
+			Mode: BatchBestEffort,
+			Operations: []BatchOperation{
+				{Op: "create", Event: event("first", 2)},
+				{Op: "create", Event: event("overlaps first", 2)},
+				{Op: "update", ID: existing.ID, Event: moved},
+				{Op: "delete", ID: "missing"},
+				{Op: "create", Event: &Event{Title: "ends before start", Start: start, End: start.Add(-time.Hour)}},
+			},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var res BatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Empty(t, res.Error)
+		statuses := make([]int, 0, len(res.Results))
+		for _, r := range res.Results {
+			statuses = append(statuses, r.Status)
+		}
+		require.Equal(t, []int{
+			http.StatusCreated, http.StatusConflict, http.StatusOK, http.StatusNotFound, http.StatusBadRequest,
+		}, statuses)
+		require.Equal(t, "first", res.Results[0].Event.Title)
+		require.Equal(t, "existing (moved)", res.Results[2].Event.Title)
+		require.Equal(t, []FieldError{{Field: "event.end", Message: "must be after start"}}, res.Results[4].Errors)
+		require.Len(t, list(t), 2)
+	})
+
+	t.Run("atomic applies everything", func(t *testing.T) {
+		events := list(t)
+		w := doJSON(t, h, http.MethodPost, "/events:batch", "user-1", BatchRequest{
+			Mode: BatchAtomic,
+			Operations: []BatchOperation{
+				{Op: "delete", ID: events[0].ID},
+				{Op: "delete", ID: events[1].ID},
+				{Op: "create", Event: event("new", 2)},
+			},
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var res BatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Equal(t, http.StatusNoContent, res.Results[0].Status)
+		require.Equal(t, http.StatusCreated, res.Results[2].Status)
+
+		events = list(t)
+		require.Len(t, events, 1)
+		require.Equal(t, "new", events[0].Title)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		events := list(t)
+		w := doJSON(t, h, http.MethodPost, "/events:batch", "user-2", BatchRequest{Operations: []BatchOperation{
+			{Op: "delete", ID: events[0].ID},
+		}})
+		require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+		require.Len(t, list(t), 1)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for name, req := range map[string]BatchRequest{
+			"empty":        {},
+			"unknown mode": {Mode: "sometimes", Operations: []BatchOperation{{Op: "delete", ID: "x"}}},
+			"unknown op":   {Operations: []BatchOperation{{Op: "upsert", ID: "x"}}},
+			"no id":        {Operations: []BatchOperation{{Op: "delete"}}},
+			"no event":     {Operations: []BatchOperation{{Op: "create"}}},
+			"bad duration": {Operations: []BatchOperation{{Op: "create", Event: &Event{Title: "x", NotifyBefore: "soon"}}}},
+			"too many ops": {Operations: make([]BatchOperation, 501)},
+		} {
+			if name == "too many ops" {
+				for i := range req.Operations {
+					req.Operations[i] = BatchOperation{Op: "delete", ID: "x"}
+				}
+			}
+			w := doJSON(t, h, http.MethodPost, "/events:batch", "user-1", req)
+			require.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+
+		w := doJSON(t, h, http.MethodGet, "/events:batch", "user-1", nil)
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
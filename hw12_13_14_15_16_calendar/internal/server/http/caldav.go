@@ -0,0 +1,744 @@
+package internalhttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/ical"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+const (
+	davPrefix      = "/dav/"
+	davMethods     = "OPTIONS, PROPFIND, REPORT, GET, HEAD, PUT, DELETE"
+	methodPropfind = "PROPFIND"
+	methodReport   = "REPORT"
+
+	// personalCalendar - личный календарь пользователя в путях CalDAV.
+	personalCalendar = "personal"
+	icalContentType  = "text/calendar; charset=utf-8"
+	maxICalendarSize = 1 << 20
+	timeRangeLayout  = "20060102T150405Z"
+)
+
+// Границы "всего времени": календарь целиком нужен для списка событий и getctag.
+var (
+	davFrom = time.Time{}
+	davTo   = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+var (
+	propResourceType     = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName      = xml.Name{Space: nsDAV, Local: "displayname"}
+	propCurrentPrincipal = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL     = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propPrivileges       = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}
+	propETag             = xml.Name{Space: nsDAV, Local: "getetag"}
+	propContentType      = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propReports          = xml.Name{Space: nsDAV, Local: "supported-report-set"}
+	propHomeSet          = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propComponents       = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propCalendarData     = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propCTag             = xml.Name{Space: nsCalServer, Local: "getctag"}
+)
+
+type davKind int
+
+const (
+	davRoot davKind = iota
+	davPrincipal
+	davHome
+	davCalendar
+	davObject
+)
+
+// davPath - разобранный путь CalDAV:
+//
+//	/dav/                                     корень
+//	/dav/principals/{user}/                   пользователь
+//	/dav/calendars/{user}/                    его календари
+//	/dav/calendars/{user}/{calendar}/         личный (personal) или именованный календарь
+//	/dav/calendars/{user}/{calendar}/{id}.ics событие
+type davPath struct {
+	kind davKind
+	user string
+	// calendar - ID именованного календаря, пустой - личный.
+	calendar string
+	event    string
+}
+
+func parseDAVPath(path string) (davPath, bool) {
+	rest := strings.Trim(strings.TrimPrefix(path, davPrefix), "/")
+	if rest == "" {
+		return davPath{kind: davRoot}, true
+	}
+	parts := strings.Split(rest, "/")
+	for _, part := range parts {
+		if part == "" {
+			return davPath{}, false
+		}
+	}
+
+	switch {
+	case len(parts) == 2 && parts[0] == "principals":
+		return davPath{kind: davPrincipal, user: parts[1]}, true
+	case len(parts) == 2 && parts[0] == "calendars":
+		return davPath{kind: davHome, user: parts[1]}, true
+	case len(parts) == 3 && parts[0] == "calendars":
+		return davPath{kind: davCalendar, user: parts[1], calendar: calendarID(parts[2])}, true
+	case len(parts) == 4 && parts[0] == "calendars" && strings.HasSuffix(parts[3], ".ics") && parts[3] != ".ics":
+		return davPath{
+			kind: davObject, user: parts[1], calendar: calendarID(parts[2]), event: strings.TrimSuffix(parts[3], ".ics"),
+		}, true
+	default:
+		return davPath{}, false
+	}
+}
+
+func calendarID(name string) string {
+	if name == personalCalendar {
+		return ""
+	}
+	return name
+}
+
+func (p davPath) href() string {
+	switch p.kind {
+	case davPrincipal:
+		return davPrefix + "principals/" + url.PathEscape(p.user) + "/"
+	case davHome:
+		return davPrefix + "calendars/" + url.PathEscape(p.user) + "/"
+	case davCalendar:
+		name := p.calendar
+		if name == "" {
+			name = personalCalendar
+		}
+		return davPath{kind: davHome, user: p.user}.href() + url.PathEscape(name) + "/"
+	case davObject:
+		return davPath{kind: davCalendar, user: p.user, calendar: p.calendar}.href() + url.PathEscape(p.event) + ".ics"
+	default:
+		return davPrefix
+	}
+}
+
+// davResource - ресурс и всё, что нужно для его свойств. У корня user - текущий пользователь.
+type davResource struct {
+	davPath
+	// calendar - именованный календарь, events - события календаря.
+	calendar storage.Calendar
+	events   []storage.Event
+	// ics - событие в iCalendar.
+	ics []byte
+}
+
+func objectResource(userID string, e storage.Event) davResource {
+	return davResource{
+		davPath: davPath{kind: davObject, user: userID, calendar: e.CalendarID, event: e.ID},
+		ics:     ical.Encode(e),
+	}
+}
+
+// propNames - свойства ресурса для allprop и propname.
+func (r davResource) propNames() []xml.Name {
+	names := []xml.Name{propResourceType, propCurrentPrincipal}
+	switch r.kind {
+	case davRoot:
+	case davPrincipal:
+		names = append(names, propDisplayName, propPrincipalURL, propHomeSet)
+	case davHome:
+		names = append(names, propDisplayName)
+	case davCalendar:
+		names = append(names, propDisplayName, propComponents, propReports, propPrivileges, propCTag, propETag)
+	case davObject:
+		names = append(names, propETag, propContentType)
+	}
+	return names
+}
+
+// prop - XML содержимого свойства, false - у ресурса такого свойства нет.
+func (r davResource) prop(name xml.Name) (string, bool) {
+	switch name {
+	case propResourceType:
+		return r.resourceType(), true
+	case propCurrentPrincipal:
+		return hrefXML(davPath{kind: davPrincipal, user: r.user}.href()), true
+	}
+
+	switch r.kind {
+	case davPrincipal:
+		switch name {
+		case propDisplayName:
+			return escapeText(r.user), true
+		case propPrincipalURL:
+			return hrefXML(r.href()), true
+		case propHomeSet:
+			return hrefXML(davPath{kind: davHome, user: r.user}.href()), true
+		}
+	case davHome:
+		if name == propDisplayName {
+			return escapeText(r.user), true
+		}
+	case davCalendar:
+		return r.calendarProp(name)
+	case davObject:
+		switch name {
+		case propETag:
+			return escapeText(etag(r.ics)), true
+		case propContentType:
+			return icalContentType + "; component=VEVENT", true
+		case propCalendarData:
+			return escapeText(string(r.ics)), true
+		}
+	case davRoot:
+	}
+	return "", false
+}
+
+func (r davResource) resourceType() string {
+	switch r.kind {
+	case davRoot, davHome:
+		return "<d:collection/>"
+	case davPrincipal:
+		return "<d:collection/><d:principal/>"
+	case davCalendar:
+		return "<d:collection/><c:calendar/>"
+	default:
+		return ""
+	}
+}
+
+func (r davResource) calendarProp(name xml.Name) (string, bool) {
+	switch name {
+	case propDisplayName:
+		if r.calendar.ID == "" {
+			return "Personal", true
+		}
+		return escapeText(r.calendar.Name), true
+	case propComponents:
+		return `<c:comp name="VEVENT"/>`, true
+	case propReports:
+		return "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>", true
+	case propPrivileges:
+		access := storage.AccessOwner
+		if r.calendar.ID != "" {
+			access = r.calendar.Access(r.user)
+		}
+		privileges := "<d:privilege><d:read/></d:privilege>"
+		if access.CanWrite() {
+			privileges += "<d:privilege><d:write/></d:privilege><d:privilege><d:write-content/></d:privilege>" +
+				"<d:privilege><d:bind/></d:privilege><d:privilege><d:unbind/></d:privilege>"
+		}
+		return privileges, true
+	case propCTag, propETag:
+		return escapeText(collectionTag(r.user, r.events)), true
+	default:
+		return "", false
+	}
+}
+
+// etag - ETag события, ics - его представление из ical.Encode.
+func etag(ics []byte) string {
+	sum := sha256.Sum256(ics)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// collectionTag - ETag календаря, меняется при любом изменении его событий.
+func collectionTag(userID string, events []storage.Event) string {
+	tags := make([]string, 0, len(events))
+	for _, e := range events {
+		tags = append(tags, e.ID+" "+etag(objectResource(userID, e).ics))
+	}
+	sort.Strings(tags)
+	return etag([]byte(strings.Join(tags, "\n")))
+}
+
+// propRequest - запрошенные свойства: names из <d:prop>, nil - все свойства ресурса.
+type propRequest struct {
+	names    []xml.Name
+	nameOnly bool
+}
+
+func parsePropfind(n *davNode) (propRequest, error) {
+	if n == nil {
+		return propRequest{}, nil
+	}
+	if n.XMLName != (xml.Name{Space: nsDAV, Local: "propfind"}) {
+		return propRequest{}, errors.New("propfind element expected")
+	}
+	if prop := n.child(nsDAV, "prop"); prop != nil {
+		return propRequest{names: prop.names()}, nil
+	}
+	return propRequest{nameOnly: n.child(nsDAV, "propname") != nil}, nil
+}
+
+func writeResource(ms *multistatus, res davResource, req propRequest) {
+	names := req.names
+	if names == nil {
+		names = res.propNames()
+	}
+	var (
+		found   []davProp
+		missing []xml.Name
+	)
+	for _, name := range names {
+		value, ok := res.prop(name)
+		switch {
+		case !ok:
+			missing = append(missing, name)
+		case req.nameOnly:
+			found = append(found, davProp{name: name})
+		default:
+			found = append(found, davProp{name: name, value: value})
+		}
+	}
+	ms.response(res.href(), found, missing)
+}
+
+// dav обслуживает CalDAV (RFC 4791) под /dav/. У пользователя личный календарь
+// и именованные, включая открытые ему, событие - ресурс {id}.ics в своём календаре.
+// Пользователь видит только свои пути, чужие календари - в своём списке.
+func (s *Server) dav(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="calendar", charset="UTF-8"`)
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	p, ok := parseDAVPath(r.URL.Path)
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if p.kind != davRoot && p.user != userID {
+		writeError(w, http.StatusForbidden, errors.New("resources of another user"))
+		return
+	}
+	p.user = userID
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("DAV", "1, 3, calendar-access")
+		w.Header().Set("Allow", davMethods)
+		w.WriteHeader(http.StatusOK)
+	case methodPropfind:
+		s.propfind(w, r, p)
+	case methodReport:
+		s.report(w, r, p)
+	case http.MethodGet, http.MethodHead:
+		s.getObject(w, r, p)
+	case http.MethodPut:
+		s.putObject(w, r, p)
+	case http.MethodDelete:
+		s.deleteObject(w, r, p)
+	default:
+		davMethodNotAllowed(w)
+	}
+}
+
+func davMethodNotAllowed(w http.ResponseWriter) {
+	w.Header().Set("Allow", davMethods)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+// propfind поддерживает Depth 0 и 1. Без Depth отвечаем как на 0: клиенты,
+// не приславшие заголовок, спрашивают о самом ресурсе.
+func (s *Server) propfind(w http.ResponseWriter, r *http.Request, p davPath) {
+	depth := r.Header.Get("Depth")
+	if depth != "" && depth != "0" && depth != "1" {
+		writeDAVError(w, http.StatusForbidden, "<d:propfind-finite-depth/>")
+		return
+	}
+	body, err := decodeDAV(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid xml: %w", err))
+		return
+	}
+	req, err := parsePropfind(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := s.davResource(r.Context(), p)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	resources := []davResource{res}
+	if depth == "1" {
+		children, err := s.davChildren(r.Context(), res)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		resources = append(resources, children...)
+	}
+
+	ms := newMultistatus()
+	for _, res := range resources {
+		writeResource(ms, res, req)
+	}
+	ms.writeTo(w)
+}
+
+func (s *Server) davResource(ctx context.Context, p davPath) (davResource, error) {
+	switch p.kind {
+	case davCalendar:
+		res := davResource{davPath: p}
+		if p.calendar != "" {
+			c, err := s.app.GetCalendar(ctx, p.user, p.calendar)
+			if err != nil {
+				return davResource{}, err
+			}
+			res.calendar = c
+		}
+		events, err := s.app.CalendarEvents(ctx, p.user, p.calendar, davFrom, davTo)
+		if err != nil {
+			return davResource{}, err
+		}
+		res.events = events
+		return res, nil
+	case davObject:
+		e, err := s.davEvent(ctx, p)
+		if err != nil {
+			return davResource{}, err
+		}
+		return objectResource(p.user, e), nil
+	default:
+		return davResource{davPath: p}, nil
+	}
+}
+
+func (s *Server) davChildren(ctx context.Context, res davResource) ([]davResource, error) {
+	switch res.kind {
+	case davHome:
+		calendars, err := s.app.ListCalendars(ctx, res.user)
+		if err != nil {
+			return nil, err
+		}
+		ids := []string{""}
+		for _, c := range calendars {
+			ids = append(ids, c.ID)
+		}
+		children := make([]davResource, 0, len(ids))
+		for _, id := range ids {
+			child, err := s.davResource(ctx, davPath{kind: davCalendar, user: res.user, calendar: id})
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		}
+		return children, nil
+	case davCalendar:
+		children := make([]davResource, 0, len(res.events))
+		for _, e := range res.events {
+			children = append(children, objectResource(res.user, e))
+		}
+		return children, nil
+	default:
+		return nil, nil
+	}
+}
+
+// davEvent - событие ресурса: событие из другого календаря по этому пути не найти.
+func (s *Server) davEvent(ctx context.Context, p davPath) (storage.Event, error) {
+	e, err := s.app.GetEvent(ctx, p.user, p.event)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if e.CalendarID != p.calendar {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	return e, nil
+}
+
+// report поддерживает calendar-query и calendar-multiget по календарю.
+func (s *Server) report(w http.ResponseWriter, r *http.Request, p davPath) {
+	req, err := decodeDAV(r.Body)
+	if err == nil && req == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid xml: %w", err))
+		return
+	}
+	if p.kind != davCalendar {
+		writeDAVError(w, http.StatusForbidden, "<d:supported-report/>")
+		return
+	}
+	var props propRequest
+	if prop := req.child(nsDAV, "prop"); prop != nil {
+		props.names = prop.names()
+	}
+
+	switch req.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		s.calendarQuery(w, r, p, req, props)
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		s.calendarMultiget(w, r, p, req, props)
+	default:
+		writeDAVError(w, http.StatusForbidden, "<d:supported-report/>")
+	}
+}
+
+func (s *Server) calendarQuery(w http.ResponseWriter, r *http.Request, p davPath, req *davNode, props propRequest) {
+	filter := req.child(nsCalDAV, "filter")
+	if filter == nil {
+		writeDAVError(w, http.StatusForbidden, "<c:valid-filter/>")
+		return
+	}
+	from, to, match, condition := queryRange(filter)
+	if condition != "" {
+		writeDAVError(w, http.StatusForbidden, condition)
+		return
+	}
+
+	ms := newMultistatus()
+	if match {
+		events, err := s.app.CalendarEvents(r.Context(), p.user, p.calendar, from, to)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		for _, e := range events {
+			writeResource(ms, objectResource(p.user, e), props)
+		}
+	}
+	ms.writeTo(w)
+}
+
+// queryRange переводит фильтр calendar-query в интервал событий. Поддержано то, что
+// шлют клиенты: VCALENDAR с VEVENT и необязательным time-range, match=false - фильтру
+// не подходит ни одно событие, condition - нарушенное предусловие.
+func queryRange(filter *davNode) (from, to time.Time, match bool, condition string) {
+	calendar := filter.child(nsCalDAV, "comp-filter")
+	if calendar == nil || calendar.attr("name") != "VCALENDAR" || len(filter.Children) != 1 {
+		return time.Time{}, time.Time{}, false, "<c:valid-filter/>"
+	}
+
+	from, to, match = davFrom, davTo, true
+	for i := range calendar.Children {
+		f := &calendar.Children[i]
+		if f.XMLName != (xml.Name{Space: nsCalDAV, Local: "comp-filter"}) {
+			return time.Time{}, time.Time{}, false, "<c:supported-filter/>"
+		}
+		mustExist := f.child(nsCalDAV, "is-not-defined") == nil
+		if f.attr("name") != "VEVENT" {
+			// Кроме VEVENT в ресурсах ничего нет.
+			match = match && !mustExist
+			continue
+		}
+		if !mustExist {
+			match = false
+			continue
+		}
+		for j := range f.Children {
+			if f.Children[j].XMLName != (xml.Name{Space: nsCalDAV, Local: "time-range"}) {
+				return time.Time{}, time.Time{}, false, "<c:supported-filter/>"
+			}
+			var ok bool
+			if from, to, ok = timeRange(&f.Children[j], from, to); !ok {
+				return time.Time{}, time.Time{}, false, "<c:valid-filter/>"
+			}
+		}
+	}
+	return from, to, match, ""
+}
+
+// timeRange сужает [from, to) атрибутами start и end, любой из них можно не указывать.
+func timeRange(n *davNode, from, to time.Time) (time.Time, time.Time, bool) {
+	if v := n.attr("start"); v != "" {
+		t, err := time.Parse(timeRangeLayout, v)
+		if err != nil {
+			return from, to, false
+		}
+		if t.After(from) {
+			from = t
+		}
+	}
+	if v := n.attr("end"); v != "" {
+		t, err := time.Parse(timeRangeLayout, v)
+		if err != nil {
+			return from, to, false
+		}
+		if t.Before(to) {
+			to = t
+		}
+	}
+	return from, to, true
+}
+
+func (s *Server) calendarMultiget(w http.ResponseWriter, r *http.Request, p davPath, req *davNode, props propRequest) {
+	ms := newMultistatus()
+	for _, n := range req.Children {
+		if n.XMLName != (xml.Name{Space: nsDAV, Local: "href"}) {
+			continue
+		}
+		href := strings.TrimSpace(n.Text)
+		res, err := s.hrefResource(r.Context(), p, href)
+		if err != nil {
+			code := appErrorStatus(err)
+			if code == http.StatusInternalServerError {
+				s.writeAppError(w, err)
+				return
+			}
+			ms.status(href, code)
+			continue
+		}
+		writeResource(ms, res, props)
+	}
+	ms.writeTo(w)
+}
+
+// hrefResource - событие по ссылке из calendar-multiget, ссылка должна вести в календарь запроса.
+func (s *Server) hrefResource(ctx context.Context, calendar davPath, href string) (davResource, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return davResource{}, storage.ErrEventNotFound
+	}
+	p, ok := parseDAVPath(u.Path)
+	if !ok || p.kind != davObject || p.user != calendar.user || p.calendar != calendar.calendar {
+		return davResource{}, storage.ErrEventNotFound
+	}
+	return s.davResource(ctx, p)
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, p davPath) {
+	if p.kind != davObject {
+		davMethodNotAllowed(w)
+		return
+	}
+	e, err := s.davEvent(r.Context(), p)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	ics := ical.Encode(e)
+	w.Header().Set("Content-Type", icalContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(ics)))
+	w.Header().Set("ETag", etag(ics))
+	w.Write(ics)
+}
+
+// putObject создаёт или заменяет событие. Категории и метки в календаре - метки
+// пользователя, CATEGORIES их не меняет. ETag в ответе нет: сохранённое событие
+// отличается от присланного (RFC 4791, 5.3.4), и клиент перечитает его сам.
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, p davPath) {
+	if p.kind != davObject {
+		davMethodNotAllowed(w)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxICalendarSize))
+	if err != nil {
+		code := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, code, err)
+		return
+	}
+	event, err := ical.Decode(data)
+	switch {
+	case errors.Is(err, ical.ErrInvalid):
+		writeDAVError(w, http.StatusForbidden, "<c:valid-calendar-data/>")
+		return
+	case err != nil:
+		writeDAVError(w, http.StatusForbidden, "<c:valid-calendar-object-resource/>")
+		return
+	case event.ID == "":
+		event.ID = p.event
+	case event.ID != p.event:
+		// ID события - имя ресурса, другой UID сохранить негде.
+		writeDAVError(w, http.StatusForbidden, "<c:valid-calendar-object-resource/>")
+		return
+	}
+
+	existing, err := s.app.GetEvent(r.Context(), p.user, p.event)
+	found := err == nil
+	if err != nil && !errors.Is(err, storage.ErrEventNotFound) {
+		s.writeAppError(w, err)
+		return
+	}
+	if found && existing.CalendarID != p.calendar {
+		href := hrefXML(objectResource(p.user, existing).href())
+		writeDAVError(w, http.StatusForbidden, "<c:no-uid-conflict>"+href+"</c:no-uid-conflict>")
+		return
+	}
+	current := ""
+	if found {
+		current = etag(ical.Encode(existing))
+	}
+	if !davPreconditions(r, current) {
+		writeError(w, http.StatusPreconditionFailed, errors.New("precondition failed"))
+		return
+	}
+
+	event.CalendarID = p.calendar
+	code := http.StatusCreated
+	if found {
+		event.Category, event.Labels = existing.Category, existing.Labels
+		_, err = s.app.UpdateEvent(r.Context(), p.user, p.event, event)
+		code = http.StatusNoContent
+	} else {
+		_, err = s.app.CreateEvent(r.Context(), p.user, event)
+	}
+	if errors.Is(err, app.ErrInvalidEvent) {
+		writeDAVError(w, http.StatusForbidden, "<c:valid-calendar-object-resource/>")
+		return
+	}
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	w.WriteHeader(code)
+}
+
+func (s *Server) deleteObject(w http.ResponseWriter, r *http.Request, p davPath) {
+	if p.kind != davObject {
+		davMethodNotAllowed(w)
+		return
+	}
+	e, err := s.davEvent(r.Context(), p)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	if !davPreconditions(r, etag(ical.Encode(e))) {
+		writeError(w, http.StatusPreconditionFailed, errors.New("precondition failed"))
+		return
+	}
+	if err := s.app.DeleteEvent(r.Context(), p.user, p.event); err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// davPreconditions проверяет If-Match и If-None-Match, current - ETag ресурса, пустой - ресурса нет.
+func davPreconditions(r *http.Request, current string) bool {
+	if m := r.Header.Get("If-Match"); m != "" {
+		if current == "" || (m != "*" && !strings.Contains(m, current)) {
+			return false
+		}
+	}
+	if m := r.Header.Get("If-None-Match"); m != "" {
+		if current != "" && (m == "*" || strings.Contains(m, current)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+package internalhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	eventUID  = "5f1c2a9e-8d4b-4c1e-9a57-0b8e1f3c2d10"
+	eventHref = "/dav/calendars/user-1/personal/" + eventUID + ".ics"
+)
+
+// doDAV повторяет запрос клиента, тело - файл из testdata/caldav или пустое.
+func doDAV(
+	t *testing.T, h http.Handler, method, path, file string, header map[string]string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	var body []byte
+	if file != "" {
+		var err error
+		body, err = os.ReadFile(filepath.Join("testdata", "caldav", file))
+		require.NoError(t, err)
+	}
+	r := httptest.NewRequest(method, path, bytes.NewReader(body))
+	r.SetBasicAuth("user-1", "app-password")
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// davResponses разбирает ответ 207: href - ответ по ресурсу.
+func davResponses(t *testing.T, w *httptest.ResponseRecorder) map[string]davNode {
+	t.Helper()
+	require.Equal(t, http.StatusMultiStatus, w.Code, w.Body.String())
+	var ms davNode
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &ms))
+	require.Equal(t, xml.Name{Space: nsDAV, Local: "multistatus"}, ms.XMLName)
+
+	res := make(map[string]davNode)
+	for _, r := range ms.Children {
+		res[r.child(nsDAV, "href").Text] = r
+	}
+	return res
+}
+
+// davProps - свойства ответа по статусу: "200" или "404".
+func davProps(t *testing.T, resp davNode, status string) map[string]davNode {
+	t.Helper()
+	res := make(map[string]davNode)
+	for _, ps := range resp.Children {
+		if ps.XMLName.Local != "propstat" || !strings.Contains(ps.child(nsDAV, "status").Text, " "+status+" ") {
+			continue
+		}
+		for _, p := range ps.child(nsDAV, "prop").Children {
+			res[p.XMLName.Local] = p
+		}
+	}
+	return res
+}
+
+func TestCalDAVSession(t *testing.T) {
+	h := newTestHandler()
+
+	w := doDAV(t, h, http.MethodOptions, "/dav/", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("DAV"), "calendar-access")
+
+	w = doDAV(t, h, methodPropfind, "/.well-known/caldav", "", nil)
+	require.Equal(t, http.StatusMovedPermanently, w.Code)
+	require.Equal(t, "/dav/", w.Header().Get("Location"))
+
+	// DAVx5 ищет пользователя и его календари.
+	depth0, depth1 := map[string]string{"Depth": "0"}, map[string]string{"Depth": "1"}
+	w = doDAV(t, h, methodPropfind, "/dav/", "davx5-discovery.xml", depth0)
+	found := davProps(t, davResponses(t, w)["/dav/"], "200")
+	require.Equal(t, "/dav/principals/user-1/", found["current-user-principal"].child(nsDAV, "href").Text)
+	require.Contains(t, davProps(t, davResponses(t, w)["/dav/"], "404"), "addressbook-home-set")
+
+	w = doDAV(t, h, methodPropfind, "/dav/principals/user-1/", "davx5-discovery.xml", depth0)
+	found = davProps(t, davResponses(t, w)["/dav/principals/user-1/"], "200")
+	require.Equal(t, "/dav/calendars/user-1/", found["calendar-home-set"].child(nsDAV, "href").Text)
+	require.NotNil(t, found["resourcetype"].child(nsDAV, "principal"))
+
+	// Thunderbird создаёт событие, время в зоне Europe/Moscow.
+	created := map[string]string{"If-None-Match": "*", "Content-Type": "text/calendar; charset=utf-8"}
+	w = doDAV(t, h, http.MethodPut, eventHref, "thunderbird-event.ics", created)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doDAV(t, h, http.MethodPut, eventHref, "thunderbird-event.ics", created)
+	require.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = doDAV(t, h, methodPropfind, "/dav/calendars/user-1/", "thunderbird-calendars.xml", depth1)
+	responses := davResponses(t, w)
+	require.Len(t, responses, 2)
+	calendar := davProps(t, responses["/dav/calendars/user-1/personal/"], "200")
+	require.NotNil(t, calendar["resourcetype"].child(nsCalDAV, "calendar"))
+	require.Equal(t, "VEVENT", calendar["supported-calendar-component-set"].Children[0].attr("name"))
+	require.Len(t, calendar["current-user-privilege-set"].Children, 5)
+	ctag := calendar["getctag"].Text
+	require.NotEmpty(t, ctag)
+	require.Contains(t, davProps(t, responses["/dav/calendars/user-1/personal/"], "404"), "owner")
+
+	w = doDAV(t, h, methodReport, "/dav/calendars/user-1/personal/", "thunderbird-query.xml", depth1)
+	responses = davResponses(t, w)
+	require.Len(t, responses, 1)
+	etag := davProps(t, responses[eventHref], "200")["getetag"].Text
+	require.NotEmpty(t, etag)
+
+	w = doDAV(t, h, methodReport, "/dav/calendars/user-1/personal/", "davx5-multiget.xml", depth1)
+	responses = davResponses(t, w)
+	require.Len(t, responses, 2)
+	found = davProps(t, responses[eventHref], "200")
+	require.Equal(t, etag, found["getetag"].Text)
+	require.Contains(t, found["calendar-data"].Text, "UID:"+eventUID+"\r\n")
+	require.Contains(t, found["calendar-data"].Text, "DTSTART:20240110T100000Z\r\n")
+	require.Contains(t, found["calendar-data"].Text, "TRIGGER:-PT15M\r\n")
+	missing := responses["/dav/calendars/user-1/personal/missing.ics"]
+	require.Contains(t, missing.child(nsDAV, "status").Text, "404")
+
+	w = doDAV(t, h, http.MethodGet, eventHref, "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, etag, w.Header().Get("ETag"))
+	require.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, found["calendar-data"].Text, w.Body.String())
+
+	// Событие из CalDAV видно в API.
+	w = doJSON(t, h, http.MethodGet, "/events/"+eventUID, "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var event Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+	require.Equal(t, time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC), event.Start.UTC())
+	require.Equal(t, "Quarter goals", event.Description)
+
+	// Apple Calendar переносит событие, ETag устарел после этого.
+	w = doDAV(t, h, http.MethodPut, eventHref, "apple-event.ics", map[string]string{"If-Match": `"stale"`})
+	require.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = doDAV(t, h, http.MethodPut, eventHref, "apple-event.ics", map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	require.Empty(t, w.Header().Get("ETag"))
+
+	w = doDAV(t, h, http.MethodGet, eventHref, "", nil)
+	require.NotEqual(t, etag, w.Header().Get("ETag"))
+	require.Contains(t, w.Body.String(), "SUMMARY:Planning\\, moved\r\n")
+	require.Contains(t, w.Body.String(), "DTSTART:20240110T110000Z\r\n")
+	require.Contains(t, w.Body.String(), "TRIGGER:-PT30M\r\n")
+	require.NotContains(t, w.Body.String(), "TRIGGER:-PT15M\r\n")
+	etag = w.Header().Get("ETag")
+
+	w = doDAV(t, h, methodPropfind, "/dav/calendars/user-1/personal/", "thunderbird-calendars.xml", depth0)
+	calendar = davProps(t, davResponses(t, w)["/dav/calendars/user-1/personal/"], "200")
+	require.NotEqual(t, ctag, calendar["getctag"].Text)
+
+	w = doDAV(t, h, http.MethodDelete, eventHref, "", map[string]string{"If-Match": etag})
+	require.Equal(t, http.StatusNoContent, w.Code)
+	w = doDAV(t, h, http.MethodGet, eventHref, "", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCalDAVErrors(t *testing.T) {
+	h := newTestHandler()
+	put := func(ics string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPut, eventHref, strings.NewReader(ics))
+		r.SetBasicAuth("user-1", "")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	event := func(props string) string {
+		return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:" + eventUID + "\r\nSUMMARY:x\r\n" +
+			props + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+
+	tests := []struct {
+		name      string
+		w         *httptest.ResponseRecorder
+		code      int
+		condition string
+	}{
+		{
+			name: "invalid data", w: put("BEGIN:VCARD\r\nEND:VCARD\r\n"),
+			code: http.StatusForbidden, condition: "valid-calendar-data",
+		},
+		{
+			name: "recurring", w: put(event("DTSTART:20240110T100000Z\r\nDURATION:PT1H\r\nRRULE:FREQ=DAILY\r\n")),
+			code: http.StatusForbidden, condition: "valid-calendar-object-resource",
+		},
+		{
+			name: "uid differs from name",
+			w:    put(strings.Replace(event("DTSTART:20240110T100000Z\r\nDURATION:PT1H\r\n"), eventUID, "other", 1)),
+			code: http.StatusForbidden, condition: "valid-calendar-object-resource",
+		},
+		{
+			name: "no title",
+			w:    put(strings.Replace(event("DTSTART:20240110T100000Z\r\nDURATION:PT1H\r\n"), "SUMMARY:x\r\n", "", 1)),
+			code: http.StatusForbidden, condition: "valid-calendar-object-resource",
+		},
+		{
+			name: "infinite depth",
+			w: doDAV(t, h, methodPropfind, "/dav/calendars/user-1/", "thunderbird-calendars.xml",
+				map[string]string{"Depth": "infinity"}),
+			code: http.StatusForbidden, condition: "propfind-finite-depth",
+		},
+		{
+			name: "report on home",
+			w:    doDAV(t, h, methodReport, "/dav/calendars/user-1/", "thunderbird-query.xml", nil),
+			code: http.StatusForbidden, condition: "supported-report",
+		},
+		{
+			name: "another user",
+			w:    doDAV(t, h, methodPropfind, "/dav/calendars/user-2/", "thunderbird-calendars.xml", nil),
+			code: http.StatusForbidden,
+		},
+		{
+			name: "unknown calendar",
+			w:    doDAV(t, h, methodPropfind, "/dav/calendars/user-1/work/", "thunderbird-calendars.xml", nil),
+			code: http.StatusNotFound,
+		},
+		{
+			name: "get collection",
+			w:    doDAV(t, h, http.MethodGet, "/dav/calendars/user-1/personal/", "", nil),
+			code: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.code, tc.w.Code, tc.w.Body.String())
+			if tc.condition == "" {
+				return
+			}
+			var e davNode
+			require.NoError(t, xml.Unmarshal(tc.w.Body.Bytes(), &e))
+			require.Equal(t, tc.condition, e.Children[0].XMLName.Local)
+		})
+	}
+}
+
+func TestCalDAVSharedCalendar(t *testing.T) {
+	h := newTestHandler()
+	w := doJSON(t, h, http.MethodPost, "/calendars", "user-2", Calendar{ID: "team", Name: "Team"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doJSON(t, h, http.MethodPut, "/calendars/team/shares/user-1", "user-2", Share{Access: "read"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	depth1 := map[string]string{"Depth": "1"}
+	w = doDAV(t, h, methodPropfind, "/dav/calendars/user-1/", "thunderbird-calendars.xml", depth1)
+	responses := davResponses(t, w)
+	require.Len(t, responses, 3)
+	team := davProps(t, responses["/dav/calendars/user-1/team/"], "200")
+	require.Len(t, team["current-user-privilege-set"].Children, 1)
+
+	w = doDAV(t, h, http.MethodPut, "/dav/calendars/user-1/team/"+eventUID+".ics", "thunderbird-event.ics", nil)
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+}
@@ -0,0 +1,162 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// Calendar - именованный календарь. Access - права текущего пользователя:
+// owner, write или read, Shares видит только владелец.
+type Calendar struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	OwnerID string  `json:"ownerId"`
+	Access  string  `json:"access"`
+	Shares  []Share `json:"shares,omitempty"`
+}
+
+// Share - доступ пользователя к календарю: read или write.
+type Share struct {
+	UserID string `json:"userId"`
+	Access string `json:"access"`
+}
+
+func toCalendarDTO(c storage.Calendar, userID string) Calendar {
+	dto := Calendar{ID: c.ID, Name: c.Name, OwnerID: c.OwnerID, Access: string(c.Access(userID))}
+	if c.OwnerID != userID {
+		return dto
+	}
+	for id, access := range c.Shares {
+		dto.Shares = append(dto.Shares, Share{UserID: id, Access: string(access)})
+	}
+	sort.Slice(dto.Shares, func(i, j int) bool {
+		return dto.Shares[i].UserID < dto.Shares[j].UserID
+	})
+	return dto
+}
+
+// calendars обслуживает /calendars: GET - свои и открытые пользователю календари, POST - создать.
+func (s *Server) calendars(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		calendars, err := s.app.ListCalendars(r.Context(), userID)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		res := make([]Calendar, 0, len(calendars))
+		for _, c := range calendars {
+			res = append(res, toCalendarDTO(c, userID))
+		}
+		writeJSON(w, http.StatusOK, res)
+	case http.MethodPost:
+		var dto Calendar
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid json: %w", err))
+			return
+		}
+		created, err := s.app.CreateCalendar(r.Context(), userID, storage.Calendar{ID: dto.ID, Name: dto.Name})
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, toCalendarDTO(created, userID))
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// calendar обслуживает /calendars/{id} и права на него /calendars/{id}/shares/{userId}.
+func (s *Server) calendar(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/calendars/"), "/")
+	if id == "" {
+		writeError(w, http.StatusNotFound, storage.ErrCalendarNotFound)
+		return
+	}
+	if rest != "" {
+		shareWith := strings.TrimPrefix(rest, "shares/")
+		if shareWith == rest || shareWith == "" || strings.Contains(shareWith, "/") {
+			writeError(w, http.StatusNotFound, errors.New("not found"))
+			return
+		}
+		s.share(w, r, userID, id, shareWith)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		c, err := s.app.GetCalendar(r.Context(), userID, id)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toCalendarDTO(c, userID))
+	case http.MethodPut:
+		var dto Calendar
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid json: %w", err))
+			return
+		}
+		updated, err := s.app.UpdateCalendar(r.Context(), userID, id, storage.Calendar{Name: dto.Name})
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toCalendarDTO(updated, userID))
+	case http.MethodDelete:
+		if err := s.app.DeleteCalendar(r.Context(), userID, id); err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// share обслуживает /calendars/{id}/shares/{userId}: PUT с {"access"} открывает
+// календарь пользователю, DELETE закрывает.
+func (s *Server) share(w http.ResponseWriter, r *http.Request, userID, id, shareWith string) {
+	switch r.Method {
+	case http.MethodPut:
+		var dto Share
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid json: %w", err))
+			return
+		}
+		c, err := s.app.ShareCalendar(r.Context(), userID, id, shareWith, storage.Access(dto.Access))
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toCalendarDTO(c, userID))
+	case http.MethodDelete:
+		if err := s.app.UnshareCalendar(r.Context(), userID, id, shareWith); err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
@@ -0,0 +1,156 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendars(t *testing.T) {
+	h := newTestHandler()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	day := func(t *testing.T, userID, query string) []Event {
+		t.Helper()
+		var events []Event
+		w := doJSON(t, h, http.MethodGet, "/events?period=day&date=2024-01-10"+query, userID, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		return events
+	}
+	share := func(t *testing.T, path, access string) {
+		t.Helper()
+		w := doJSON(t, h, http.MethodPut, path+"/shares/user-2", "user-1", Share{Access: access})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w := doJSON(t, h, http.MethodPost, "/calendars", "user-1", Calendar{Name: "Team"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var team Calendar
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &team))
+	require.Equal(t, "user-1", team.OwnerID)
+	require.Equal(t, "owner", team.Access)
+	path := "/calendars/" + team.ID
+
+	w = doJSON(t, h, http.MethodPost, "/calendars", "user-1", Calendar{Name: "Team"})
+	require.Equal(t, http.StatusConflict, w.Code)
+	w = doJSON(t, h, http.MethodPost, "/calendars", "user-1", Calendar{})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title: "standup", Start: start, End: start.Add(time.Hour), CalendarID: team.ID,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var standup Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &standup))
+	w = doJSON(t, h, http.MethodPost, "/events", "user-2", Event{
+		Title: "dentist", Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour),
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	t.Run("not shared", func(t *testing.T) {
+		require.Len(t, day(t, "user-2", ""), 1)
+		w := doJSON(t, h, http.MethodGet, "/events/"+standup.ID, "user-2", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodGet, path, "user-2", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodPost, "/events", "user-2", Event{
+			Title: "sneaky", Start: start.Add(4 * time.Hour), End: start.Add(5 * time.Hour), CalendarID: team.ID,
+		})
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("read access", func(t *testing.T) {
+		share(t, path, "read")
+
+		var calendars []Calendar
+		w := doJSON(t, h, http.MethodGet, "/calendars", "user-2", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &calendars))
+		require.Equal(t, []Calendar{{ID: team.ID, Name: "Team", OwnerID: "user-1", Access: "read"}}, calendars)
+
+		events := day(t, "user-2", "")
+		require.Len(t, events, 2)
+		require.Equal(t, "standup", events[0].Title)
+		require.Equal(t, "dentist", events[1].Title)
+		require.Len(t, day(t, "user-2", "&calendar="+team.ID), 1)
+
+		w = doJSON(t, h, http.MethodGet, "/events/"+standup.ID, "user-2", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		w = doJSON(t, h, http.MethodPut, "/events/"+standup.ID, "user-2", standup)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		w = doJSON(t, h, http.MethodDelete, "/events/"+standup.ID, "user-2", nil)
+		require.Equal(t, http.StatusForbidden, w.Code)
+		w = doJSON(t, h, http.MethodPut, path, "user-2", Calendar{Name: "Mine"})
+		require.Equal(t, http.StatusForbidden, w.Code)
+		w = doJSON(t, h, http.MethodPut, path+"/shares/user-3", "user-2", Share{Access: "read"})
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("write access", func(t *testing.T) {
+		share(t, path, "write")
+
+		w := doJSON(t, h, http.MethodPost, "/events", "user-2", Event{
+			Title: "retro", Start: start.Add(4 * time.Hour), End: start.Add(5 * time.Hour), CalendarID: team.ID,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var retro Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &retro))
+		require.Equal(t, "user-1", retro.UserID, "event belongs to the calendar owner")
+
+		retro.Title = "retro (moved)"
+		w = doJSON(t, h, http.MethodPut, "/events/"+retro.ID, "user-2", retro)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var history []HistoryEntry
+		w = doJSON(t, h, http.MethodGet, "/events/"+retro.ID+"/history", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		require.Len(t, history, 2)
+		require.Equal(t, "user-2", history[1].Actor)
+
+		retro.CalendarID = ""
+		w = doJSON(t, h, http.MethodPut, "/events/"+retro.ID, "user-2", retro)
+		require.Equal(t, http.StatusBadRequest, w.Code, "cannot move to another owner")
+
+		w = doJSON(t, h, http.MethodDelete, "/events/"+retro.ID, "user-2", nil)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = doJSON(t, h, http.MethodPost, "/events/"+retro.ID+"/restore", "user-2", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(t, h, http.MethodGet, path, "user-1", nil)
+		var got Calendar
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Equal(t, []Share{{UserID: "user-2", Access: "write"}}, got.Shares)
+	})
+
+	t.Run("invalid share", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPut, path+"/shares/user-2", "user-1", Share{Access: "admin"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(t, h, http.MethodPut, path+"/shares/user-1", "user-1", Share{Access: "read"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(t, h, http.MethodDelete, path+"/shares/user-3", "user-1", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodGet, path+"/other", "user-1", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("leave and delete", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodDelete, path+"/shares/user-2", "user-2", nil)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Len(t, day(t, "user-2", ""), 1)
+
+		w = doJSON(t, h, http.MethodDelete, path, "user-2", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodDelete, path, "user-1", nil)
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		events := day(t, "user-1", "")
+		require.Len(t, events, 2)
+		for _, e := range events {
+			require.Empty(t, e.CalendarID)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package internalhttp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const (
+	nsDAV       = "DAV:"
+	nsCalDAV    = "urn:ietf:params:xml:ns:caldav"
+	nsCalServer = "http://calendarserver.org/ns/"
+
+	xmlHeader = `<?xml version="1.0" encoding="utf-8"?>` + "\n"
+	xmlns     = ` xmlns:d="` + nsDAV + `" xmlns:c="` + nsCalDAV + `" xmlns:cs="` + nsCalServer + `"`
+)
+
+var davPrefixes = map[string]string{nsDAV: "d", nsCalDAV: "c", nsCalServer: "cs"}
+
+// davNode - элемент XML-запроса. Запросы WebDAV расширяемы и приходят
+// с произвольными префиксами, поэтому разбираются деревом, а не структурами.
+type davNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []davNode  `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+func (n davNode) child(space, local string) *davNode {
+	for i := range n.Children {
+		if n.Children[i].XMLName.Space == space && n.Children[i].XMLName.Local == local {
+			return &n.Children[i]
+		}
+	}
+	return nil
+}
+
+func (n davNode) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// names - имена дочерних элементов, например свойств из <d:prop>.
+func (n davNode) names() []xml.Name {
+	res := make([]xml.Name, 0, len(n.Children))
+	for _, c := range n.Children {
+		res = append(res, c.XMLName)
+	}
+	return res
+}
+
+// decodeDAV разбирает тело запроса, пустое тело - nil без ошибки.
+func decodeDAV(r io.Reader) (*davNode, error) {
+	var n davNode
+	err := xml.NewDecoder(r).Decode(&n)
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// davProp - найденное свойство, value - готовый XML его содержимого.
+type davProp struct {
+	name  xml.Name
+	value string
+}
+
+// multistatus собирает ответ 207, элементы пишутся вручную: encoding/xml
+// не умеет префиксы пространств имён, а клиенты к ним бывают придирчивы.
+type multistatus struct {
+	b bytes.Buffer
+}
+
+func newMultistatus() *multistatus {
+	m := &multistatus{}
+	m.b.WriteString(xmlHeader + "<d:multistatus" + xmlns + ">")
+	return m
+}
+
+// response - ответ по ресурсу: найденные свойства с 200, отсутствующие с 404.
+func (m *multistatus) response(href string, found []davProp, missing []xml.Name) {
+	m.b.WriteString("<d:response>" + hrefXML(href))
+	if len(found) > 0 {
+		m.b.WriteString("<d:propstat><d:prop>")
+		for _, p := range found {
+			m.element(p.name, p.value)
+		}
+		m.b.WriteString("</d:prop>" + statusLine(http.StatusOK) + "</d:propstat>")
+	}
+	if len(missing) > 0 {
+		m.b.WriteString("<d:propstat><d:prop>")
+		for _, name := range missing {
+			m.element(name, "")
+		}
+		m.b.WriteString("</d:prop>" + statusLine(http.StatusNotFound) + "</d:propstat>")
+	}
+	m.b.WriteString("</d:response>")
+}
+
+// status - ответ по ресурсу без свойств, например 404 в calendar-multiget.
+func (m *multistatus) status(href string, code int) {
+	m.b.WriteString("<d:response>" + hrefXML(href) + statusLine(code) + "</d:response>")
+}
+
+func (m *multistatus) element(name xml.Name, value string) {
+	tag, decl := name.Local, ""
+	if prefix, ok := davPrefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		decl = ` xmlns="` + escapeText(name.Space) + `"`
+	}
+	if value == "" {
+		m.b.WriteString("<" + tag + decl + "/>")
+		return
+	}
+	m.b.WriteString("<" + tag + decl + ">" + value + "</" + tag + ">")
+}
+
+func (m *multistatus) writeTo(w http.ResponseWriter) {
+	m.b.WriteString("</d:multistatus>")
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write(m.b.Bytes())
+}
+
+func statusLine(code int) string {
+	return "<d:status>HTTP/1.1 " + strconv.Itoa(code) + " " + http.StatusText(code) + "</d:status>"
+}
+
+// writeDAVError - ответ с нарушенным предусловием, condition - XML элемента, например "<c:valid-calendar-data/>".
+func writeDAVError(w http.ResponseWriter, code int, condition string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write([]byte(xmlHeader + "<d:error" + xmlns + ">" + condition + "</d:error>"))
+}
+
+// escapeText экранирует и кавычки, поэтому годится и для атрибутов.
+func escapeText(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s)) //nolint:errcheck // bytes.Buffer не возвращает ошибок
+	return b.String()
+}
+
+func hrefXML(href string) string {
+	return "<d:href>" + escapeText(href) + "</d:href>"
+}
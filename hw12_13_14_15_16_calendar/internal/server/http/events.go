@@ -0,0 +1,342 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/validator"
+)
+
+// DateLayout - формат параметра date в запросах списка событий.
+const DateLayout = "2006-01-02"
+
+// Event - представление события в API, NotifyBefore - длительность вида "15m".
+// Color только для чтения: это цвет метки-категории. Теги validate проверяются
+// при разборе тела запроса, до бизнес-правил приложения.
+type Event struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title" validate:"required|max:200"`
+	Start        time.Time  `json:"start" validate:"required"`
+	End          time.Time  `json:"end" validate:"required|after:Start|within:Start,8784h"`
+	Description  string     `json:"description,omitempty" validate:"max:4000"`
+	UserID       string     `json:"userId"`
+	NotifyBefore string     `json:"notifyBefore,omitempty" validate:"duration:0s,672h"`
+	Category     string     `json:"category,omitempty"`
+	Labels       []string   `json:"labels,omitempty"`
+	Color        string     `json:"color,omitempty"`
+	Reminders    []Reminder `json:"reminders,omitempty" validate:"max:10"`
+	DeletedAt    *time.Time `json:"deletedAt,omitempty"`
+	CalendarID   string     `json:"calendarId,omitempty"`
+}
+
+// Reminder - напоминание: относительно начала или конца события (RelativeTo
+// и Offset вида "-15m" или "-1d") либо в момент At. ID только для чтения.
+type Reminder struct {
+	ID         string     `json:"id,omitempty"`
+	RelativeTo string     `json:"relativeTo,omitempty" validate:"in:start,end"`
+	Offset     string     `json:"offset,omitempty"`
+	At         *time.Time `json:"at,omitempty"`
+}
+
+func toDTO(e storage.Event, colors map[string]string) Event {
+	dto := Event{
+		ID:          e.ID,
+		Title:       e.Title,
+		Start:       e.Start,
+		End:         e.End,
+		Description: e.Description,
+		UserID:      e.UserID,
+		Category:    e.Category,
+		Labels:      e.Labels,
+		Color:       colors[e.Category],
+		CalendarID:  e.CalendarID,
+	}
+	if e.NotifyBefore > 0 {
+		dto.NotifyBefore = e.NotifyBefore.String()
+	}
+	for _, r := range e.Reminders {
+		dto.Reminders = append(dto.Reminders, toReminderDTO(r))
+	}
+	if e.Trashed() {
+		deletedAt := e.DeletedAt
+		dto.DeletedAt = &deletedAt
+	}
+	return dto
+}
+
+func toReminderDTO(r storage.Reminder) Reminder {
+	dto := Reminder{ID: r.ID()}
+	if r.Anchor == "" {
+		at := r.At
+		dto.At = &at
+		return dto
+	}
+	dto.RelativeTo = string(r.Anchor)
+	dto.Offset = r.Offset.String()
+	return dto
+}
+
+func fromReminderDTO(dto Reminder) (storage.Reminder, error) {
+	if dto.At != nil {
+		if dto.RelativeTo != "" || dto.Offset != "" {
+			return storage.Reminder{}, validator.ValidationErrors{
+				{Field: "at", Err: errors.New("reminder is either relative or absolute")},
+			}
+		}
+		return storage.Reminder{At: *dto.At}, nil
+	}
+
+	r := storage.Reminder{Anchor: storage.ReminderAnchor(dto.RelativeTo)}
+	if r.Anchor == "" {
+		r.Anchor = storage.AnchorStart
+	}
+	if dto.Offset != "" {
+		d, err := ParseOffset(dto.Offset)
+		if err != nil {
+			return storage.Reminder{}, validator.ValidationErrors{{Field: "offset", Err: err}}
+		}
+		r.Offset = d
+	}
+	return r, nil
+}
+
+// ParseOffset разбирает смещение напоминания: time.ParseDuration
+// с дополнительной единицей "d" (сутки) в начале, например "-1d", "-1d12h".
+func ParseOffset(s string) (time.Duration, error) {
+	m := daysRe.FindStringSubmatch(s)
+	if m == nil {
+		return time.ParseDuration(s)
+	}
+
+	days, err := strconv.Atoi(m[2])
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	d := time.Duration(days) * 24 * time.Hour
+	if m[3] != "" {
+		rest, err := time.ParseDuration(m[3])
+		if err != nil || rest < 0 {
+			return 0, fmt.Errorf("invalid offset %q", s)
+		}
+		d += rest
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+var daysRe = regexp.MustCompile(`^([+-]?)(\d+)d(.*)$`)
+
+func fromDTO(dto Event) (storage.Event, error) {
+	e := storage.Event{
+		ID:          dto.ID,
+		Title:       dto.Title,
+		Start:       dto.Start,
+		End:         dto.End,
+		Description: dto.Description,
+		UserID:      dto.UserID,
+		Category:    dto.Category,
+		Labels:      dto.Labels,
+		CalendarID:  dto.CalendarID,
+	}
+	if dto.NotifyBefore != "" {
+		d, err := time.ParseDuration(dto.NotifyBefore)
+		if err != nil {
+			return storage.Event{}, validator.ValidationErrors{{Field: "notifyBefore", Err: err}}
+		}
+		e.NotifyBefore = d
+	}
+	for i, r := range dto.Reminders {
+		reminder, err := fromReminderDTO(r)
+		if err != nil {
+			return storage.Event{}, nested(fmt.Sprintf("reminders[%d]", i), err)
+		}
+		e.Reminders = append(e.Reminders, reminder)
+	}
+	return e, nil
+}
+
+// events обслуживает /events: POST - создать, GET - список за period (day|week|month) от date
+// или все события (period=all).
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		event, err := decodeEvent(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		created, err := s.app.CreateEvent(r.Context(), userID, event)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, toDTO(created, s.colors(r.Context(), userID)))
+	case http.MethodGet:
+		s.listEvents(w, r, userID)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request, userID string) {
+	q := r.URL.Query()
+
+	date := time.Now().UTC()
+	if v := q.Get("date"); v != "" {
+		d, err := time.Parse(DateLayout, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid date: %w", err))
+			return
+		}
+		date = d
+	}
+
+	var (
+		events []storage.Event
+		err    error
+		filter = app.Filter{Label: q.Get("label"), Calendar: q.Get("calendar")}
+	)
+	switch q.Get("period") {
+	case "", "day":
+		events, err = s.app.ListDay(r.Context(), userID, date, filter)
+	case "week":
+		events, err = s.app.ListWeek(r.Context(), userID, date, filter)
+	case "month":
+		events, err = s.app.ListMonth(r.Context(), userID, date, filter)
+	case "all":
+		events, err = s.app.ListAll(r.Context(), userID, filter)
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("period must be day, week, month or all"))
+		return
+	}
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+
+	colors := s.colors(r.Context(), userID)
+	res := make([]Event, 0, len(events))
+	for _, e := range events {
+		res = append(res, toDTO(e, colors))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// event обслуживает /events/{id}, восстановление из корзины /events/{id}/restore
+// и журнал изменений /events/{id}/history.
+func (s *Server) event(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/events/"), "/")
+	if id == "" {
+		writeError(w, http.StatusNotFound, storage.ErrEventNotFound)
+		return
+	}
+	if rest == "restore" {
+		s.restoreFromTrash(w, r, userID, id)
+		return
+	}
+	if rest != "" {
+		s.history(w, r, userID, id, rest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		event, err := s.app.GetEvent(r.Context(), userID, id)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toDTO(event, s.colors(r.Context(), userID)))
+	case http.MethodPut:
+		event, err := decodeEvent(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		updated, err := s.app.UpdateEvent(r.Context(), userID, id, event)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toDTO(updated, s.colors(r.Context(), userID)))
+	case http.MethodDelete:
+		if err := s.app.DeleteEvent(r.Context(), userID, id); err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func decodeEvent(r *http.Request) (storage.Event, error) {
+	var dto Event
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return storage.Event{}, fmt.Errorf("invalid json: %w", err)
+	}
+	if err := validator.Validate(dto); err != nil {
+		return storage.Event{}, err
+	}
+	return fromDTO(dto)
+}
+
+func (s *Server) writeAppError(w http.ResponseWriter, err error) {
+	code := appErrorStatus(err)
+	if code == http.StatusInternalServerError {
+		s.logger.Error("internal error: " + err.Error())
+		err = errors.New("internal error")
+	}
+	writeError(w, code, err)
+}
+
+// appErrorStatus - HTTP-статус для ошибки приложения или хранилища.
+func appErrorStatus(err error) int {
+	var verrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &verrs):
+		return http.StatusBadRequest
+	case errors.Is(err, app.ErrInvalidEvent), errors.Is(err, app.ErrInvalidLabel),
+		errors.Is(err, app.ErrInvalidSnooze), errors.Is(err, app.ErrInvalidBatch),
+		errors.Is(err, app.ErrInvalidCalendar), errors.Is(err, app.ErrInvalidWorkingHours),
+		errors.Is(err, app.ErrInvalidRange):
+		return http.StatusBadRequest
+	case errors.Is(err, app.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, storage.ErrEventNotFound), errors.Is(err, storage.ErrLabelNotFound),
+		errors.Is(err, storage.ErrNotificationNotFound), errors.Is(err, storage.ErrCalendarNotFound),
+		errors.Is(err, storage.ErrShareNotFound), errors.Is(err, storage.ErrWorkingHoursNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrDateBusy), errors.Is(err, storage.ErrEventExists),
+		errors.Is(err, storage.ErrLabelExists), errors.Is(err, storage.ErrInvalidTransition),
+		errors.Is(err, storage.ErrCalendarExists):
+		return http.StatusConflict
+	case errors.Is(err, app.ErrBatchAborted):
+		return http.StatusFailedDependency
+	default:
+		return http.StatusInternalServerError
+	}
+}
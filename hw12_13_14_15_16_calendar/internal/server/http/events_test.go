@@ -0,0 +1,227 @@
+package internalhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHandler() http.Handler {
+	calendar := app.New(logger.Discard(), memorystorage.New())
+	return NewServer(logger.Discard(), calendar, "").Handler()
+}
+
+func doJSON(t *testing.T, h http.Handler, method, path, userID string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	r := httptest.NewRequest(method, path, &buf)
+	r.Header.Set("X-User-ID", userID)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestEvents(t *testing.T) {
+	h := newTestHandler()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	w := doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title:        "standup",
+		Start:        start,
+		End:          start.Add(15 * time.Minute),
+		NotifyBefore: "5m",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.NotEmpty(t, created.ID)
+	require.Equal(t, "user-1", created.UserID)
+	require.Equal(t, "5m0s", created.NotifyBefore)
+
+	t.Run("date busy", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+			Title: "overlap", Start: start.Add(5 * time.Minute), End: start.Add(time.Hour),
+		})
+		require.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/events", "user-1", Event{Title: "no time"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+			Title: "bad notify", Start: start, End: start.Add(time.Hour), NotifyBefore: "soon",
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("no user", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodGet, "/events", "", nil)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		var events []Event
+		w := doJSON(t, h, http.MethodGet, "/events?period=week&date=2024-01-08", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		require.Len(t, events, 1)
+
+		w = doJSON(t, h, http.MethodGet, "/events?period=day&date=2024-01-11", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, "[]", w.Body.String())
+
+		w = doJSON(t, h, http.MethodGet, "/events?period=all&date=2030-01-01", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		require.Len(t, events, 1)
+
+		w = doJSON(t, h, http.MethodGet, "/events?period=year", "user-1", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodGet, "/events/"+created.ID, "user-2", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodDelete, "/events/"+created.ID, "user-2", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		created.Title = "daily standup"
+		w := doJSON(t, h, http.MethodPut, "/events/"+created.ID, "user-1", created)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(t, h, http.MethodGet, "/events/"+created.ID, "user-1", nil)
+		var got Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Equal(t, "daily standup", got.Title)
+
+		w = doJSON(t, h, http.MethodDelete, "/events/"+created.ID, "user-1", nil)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		w = doJSON(t, h, http.MethodGet, "/events/"+created.ID, "user-1", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestEventReminders(t *testing.T) {
+	h := newTestHandler()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	at := start.Add(-48 * time.Hour)
+
+	w := doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title: "release", Start: start, End: start.Add(time.Hour),
+		Reminders: []Reminder{
+			{Offset: "-1d"},
+			{RelativeTo: "end", Offset: "-15m"},
+			{At: &at},
+		},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Len(t, created.Reminders, 3)
+	require.Equal(t, Reminder{ID: "start-86400s", RelativeTo: "start", Offset: "-24h0m0s"}, created.Reminders[0])
+	require.Equal(t, "end-900s", created.Reminders[1].ID)
+	require.True(t, created.Reminders[2].At.Equal(at))
+
+	for name, r := range map[string]Reminder{
+		"bad offset": {Offset: "soon"},
+		"bad anchor": {RelativeTo: "middle"},
+		"both kinds": {Offset: "-1h", At: &at},
+	} {
+		w := doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+			Title: name, Start: start.Add(24 * time.Hour), End: start.Add(25 * time.Hour), Reminders: []Reminder{r},
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+
+	w = doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title: "duplicate", Start: start.Add(24 * time.Hour), End: start.Add(25 * time.Hour),
+		Reminders: []Reminder{{Offset: "-1h"}, {RelativeTo: "start", Offset: "-60m"}},
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestEventValidation(t *testing.T) {
+	h := newTestHandler()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	w := doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Start: start, End: start.Add(-time.Hour), UserID: "user 1", NotifyBefore: "720h",
+		Reminders: []Reminder{{Offset: "-15m"}, {RelativeTo: "middle"}, {Offset: "soon"}},
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	require.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+	var res Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, "about:blank", res.Type)
+	require.Equal(t, http.StatusBadRequest, res.Status)
+	require.Equal(t, []FieldError{
+		{Field: "title", Message: "is required"},
+		{Field: "end", Message: "must be after start"},
+		{Field: "notifyBefore", Message: "must be between 0s and 672h0m0s"},
+		{Field: "reminders[1].relativeTo", Message: "must be one of: start, end"},
+	}, res.Errors)
+
+	// разбор смещения идёт после проверки тегов и тоже отвечает ошибкой поля
+	w = doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title: "sync", Start: start, End: start.Add(time.Hour), Reminders: []Reminder{{Offset: "soon"}},
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Equal(t, "reminders[0].offset", res.Errors[0].Field)
+
+	for name, e := range map[string]Event{
+		"long title": {Title: strings.Repeat("я", 201), Start: start, End: start.Add(time.Hour)},
+		"long event": {Title: "trip", Start: start, End: start.AddDate(1, 1, 0)},
+	} {
+		w := doJSON(t, h, http.MethodPost, "/events", "user-1", e)
+		require.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+
+	w = doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title: strings.Repeat("я", 200), Start: start, End: start.AddDate(0, 0, 7), UserID: "user-1", NotifyBefore: "24h",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = doJSON(t, h, http.MethodGet, "/events/missing", "user-1", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	var notFound Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &notFound))
+	require.Equal(t, Problem{
+		Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "event not found",
+	}, notFound)
+}
+
+func TestParseOffset(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"-15m":   -15 * time.Minute,
+		"-1d":    -24 * time.Hour,
+		"+2d":    48 * time.Hour,
+		"-1d12h": -36 * time.Hour,
+		"0":      0,
+	} {
+		got, err := ParseOffset(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "1w", "-1d-2h", "d"} {
+		_, err := ParseOffset(in)
+		require.Error(t, err, in)
+	}
+}
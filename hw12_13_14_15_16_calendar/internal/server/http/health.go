@@ -0,0 +1,90 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const checkTimeout = 2 * time.Second
+
+// HealthCheck проверяет доступность зависимости (хранилище, брокер).
+type HealthCheck func(ctx context.Context) error
+
+// BuildInfo - то же, что печатает `calendar version`.
+type BuildInfo struct {
+	Release   string
+	BuildDate string
+	GitHash   string
+}
+
+type checkStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type readiness struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkStatus `json:"checks"`
+}
+
+// WithReadinessChecks добавляет зависимости, которые проверяет /readyz.
+func WithReadinessChecks(checks map[string]HealthCheck) Option {
+	return func(o *options) {
+		o.checks = checks
+	}
+}
+
+func WithBuildInfo(info BuildInfo) Option {
+	return func(o *options) {
+		o.buildInfo = info
+	}
+}
+
+// healthz - процесс жив и отвечает.
+func healthz(w http.ResponseWriter, _ *http.Request) {
+	w.Write([]byte("ok\n"))
+}
+
+func readyz(checks map[string]HealthCheck) http.HandlerFunc {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := readiness{Status: "ok", Checks: make(map[string]checkStatus, len(checks))}
+		code := http.StatusOK
+
+		for _, name := range names {
+			ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+			err := checks[name](ctx)
+			cancel()
+
+			if err != nil {
+				res.Status = "unavailable"
+				res.Checks[name] = checkStatus{Status: "unavailable", Error: err.Error()}
+				code = http.StatusServiceUnavailable
+				continue
+			}
+			res.Checks[name] = checkStatus{Status: "ok"}
+		}
+
+		writeJSON(w, code, res)
+	}
+}
+
+func version(info BuildInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, info)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,76 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/auth"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	info := BuildInfo{Release: "v1", BuildDate: "2024-01-01", GitHash: "abc"}
+	storageErr := errors.New("connection refused")
+	down := false
+
+	handler := NewServer(logger.Discard(), nil, "",
+		WithAuth(auth.New(nil, "secret")),
+		WithBuildInfo(info),
+		WithReadinessChecks(map[string]HealthCheck{
+			"storage": func(context.Context) error {
+				if down {
+					return storageErr
+				}
+				return nil
+			},
+			"broker": func(context.Context) error { return nil },
+		}),
+	).Handler()
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("healthz without credentials", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do("/healthz").Code)
+		require.Equal(t, http.StatusUnauthorized, do("/hello").Code)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		var got BuildInfo
+		w := do("/version")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Equal(t, info, got)
+	})
+
+	t.Run("ready", func(t *testing.T) {
+		var got readiness
+		w := do("/readyz")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Equal(t, "ok", got.Status)
+		require.Equal(t, "ok", got.Checks["storage"].Status)
+		require.Equal(t, "ok", got.Checks["broker"].Status)
+	})
+
+	t.Run("not ready", func(t *testing.T) {
+		down = true
+		defer func() { down = false }()
+
+		var got readiness
+		w := do("/readyz")
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Equal(t, "unavailable", got.Status)
+		require.Equal(t, checkStatus{Status: "unavailable", Error: storageErr.Error()}, got.Checks["storage"])
+		require.Equal(t, "ok", got.Checks["broker"].Status)
+	})
+}
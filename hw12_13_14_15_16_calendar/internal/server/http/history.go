@@ -0,0 +1,95 @@
+package internalhttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// HistoryEntry - запись журнала изменений события. Event - состояние
+// после изменения, для удаления - перед ним; его и восстанавливает restore.
+type HistoryEntry struct {
+	Version int       `json:"version"`
+	Action  string    `json:"action"`
+	Actor   string    `json:"actor"`
+	At      time.Time `json:"at"`
+	Changes []Change  `json:"changes"`
+	Event   Event     `json:"event"`
+}
+
+// Change - изменение поля события, значения в текстовом виде.
+type Change struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// history обслуживает GET /events/{id}/history и POST /events/{id}/history/{version}/restore.
+func (s *Server) history(w http.ResponseWriter, r *http.Request, userID, id, path string) {
+	if path == "history" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		s.listHistory(w, r, userID, id)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 || parts[0] != "history" || parts[2] != "restore" {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	event, err := s.app.RestoreEvent(r.Context(), userID, id, version)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toDTO(event, s.colors(r.Context(), userID)))
+}
+
+func (s *Server) listHistory(w http.ResponseWriter, r *http.Request, userID, id string) {
+	history, err := s.app.History(r.Context(), userID, id)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+
+	colors := s.colors(r.Context(), userID)
+	res := make([]HistoryEntry, 0, len(history))
+	for _, entry := range history {
+		res = append(res, toHistoryDTO(entry, colors))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func toHistoryDTO(entry storage.AuditEntry, colors map[string]string) HistoryEntry {
+	dto := HistoryEntry{
+		Version: entry.Version,
+		Action:  string(entry.Action),
+		Actor:   entry.Actor,
+		At:      entry.At,
+		Changes: make([]Change, 0),
+		Event:   toDTO(entry.Snapshot(), colors),
+	}
+	for _, c := range entry.Changes() {
+		dto.Changes = append(dto.Changes, Change{Field: c.Field, Before: c.Before, After: c.After})
+	}
+	return dto
+}
@@ -0,0 +1,89 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	h := newTestHandler()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	w := doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title: "planning", Start: start, End: start.Add(time.Hour),
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var event Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+	path := "/events/" + event.ID
+
+	event.Title = "planning (moved)"
+	event.Start = start.Add(2 * time.Hour)
+	event.End = start.Add(3 * time.Hour)
+	w = doJSON(t, h, http.MethodPut, path, "user-1", event)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doJSON(t, h, http.MethodDelete, path, "user-1", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	var history []HistoryEntry
+	w = doJSON(t, h, http.MethodGet, path+"/history", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Len(t, history, 3)
+
+	require.Equal(t, "create", history[0].Action)
+	require.Equal(t, "user-1", history[0].Actor)
+	require.Equal(t, "update", history[1].Action)
+	require.Equal(t, []Change{
+		{Field: "title", Before: "planning", After: "planning (moved)"},
+		{Field: "start", Before: "2024-01-10T10:00:00Z", After: "2024-01-10T12:00:00Z"},
+		{Field: "end", Before: "2024-01-10T11:00:00Z", After: "2024-01-10T13:00:00Z"},
+	}, history[1].Changes)
+	require.Equal(t, "delete", history[2].Action)
+	require.Equal(t, "planning (moved)", history[2].Event.Title)
+
+	t.Run("other user", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodGet, path+"/history", "user-2", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodPost, path+"/history/1/restore", "user-2", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("restore deleted", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, path+"/history/1/restore", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var got Event
+		w = doJSON(t, h, http.MethodGet, path, "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Equal(t, "planning", got.Title)
+		require.True(t, got.Start.Equal(start))
+	})
+
+	t.Run("restore existing", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, path+"/history/2/restore", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var history []HistoryEntry
+		w = doJSON(t, h, http.MethodGet, path+"/history", "user-1", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		require.Len(t, history, 5)
+		require.Equal(t, "restore", history[4].Action)
+		require.Equal(t, "planning (moved)", history[4].Event.Title)
+		require.Equal(t, "planning", history[4].Changes[0].Before)
+	})
+
+	t.Run("bad version", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, path+"/history/9/restore", "user-1", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodPost, path+"/history/x/restore", "user-1", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodGet, path+"/history/1/restore", "user-1", nil)
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
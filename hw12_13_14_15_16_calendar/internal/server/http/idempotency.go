@@ -0,0 +1,153 @@
+package internalhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+const (
+	// IdempotencyHeader - ключ запроса: повтор с тем же ключом получает первый ответ.
+	IdempotencyHeader = "Idempotency-Key"
+	// ReplayedHeader отмечает ответ, отданный из сохранённого.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKey  = 255
+	maxIdempotentBody  = 1 << 20
+	idempotencyTimeout = 5 * time.Second
+)
+
+// IdempotencyStore хранит ответы на запросы с Idempotency-Key.
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(
+		ctx context.Context, k storage.IdempotencyKey, ttl time.Duration,
+	) (storage.IdempotencyKey, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, k storage.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, userID, key string) error
+}
+
+type idempotency struct {
+	store IdempotencyStore
+	ttl   time.Duration
+}
+
+// WithIdempotency включает Idempotency-Key у создания событий и пакетов: ответ хранится ttl,
+// и повтор запроса с тем же ключом получает его, а не создаёт событие ещё раз.
+func WithIdempotency(store IdempotencyStore, ttl time.Duration) Option {
+	return func(o *options) {
+		o.idempotency = idempotency{store: store, ttl: ttl}
+	}
+}
+
+// idempotencyMiddleware запоминает ответы на POST с Idempotency-Key. Ответы 5xx не
+// сохраняются: ключ освобождается, и повтор выполнит запрос заново.
+func idempotencyMiddleware(logger Logger, c idempotency, next http.Handler) http.Handler {
+	if c.store == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyHeader)
+		userID := UserID(r.Context())
+		if key == "" || userID == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%s is longer than %d bytes", IdempotencyHeader, maxIdempotencyKey))
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, errors.New("request body is too large"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		k := storage.IdempotencyKey{UserID: userID, Key: key, Hash: requestHash(r, body)}
+		saved, reserved, err := c.store.ReserveIdempotencyKey(r.Context(), k, c.ttl)
+		switch {
+		case err != nil:
+			logger.Error("idempotency key: " + err.Error())
+			writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+		case saved.Hash != k.Hash:
+			writeError(w, http.StatusUnprocessableEntity,
+				fmt.Errorf("%s was already used for a different request", IdempotencyHeader))
+		case !reserved && saved.Pending():
+			writeError(w, http.StatusConflict,
+				fmt.Errorf("request with this %s is still in progress", IdempotencyHeader))
+		case !reserved:
+			w.Header().Set("Content-Type", saved.ContentType)
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(saved.Status)
+			w.Write(saved.Body)
+		default:
+			record(logger, c.store, k, w, r, next)
+		}
+	})
+}
+
+// record выполняет запрос и сохраняет ответ в занятый ключ. Ключ сохраняется
+// и освобождается без контекста запроса: клиент мог уже отключиться, а ключ
+// не должен остаться занятым до конца ttl.
+func record(logger Logger, store IdempotencyStore, k storage.IdempotencyKey,
+	w http.ResponseWriter, r *http.Request, next http.Handler,
+) {
+	rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	completed := false
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), idempotencyTimeout)
+		defer cancel()
+
+		var err error
+		if completed && rw.status < http.StatusInternalServerError {
+			k.Status, k.ContentType, k.Body = rw.status, rw.Header().Get("Content-Type"), rw.body.Bytes()
+			err = store.CompleteIdempotencyKey(ctx, k)
+		} else {
+			err = store.DeleteIdempotencyKey(ctx, k.UserID, k.Key)
+		}
+		if err != nil {
+			logger.Error("idempotency key: " + err.Error())
+		}
+	}()
+
+	next.ServeHTTP(rw, r)
+	completed = true
+}
+
+// requestHash - отпечаток запроса: метод, путь с параметрами и тело.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder пишет ответ клиенту и запоминает его копию.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
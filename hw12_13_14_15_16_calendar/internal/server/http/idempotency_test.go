@@ -0,0 +1,142 @@
+package internalhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func doIdempotent(t *testing.T, h http.Handler, path, userID, key string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	b, err := json.Marshal(body)
+	require.NoError(t, err)
+	r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+	r.Header.Set("X-User-ID", userID)
+	r.Header.Set(IdempotencyHeader, key)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	st := memorystorage.New()
+	h := NewServer(logger.Discard(), app.New(logger.Discard(), st), "", WithIdempotency(st, time.Hour)).Handler()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	event := func(title string, hour int) Event {
+		from := start.Add(time.Duration(hour) * time.Hour)
+		return Event{Title: title, Start: from, End: from.Add(time.Hour)}
+	}
+	count := func(userID string) int {
+		t.Helper()
+		events, err := st.ListEvents(context.Background(), userID, start, start.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		return len(events)
+	}
+
+	t.Run("retry returns the first response", func(t *testing.T) {
+		first := doIdempotent(t, h, "/events", "user-1", "create-1", event("standup", 0))
+		require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+		require.Empty(t, first.Header().Get(ReplayedHeader))
+
+		retry := doIdempotent(t, h, "/events", "user-1", "create-1", event("standup", 0))
+		require.Equal(t, http.StatusCreated, retry.Code)
+		require.Equal(t, "true", retry.Header().Get(ReplayedHeader))
+		require.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+		require.Equal(t, first.Body.String(), retry.Body.String())
+		require.Equal(t, 1, count("user-1"))
+	})
+
+	t.Run("key reused for another request", func(t *testing.T) {
+		w := doIdempotent(t, h, "/events", "user-1", "create-1", event("planning", 2))
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		w = doIdempotent(t, h, "/events:batch", "user-1", "create-1", event("standup", 0))
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		require.Equal(t, 1, count("user-1"))
+	})
+
+	t.Run("keys are per user", func(t *testing.T) {
+		w := doIdempotent(t, h, "/events", "user-2", "create-1", event("standup", 0))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.Empty(t, w.Header().Get(ReplayedHeader))
+		require.Equal(t, 1, count("user-2"))
+	})
+
+	t.Run("client errors are replayed", func(t *testing.T) {
+		w := doIdempotent(t, h, "/events", "user-1", "busy", event("overlaps standup", 0))
+		require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+		w = doIdempotent(t, h, "/events", "user-1", "busy", event("overlaps standup", 0))
+		require.Equal(t, http.StatusConflict, w.Code)
+		require.Equal(t, "true", w.Header().Get(ReplayedHeader))
+		require.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		a, b := event("a", 4), event("b", 5)
+		req := BatchRequest{Operations: []BatchOperation{{Op: "create", Event: &a}, {Op: "create", Event: &b}}}
+		for i := 0; i < 2; i++ {
+			w := doIdempotent(t, h, "/events:batch", "user-1", "batch-1", req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		}
+		require.Equal(t, 3, count("user-1"))
+	})
+
+	t.Run("request in progress", func(t *testing.T) {
+		body, err := json.Marshal(event("slow", 8))
+		require.NoError(t, err)
+		_, _, err = st.ReserveIdempotencyKey(context.Background(), storage.IdempotencyKey{
+			UserID: "user-1", Key: "pending", Hash: requestHash(httptest.NewRequest(http.MethodPost, "/events", nil), body),
+		}, time.Hour)
+		require.NoError(t, err)
+
+		w := doIdempotent(t, h, "/events", "user-1", "pending", event("slow", 8))
+		require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+		require.Equal(t, 3, count("user-1"))
+	})
+
+	t.Run("without key", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/events", "user-1", event("no key", 10))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		w = doIdempotent(t, h, "/events", "user-1", strings.Repeat("k", 256), event("long key", 11))
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
+
+func TestIdempotencyServerError(t *testing.T) {
+	st := memorystorage.New()
+	calls := 0
+	h := idempotencyMiddleware(logger.Discard(), idempotency{store: st, ttl: time.Hour},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				writeError(w, http.StatusServiceUnavailable, context.DeadlineExceeded)
+				return
+			}
+			writeJSON(w, http.StatusCreated, Event{ID: "1"})
+		}))
+	do := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(`{}`)))
+		r = r.WithContext(withUserID(r.Context(), "user-1"))
+		r.Header.Set(IdempotencyHeader, "retry")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	require.Equal(t, http.StatusServiceUnavailable, do().Code)
+	require.Equal(t, http.StatusCreated, do().Code, "the key is released after a server error")
+	w := do()
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, "true", w.Header().Get(ReplayedHeader))
+	require.Equal(t, 2, calls)
+}
@@ -0,0 +1,113 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// Label - метка пользователя, Color в формате "#rrggbb".
+type Label struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+func toLabelDTO(l storage.Label) Label {
+	return Label{Name: l.Name, Color: l.Color}
+}
+
+// labels обслуживает /labels: GET - набор меток пользователя, POST - создать метку.
+func (s *Server) labels(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		labels, err := s.app.ListLabels(r.Context(), userID)
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		res := make([]Label, 0, len(labels))
+		for _, l := range labels {
+			res = append(res, toLabelDTO(l))
+		}
+		writeJSON(w, http.StatusOK, res)
+	case http.MethodPost:
+		var dto Label
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid json: %w", err))
+			return
+		}
+		created, err := s.app.CreateLabel(r.Context(), userID, storage.Label{Name: dto.Name, Color: dto.Color})
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, toLabelDTO(created))
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// label обслуживает /labels/{name}: PUT меняет цвет, DELETE удаляет метку.
+func (s *Server) label(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/labels/")
+	if name == "" || strings.Contains(name, "/") {
+		writeError(w, http.StatusNotFound, storage.ErrLabelNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var dto Label
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid json: %w", err))
+			return
+		}
+		updated, err := s.app.UpdateLabel(r.Context(), userID, name, storage.Label{Color: dto.Color})
+		if err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toLabelDTO(updated))
+	case http.MethodDelete:
+		if err := s.app.DeleteLabel(r.Context(), userID, name); err != nil {
+			s.writeAppError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// colors - цвета меток пользователя по имени. Цвет в ответе вторичен,
+// поэтому ошибка только логируется, а событие отдаётся без него.
+func (s *Server) colors(ctx context.Context, userID string) map[string]string {
+	labels, err := s.app.ListLabels(ctx, userID)
+	if err != nil {
+		s.logger.Error("list labels: " + err.Error())
+		return nil
+	}
+	colors := make(map[string]string, len(labels))
+	for _, l := range labels {
+		colors[l.Name] = l.Color
+	}
+	return colors
+}
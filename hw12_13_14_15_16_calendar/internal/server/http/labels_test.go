@@ -0,0 +1,86 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabels(t *testing.T) {
+	h := newTestHandler()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	w := doJSON(t, h, http.MethodPost, "/labels", "user-1", Label{Name: "work", Color: "#0000ff"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doJSON(t, h, http.MethodPost, "/labels", "user-1", Label{Name: "urgent", Color: "#ff0000"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	t.Run("invalid", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/labels", "user-1", Label{Name: "work", Color: "#0000ff"})
+		require.Equal(t, http.StatusConflict, w.Code)
+		w = doJSON(t, h, http.MethodPost, "/labels", "user-1", Label{Name: "home", Color: "blue"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(t, h, http.MethodPut, "/labels/home", "user-1", Label{Color: "#00ff00"})
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		// Метки других пользователей недоступны.
+		w = doJSON(t, h, http.MethodPost, "/events", "user-2", Event{
+			Title: "foreign", Start: start, End: start.Add(time.Hour), Category: "work",
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	w = doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title: "review", Start: start, End: start.Add(time.Hour), Category: "work", Labels: []string{"urgent"},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Equal(t, "#0000ff", created.Color)
+	w = doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title: "lunch", Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour),
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	t.Run("filter", func(t *testing.T) {
+		var events []Event
+		w := doJSON(t, h, http.MethodGet, "/events?date=2024-01-10&label=urgent", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		require.Len(t, events, 1)
+		require.Equal(t, created.ID, events[0].ID)
+
+		w = doJSON(t, h, http.MethodGet, "/events?date=2024-01-10&label=home", "user-1", nil)
+		require.JSONEq(t, "[]", w.Body.String())
+	})
+
+	t.Run("color follows label", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPut, "/labels/work", "user-1", Label{Color: "#000080"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var got Event
+		w = doJSON(t, h, http.MethodGet, "/events/"+created.ID, "user-1", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Equal(t, "#000080", got.Color)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodDelete, "/labels/work", "user-1", nil)
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		var got Event
+		w = doJSON(t, h, http.MethodGet, "/events/"+created.ID, "user-1", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		require.Empty(t, got.Category)
+		require.Empty(t, got.Color)
+		require.Equal(t, []string{"urgent"}, got.Labels)
+
+		var labels []Label
+		w = doJSON(t, h, http.MethodGet, "/labels", "user-1", nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &labels))
+		require.Equal(t, []Label{{Name: "urgent", Color: "#ff0000"}}, labels)
+	})
+}
@@ -1,11 +1,152 @@
 package internalhttp
 
 import (
+	"context"
+	"errors"
+	"math"
+	"net"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/auth"
+)
+
+const userIDHeader = "X-User-ID"
+
+// userIDRe - допустимый ID пользователя: он попадает в ключи кэша и лимитов, в пути и журнал.
+var userIDRe = regexp.MustCompile(`^[A-Za-z0-9._@+-]{1,64}$`)
+
+var (
+	errInvalidUserID   = errors.New("invalid user id: want up to 64 letters, digits or ._@+-")
+	errTooManyRequests = errors.New("too many requests")
 )
 
+type ctxKey int
+
+const userIDKey ctxKey = iota
+
+// UserID возвращает ID пользователя, от имени которого выполняется запрос.
+func UserID(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey).(string)
+	return userID
+}
+
+func withUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
 func loggingMiddleware(next http.Handler) http.Handler { //nolint:unused
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// TODO
 	})
 }
+
+// userHeaderMiddleware берёт ID пользователя из X-User-ID, а если его нет - из имени
+// пользователя Basic-аутентификации: CalDAV-клиенты другие заголовки не шлют.
+// ID неверного формата - 400, пустой пропускается: его отвергнут обработчики.
+func userHeaderMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Header.Get(userIDHeader)
+		if userID == "" {
+			userID, _, _ = r.BasicAuth()
+		}
+		if userID != "" && !userIDRe.MatchString(userID) {
+			writeError(w, http.StatusBadRequest, errInvalidUserID)
+			return
+		}
+		ctx := withUserID(r.Context(), userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authMiddleware берёт ID пользователя из учётных данных, заголовок X-User-ID игнорируется.
+// Ключ передаётся как "Authorization: Bearer <key|jwt>", в заголовке X-API-Key
+// или паролем Basic-аутентификации, имя пользователя при этом не проверяется.
+func authMiddleware(a Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := a.Authenticate(credential(r))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="calendar", error="`+authError(err)+`"`)
+			// CalDAV-клиенты умеют только Basic и без такого вызова не спросят пароль.
+			if strings.HasPrefix(r.URL.Path, davPrefix) {
+				w.Header().Add("WWW-Authenticate", `Basic realm="calendar", charset="UTF-8"`)
+			}
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withUserID(r.Context(), userID)))
+	})
+}
+
+func credential(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	const prefix = "bearer "
+	h := r.Header.Get("Authorization")
+	if len(h) > len(prefix) && strings.EqualFold(h[:len(prefix)], prefix) {
+		return strings.TrimSpace(h[len(prefix):])
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	return ""
+}
+
+func authError(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrNoCredentials):
+		return "invalid_request"
+	default:
+		return "invalid_token"
+	}
+}
+
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func userKey(r *http.Request) string {
+	userID := UserID(r.Context())
+	if userID == "" {
+		return ""
+	}
+	return "user:" + userID
+}
+
+// rateLimitMiddleware выбирает лимит по классу маршрута: чтение или изменение.
+func rateLimitMiddleware(limits RateLimits, key func(*http.Request) string, next http.Handler) http.Handler {
+	if limits.Read == nil && limits.Write == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := limits.Write
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, methodPropfind, methodReport:
+			limiter = limits.Read
+		}
+
+		k := key(r)
+		if limiter == nil || k == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if ok, retryAfter := limiter.Allow(k); !ok {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			writeError(w, http.StatusTooManyRequests, errTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,171 @@
+package internalhttp
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/auth"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	key, hash, err := auth.NewKey()
+	require.NoError(t, err)
+	a := auth.New(map[string]string{hash: "user-1"}, "secret")
+	token, err := a.NewToken("user-2", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	expired, err := a.NewToken("user-2", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+
+	handler := NewServer(logger.Discard(), nil, "", WithAuth(a)).Handler()
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		code   int
+		body   string
+	}{
+		{name: "no credentials", code: http.StatusUnauthorized},
+		{
+			name:   "user header is not trusted",
+			header: map[string]string{"X-User-ID": "user-1"},
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "unknown key",
+			header: map[string]string{"X-API-Key": "cal_unknown"},
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "expired token",
+			header: map[string]string{"Authorization": "Bearer " + expired},
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "api key",
+			header: map[string]string{"X-API-Key": key, "X-User-ID": "user-2"},
+			code:   http.StatusOK,
+			body:   "hello, user-1\n",
+		},
+		{
+			name:   "bearer api key",
+			header: map[string]string{"Authorization": "Bearer " + key},
+			code:   http.StatusOK,
+			body:   "hello, user-1\n",
+		},
+		{
+			name:   "jwt",
+			header: map[string]string{"Authorization": "bearer " + token},
+			code:   http.StatusOK,
+			body:   "hello, user-2\n",
+		},
+		{
+			name:   "basic password",
+			header: map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("any:"+key))},
+			code:   http.StatusOK,
+			body:   "hello, user-1\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/hello", nil)
+			for k, v := range tc.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			require.Equal(t, tc.code, w.Code)
+			if tc.code == http.StatusUnauthorized {
+				require.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+				require.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+				return
+			}
+			require.Equal(t, tc.body, w.Body.String())
+		})
+	}
+}
+
+func TestAuthMiddlewareCalDAV(t *testing.T) {
+	handler := NewServer(logger.Discard(), nil, "", WithAuth(auth.New(nil, "secret"))).Handler()
+
+	r := httptest.NewRequest(methodPropfind, "/dav/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Len(t, w.Header().Values("WWW-Authenticate"), 2)
+	require.Contains(t, w.Header().Values("WWW-Authenticate")[1], "Basic")
+}
+
+func TestUserHeaderWithoutAuth(t *testing.T) {
+	handler := NewServer(logger.Discard(), nil, "").Handler()
+
+	r := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	r.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "hello, user-1\n", w.Body.String())
+
+	for name, userID := range map[string]string{
+		"spaces":   "user 1",
+		"slash":    "../user-1",
+		"too long": strings.Repeat("u", 65),
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/hello", nil)
+		r.Header.Set("X-User-ID", userID)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, name)
+		require.Equal(t, ProblemContentType, w.Header().Get("Content-Type"), name)
+	}
+}
+
+type stubLimiter map[string]int
+
+func (l stubLimiter) Allow(key string) (bool, time.Duration) {
+	l[key]++
+	if l[key] > 1 {
+		return false, 1500 * time.Millisecond
+	}
+	return true, 0
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	read, write := stubLimiter{}, stubLimiter{}
+	handler := NewServer(logger.Discard(), nil, "", WithRateLimits(RateLimits{Read: read, Write: write})).Handler()
+
+	do := func(method, ip, userID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/hello", nil)
+		r.RemoteAddr = ip + ":12345"
+		r.Header.Set("X-User-ID", userID)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "10.0.0.1", "user-1").Code)
+
+	w := do(http.MethodGet, "10.0.0.2", "user-1")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+	require.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+	w = do(http.MethodGet, "10.0.0.1", "user-2")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "10.0.0.1", "user-1").Code)
+	require.Equal(t, 1, write["ip:10.0.0.1"])
+	require.Equal(t, 1, write["user:user-1"])
+}
@@ -0,0 +1,117 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// Notification - сохранённое уведомление, Status - pending, sent, acked или snoozed.
+type Notification struct {
+	ID           string     `json:"id"`
+	EventID      string     `json:"eventId"`
+	ReminderID   string     `json:"reminderId"`
+	Title        string     `json:"title"`
+	Start        time.Time  `json:"start"`
+	UserID       string     `json:"userId"`
+	Status       string     `json:"status"`
+	SnoozedUntil *time.Time `json:"snoozedUntil,omitempty"`
+}
+
+// Snooze - тело запроса snooze, Duration вида "10m".
+type Snooze struct {
+	Duration string `json:"duration"`
+}
+
+func toNotificationDTO(n storage.Notification) Notification {
+	dto := Notification{
+		ID:         n.ID,
+		EventID:    n.EventID,
+		ReminderID: n.ReminderID,
+		Title:      n.Title,
+		Start:      n.Start,
+		UserID:     n.UserID,
+		Status:     string(n.Status),
+	}
+	if !n.SnoozedUntil.IsZero() {
+		until := n.SnoozedUntil
+		dto.SnoozedUntil = &until
+	}
+	return dto
+}
+
+// notifications обслуживает GET /notifications - уведомления пользователя.
+func (s *Server) notifications(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	list, err := s.app.ListNotifications(r.Context(), userID)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	res := make([]Notification, 0, len(list))
+	for _, n := range list {
+		res = append(res, toNotificationDTO(n))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// notification обслуживает POST /notifications/{id}/ack и /notifications/{id}/snooze.
+func (s *Server) notification(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/notifications/"), "/")
+	if len(parts) != 2 || parts[0] == "" || (parts[1] != "ack" && parts[1] != "snooze") {
+		writeError(w, http.StatusNotFound, storage.ErrNotificationNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	id := parts[0]
+
+	var (
+		n   storage.Notification
+		err error
+	)
+	if parts[1] == "ack" {
+		n, err = s.app.AckNotification(r.Context(), userID, id)
+	} else {
+		var body Snooze
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid json: %w", err))
+			return
+		}
+		d, perr := time.ParseDuration(body.Duration)
+		if perr != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %s", app.ErrInvalidSnooze, perr.Error()))
+			return
+		}
+		n, err = s.app.SnoozeNotification(r.Context(), userID, id, d)
+	}
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toNotificationDTO(n))
+}
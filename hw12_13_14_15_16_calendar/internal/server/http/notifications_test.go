@@ -0,0 +1,78 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	st := memorystorage.New()
+	h := NewServer(logger.Discard(), app.New(logger.Discard(), st), "").Handler()
+
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	for _, id := range []string{"1", "2"} {
+		require.NoError(t, st.SaveNotification(context.Background(), storage.Notification{
+			ID: id + ":r", EventID: id, ReminderID: "r", Title: "standup", Start: start, UserID: "user-1",
+			Status: storage.NotificationSent,
+		}))
+	}
+
+	t.Run("snooze", func(t *testing.T) {
+		before := time.Now()
+		w := doJSON(t, h, http.MethodPost, "/notifications/1:r/snooze", "user-1", Snooze{Duration: "10m"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var n Notification
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &n))
+		require.Equal(t, "snoozed", n.Status)
+		require.NotNil(t, n.SnoozedUntil)
+		require.WithinDuration(t, before.Add(10*time.Minute), *n.SnoozedUntil, time.Minute)
+
+		w = doJSON(t, h, http.MethodPost, "/notifications/1:r/snooze", "user-1", Snooze{Duration: "-1m"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(t, h, http.MethodPost, "/notifications/1:r/snooze", "user-1", Snooze{Duration: "later"})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("ack", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/notifications/2:r/ack", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// Из acked переходов нет.
+		w = doJSON(t, h, http.MethodPost, "/notifications/2:r/snooze", "user-1", Snooze{Duration: "10m"})
+		require.Equal(t, http.StatusConflict, w.Code)
+		w = doJSON(t, h, http.MethodPost, "/notifications/2:r/ack", "user-1", nil)
+		require.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/notifications/1:r/ack", "user-2", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodPost, "/notifications/3:r/ack", "user-1", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(t, h, http.MethodPost, "/notifications/1:r/dismiss", "user-1", nil)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		var list []Notification
+		w := doJSON(t, h, http.MethodGet, "/notifications", "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list, 2)
+
+		statuses := map[string]string{}
+		for _, n := range list {
+			statuses[n.ID] = n.Status
+		}
+		require.Equal(t, map[string]string{"1:r": "snoozed", "2:r": "acked"}, statuses)
+	})
+}
@@ -0,0 +1,65 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/validator"
+)
+
+// ProblemContentType - тип ответа с ошибкой по RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// Problem - ответ с ошибкой по RFC 7807. Type всегда about:blank, Title - текст
+// статуса, подробности в Detail, а ошибки отдельных полей - в расширении Errors.
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError - нарушение в поле запроса, Field - путь вида "reminders[0].offset".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func newProblem(code int, err error) Problem {
+	p := Problem{Type: "about:blank", Title: http.StatusText(code), Status: code, Detail: err.Error()}
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		p.Errors = make([]FieldError, 0, len(verrs))
+		for _, v := range verrs {
+			p.Errors = append(p.Errors, FieldError{Field: v.Field, Message: v.Err.Error()})
+		}
+	}
+	return p
+}
+
+// nested переносит ошибки полей вложенного объекта под prefix, остальные ошибки просто уточняет.
+func nested(prefix string, err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return fmt.Errorf("%s: %w", prefix, err)
+	}
+	res := make(validator.ValidationErrors, 0, len(verrs))
+	for _, v := range verrs {
+		v.Field = prefix + "." + v.Field
+		res = append(res, v)
+	}
+	return res
+}
+
+func writeProblem(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeProblem(w, code, newProblem(code, err))
+}
@@ -0,0 +1,70 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/quickadd"
+)
+
+// QuickAddRequest - тело POST /events:quickAdd. TimeZone - имя из базы IANA,
+// например "Europe/Moscow", в нём понимаются "tomorrow 10:00"; по умолчанию UTC.
+type QuickAddRequest struct {
+	Text       string `json:"text"`
+	TimeZone   string `json:"timeZone,omitempty"`
+	CalendarID string `json:"calendarId,omitempty"`
+}
+
+// QuickAddError - ответ на нераспознанный текст: Problem с расширением,
+// Position и Length - фрагмент текста в символах, к которому относится ошибка.
+type QuickAddError struct {
+	Problem
+	Position int `json:"position"`
+	Length   int `json:"length"`
+}
+
+// quickAdd обслуживает POST /events:quickAdd.
+func (s *Server) quickAdd(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	var req QuickAddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid json: %w", err))
+		return
+	}
+	loc := time.UTC
+	if req.TimeZone != "" {
+		l, err := time.LoadLocation(req.TimeZone)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown time zone %q", req.TimeZone))
+			return
+		}
+		loc = l
+	}
+
+	created, err := s.app.QuickAdd(r.Context(), userID, req.Text, loc, req.CalendarID)
+	var perr *quickadd.ParseError
+	if errors.As(err, &perr) {
+		writeProblem(w, http.StatusBadRequest, QuickAddError{
+			Problem: newProblem(http.StatusBadRequest, perr), Position: perr.Pos, Length: perr.Len,
+		})
+		return
+	}
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toDTO(created, s.colors(r.Context(), userID)))
+}
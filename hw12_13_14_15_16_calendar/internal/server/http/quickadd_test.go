@@ -0,0 +1,65 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuickAdd(t *testing.T) {
+	h := newTestHandler()
+
+	t.Run("created in the user's time zone", func(t *testing.T) {
+		loc, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+		w := doJSON(t, h, http.MethodPost, "/events:quickAdd", "user-1", QuickAddRequest{
+			Text:     "Team sync tomorrow 10:00 for 30m remind 15m",
+			TimeZone: "Asia/Tokyo",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var event Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		y, m, d := time.Now().In(loc).AddDate(0, 0, 1).Date()
+		start := time.Date(y, m, d, 10, 0, 0, 0, loc)
+		require.Equal(t, "Team sync", event.Title)
+		require.True(t, start.Equal(event.Start), event.Start.String())
+		require.True(t, start.Add(30*time.Minute).Equal(event.End), event.End.String())
+		require.Equal(t, []Reminder{{ID: "start-900s", RelativeTo: "start", Offset: "-15m0s"}}, event.Reminders)
+
+		w = doJSON(t, h, http.MethodGet, "/events/"+event.ID, "user-1", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("parse error with position", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/events:quickAdd", "user-1", QuickAddRequest{Text: "Созвон завтра в 25:00"})
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		var res QuickAddError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+		require.Equal(t, QuickAddError{
+			Problem: Problem{
+				Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest,
+				Detail: `position 16: invalid time "25:00"`,
+			},
+			Position: 16, Length: 5,
+		}, res)
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/events:quickAdd", "user-1", QuickAddRequest{
+			Text: "Sync tomorrow 10:00", TimeZone: "Mars/Olympus",
+		})
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = doJSON(t, h, http.MethodGet, "/events:quickAdd", "user-1", nil)
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+		w = doJSON(t, h, http.MethodPost, "/events:quickAdd", "", QuickAddRequest{Text: "Sync tomorrow 10:00"})
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
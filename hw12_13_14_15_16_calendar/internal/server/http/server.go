@@ -2,30 +2,183 @@ package internalhttp
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/tracing"
 )
 
-type Server struct { // TODO
+type Server struct {
+	logger Logger
+	app    Application
+	server *http.Server
+}
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
+
+type Application interface {
+	CreateEvent(ctx context.Context, userID string, event storage.Event) (storage.Event, error)
+	UpdateEvent(ctx context.Context, userID, id string, event storage.Event) (storage.Event, error)
+	DeleteEvent(ctx context.Context, userID, id string) error
+	Batch(ctx context.Context, userID string, ops []app.BatchOp, atomic bool) ([]app.BatchResult, error)
+	QuickAdd(ctx context.Context, userID, text string, loc *time.Location, calendarID string) (storage.Event, error)
+	GetEvent(ctx context.Context, userID, id string) (storage.Event, error)
+	ListDay(ctx context.Context, userID string, date time.Time, filter app.Filter) ([]storage.Event, error)
+	ListWeek(ctx context.Context, userID string, date time.Time, filter app.Filter) ([]storage.Event, error)
+	ListMonth(ctx context.Context, userID string, date time.Time, filter app.Filter) ([]storage.Event, error)
+	ListAll(ctx context.Context, userID string, filter app.Filter) ([]storage.Event, error)
+	CreateLabel(ctx context.Context, userID string, label storage.Label) (storage.Label, error)
+	UpdateLabel(ctx context.Context, userID, name string, label storage.Label) (storage.Label, error)
+	DeleteLabel(ctx context.Context, userID, name string) error
+	ListLabels(ctx context.Context, userID string) ([]storage.Label, error)
+	CreateCalendar(ctx context.Context, userID string, c storage.Calendar) (storage.Calendar, error)
+	UpdateCalendar(ctx context.Context, userID, id string, c storage.Calendar) (storage.Calendar, error)
+	DeleteCalendar(ctx context.Context, userID, id string) error
+	GetCalendar(ctx context.Context, userID, id string) (storage.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error)
+	CalendarEvents(ctx context.Context, userID, calendarID string, from, to time.Time) ([]storage.Event, error)
+	ShareCalendar(ctx context.Context, userID, id, shareWith string, access storage.Access) (storage.Calendar, error)
+	UnshareCalendar(ctx context.Context, userID, id, shareWith string) error
+	GetWorkingHours(ctx context.Context, userID string) (storage.WorkingHours, error)
+	SetWorkingHours(ctx context.Context, userID string, w storage.WorkingHours) (storage.WorkingHours, error)
+	DeleteWorkingHours(ctx context.Context, userID string) error
+	FreeBusy(ctx context.Context, userID string, users []string, from, to time.Time) ([]app.FreeBusy, error)
+	ListTrash(ctx context.Context, userID string) ([]storage.Event, error)
+	RestoreFromTrash(ctx context.Context, userID, id string) (storage.Event, error)
+	History(ctx context.Context, userID, id string) ([]storage.AuditEntry, error)
+	RestoreEvent(ctx context.Context, userID, id string, version int) (storage.Event, error)
+	ListNotifications(ctx context.Context, userID string) ([]storage.Notification, error)
+	AckNotification(ctx context.Context, userID, id string) (storage.Notification, error)
+	SnoozeNotification(ctx context.Context, userID, id string, d time.Duration) (storage.Notification, error)
+}
+
+// Authenticator возвращает ID пользователя по API-ключу или токену.
+type Authenticator interface {
+	Authenticate(credential string) (string, error)
+}
+
+// RateLimiter ограничивает частоту запросов по ключу клиента.
+type RateLimiter interface {
+	Allow(key string) (bool, time.Duration)
+}
+
+// RateLimits - лимиты по классам маршрутов, nil - без ограничений.
+type RateLimits struct {
+	Read  RateLimiter
+	Write RateLimiter
+}
+
+type options struct {
+	auth        Authenticator
+	limits      RateLimits
+	checks      map[string]HealthCheck
+	buildInfo   BuildInfo
+	idempotency idempotency
+}
+
+type Option func(*options)
+
+// WithAuth включает проверку API-ключей и JWT. Без неё ID пользователя
+// берётся из заголовка X-User-ID без проверки, как описано в ТЗ.
+func WithAuth(auth Authenticator) Option {
+	return func(o *options) {
+		o.auth = auth
+	}
 }
 
-type Logger interface { // TODO
+// WithRateLimits включает ограничение частоты запросов по IP и по пользователю.
+func WithRateLimits(limits RateLimits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
 }
 
-type Application interface { // TODO
+func NewServer(logger Logger, app Application, addr string, opts ...Option) *Server {
+	s := &Server{
+		logger: logger,
+		app:    app,
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", s.hello)
+	// Создание событий мобильные клиенты повторяют при обрывах связи, см. WithIdempotency.
+	idempotent := func(h http.HandlerFunc) http.Handler {
+		return idempotencyMiddleware(logger, o.idempotency, h)
+	}
+	mux.Handle("/events", idempotent(s.events))
+	mux.HandleFunc("/events/", s.event)
+	mux.Handle("/events:batch", idempotent(s.batch))
+	mux.Handle("/events:quickAdd", idempotent(s.quickAdd))
+	mux.HandleFunc("/trash", s.trash)
+	mux.HandleFunc("/calendars", s.calendars)
+	mux.HandleFunc("/calendars/", s.calendar)
+	mux.HandleFunc("/working-hours", s.workingHours)
+	mux.HandleFunc("/freebusy", s.freeBusy)
+	mux.HandleFunc("/labels", s.labels)
+	mux.HandleFunc("/labels/", s.label)
+	mux.HandleFunc("/notifications", s.notifications)
+	mux.HandleFunc("/notifications/", s.notification)
+	mux.HandleFunc(davPrefix, s.dav)
+
+	// IP проверяем до аутентификации, чтобы перебор ключей тоже ограничивался,
+	// пользователя - после, когда его ID уже известен.
+	var handler http.Handler = mux
+	handler = rateLimitMiddleware(o.limits, userKey, handler)
+	if o.auth != nil {
+		handler = authMiddleware(o.auth, handler)
+	} else {
+		handler = userHeaderMiddleware(handler)
+	}
+	handler = rateLimitMiddleware(o.limits, ipKey, handler)
+	handler = tracing.Middleware(handler)
+
+	// Служебные ручки не требуют ключа и не лимитируются: их дёргают healthcheck'и.
+	root := http.NewServeMux()
+	root.HandleFunc("/healthz", healthz)
+	root.Handle("/readyz", readyz(o.checks))
+	root.Handle("/version", version(o.buildInfo))
+	// RFC 6764: клиенты начинают поиск CalDAV-сервера отсюда, ещё без учётных данных.
+	root.Handle("/.well-known/caldav", http.RedirectHandler(davPrefix, http.StatusMovedPermanently))
+	root.Handle("/", handler)
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           root,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
 }
 
-func NewServer(logger Logger, app Application) *Server {
-	return &Server{}
+// Handler - всё API сервера, удобно для httptest.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
 }
 
-func (s *Server) Start(ctx context.Context) error {
-	// TODO
-	<-ctx.Done()
+func (s *Server) Start(_ context.Context) error {
+	s.logger.Info("http server is listening on " + s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
 	return nil
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	// TODO
-	return nil
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) hello(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("hello, " + UserID(r.Context()) + "\n"))
 }
 
 // TODO
@@ -0,0 +1,48 @@
+package internalhttp
+
+import (
+	"errors"
+	"net/http"
+)
+
+// trash обслуживает GET /trash - удалённые события пользователя.
+func (s *Server) trash(w http.ResponseWriter, r *http.Request) {
+	userID := UserID(r.Context())
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, errors.New("user id is required"))
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	events, err := s.app.ListTrash(r.Context(), userID)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	colors := s.colors(r.Context(), userID)
+	res := make([]Event, 0, len(events))
+	for _, e := range events {
+		res = append(res, toDTO(e, colors))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// restoreFromTrash обслуживает POST /events/{id}/restore.
+func (s *Server) restoreFromTrash(w http.ResponseWriter, r *http.Request, userID, id string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	event, err := s.app.RestoreFromTrash(r.Context(), userID, id)
+	if err != nil {
+		s.writeAppError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toDTO(event, s.colors(r.Context(), userID)))
+}
@@ -0,0 +1,82 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	h := newTestHandler()
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	w := doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+		Title: "planning", Start: start, End: start.Add(time.Hour),
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var event Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+	path := "/events/" + event.ID
+
+	w = doJSON(t, h, http.MethodPost, path+"/restore", "user-1", nil)
+	require.Equal(t, http.StatusNotFound, w.Code, "not in trash")
+
+	w = doJSON(t, h, http.MethodDelete, path, "user-1", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = doJSON(t, h, http.MethodGet, path, "user-1", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	var events []Event
+	w = doJSON(t, h, http.MethodGet, "/events?period=day&date=2024-01-10", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	require.Empty(t, events)
+
+	w = doJSON(t, h, http.MethodGet, "/trash", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	require.Len(t, events, 1)
+	require.Equal(t, event.ID, events[0].ID)
+	require.NotNil(t, events[0].DeletedAt)
+
+	w = doJSON(t, h, http.MethodGet, "/trash", "user-2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, "[]", w.Body.String())
+	w = doJSON(t, h, http.MethodPost, path+"/restore", "user-2", nil)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	t.Run("slot is taken", func(t *testing.T) {
+		w := doJSON(t, h, http.MethodPost, "/events", "user-1", Event{
+			Title: "other", Start: start, End: start.Add(time.Hour),
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var other Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &other))
+
+		w = doJSON(t, h, http.MethodPost, path+"/restore", "user-1", nil)
+		require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+		w = doJSON(t, h, http.MethodDelete, "/events/"+other.ID, "user-1", nil)
+		require.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	w = doJSON(t, h, http.MethodPost, path+"/restore", "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var restored Event
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &restored))
+	require.Equal(t, "planning", restored.Title)
+	require.Nil(t, restored.DeletedAt)
+
+	w = doJSON(t, h, http.MethodGet, path, "user-1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doJSON(t, h, http.MethodGet, path+"/history", "user-1", nil)
+	var history []HistoryEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Equal(t, "restore", history[len(history)-1].Action)
+
+	w = doJSON(t, h, http.MethodGet, path+"/restore", "user-1", nil)
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// AuditAction - что сделали с событием.
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditEntry - запись журнала изменений события. Version нумерует записи
+// события с 1 и назначается хранилищем. Before пуст при создании, After - при удалении.
+type AuditEntry struct {
+	EventID string
+	Version int
+	// UserID - владелец события, Actor - кто внёс изменение.
+	UserID string
+	Actor  string
+	Action AuditAction
+	At     time.Time
+	Before *Event
+	After  *Event
+}
+
+// Snapshot - состояние события после записи, для удаления - перед ней.
+func (a AuditEntry) Snapshot() Event {
+	if a.After != nil {
+		return *a.After
+	}
+	if a.Before != nil {
+		return *a.Before
+	}
+	return Event{}
+}
+
+// Change - изменение одного поля события.
+type Change struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// Changes - поля, которые различаются в Before и After.
+func (a AuditEntry) Changes() []Change {
+	before, after := eventFields(a.Before), eventFields(a.After)
+
+	var res []Change
+	for _, f := range eventFieldNames {
+		if before[f] != after[f] {
+			res = append(res, Change{Field: f, Before: before[f], After: after[f]})
+		}
+	}
+	return res
+}
+
+var eventFieldNames = []string{
+	"title", "start", "end", "description", "notifyBefore", "category", "labels", "reminders", "calendar",
+}
+
+func eventFields(e *Event) map[string]string {
+	if e == nil {
+		return map[string]string{}
+	}
+
+	var notifyBefore string
+	if e.NotifyBefore > 0 {
+		notifyBefore = e.NotifyBefore.String()
+	}
+	reminders := make([]string, 0, len(e.Reminders))
+	for _, r := range e.Reminders {
+		reminders = append(reminders, r.ID())
+	}
+	return map[string]string{
+		"title":        e.Title,
+		"start":        e.Start.UTC().Format(time.RFC3339),
+		"end":          e.End.UTC().Format(time.RFC3339),
+		"description":  e.Description,
+		"notifyBefore": notifyBefore,
+		"category":     e.Category,
+		"labels":       strings.Join(e.Labels, ","),
+		"reminders":    strings.Join(reminders, ","),
+		"calendar":     e.CalendarID,
+	}
+}
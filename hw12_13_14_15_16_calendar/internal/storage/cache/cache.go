@@ -0,0 +1,381 @@
+// Package cachestorage - кэш списков событий поверх хранилища. Списки за день,
+// неделю и месяц запрашиваются намного чаще, чем события меняются, поэтому
+// ответы ListEvents и ListCalendarEvents хранятся в LRU с TTL и сбрасываются
+// при изменении событий пользователя или календаря.
+package cachestorage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/lru"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// Inner - хранилище под кэшем, методы вне кэша вызываются у него напрямую.
+type Inner interface {
+	CreateEvent(ctx context.Context, event storage.Event) error
+	UpdateEvent(ctx context.Context, id string, event storage.Event) error
+	DeleteEvent(ctx context.Context, id string) error
+	GetEvent(ctx context.Context, id string) (storage.Event, error)
+	ListEvents(ctx context.Context, userID string, from, to time.Time) ([]storage.Event, error)
+	ListDueNotifications(ctx context.Context, from, to time.Time) ([]storage.Notification, error)
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int, error)
+	SaveNotification(ctx context.Context, n storage.Notification) error
+	ListNotifications(ctx context.Context, userID string) ([]storage.Notification, error)
+	GetNotification(ctx context.Context, id string) (storage.Notification, error)
+	ListSnoozedNotifications(ctx context.Context, from, to time.Time) ([]storage.Notification, error)
+	CreateLabel(ctx context.Context, label storage.Label) error
+	UpdateLabel(ctx context.Context, label storage.Label) error
+	DeleteLabel(ctx context.Context, userID, name string) error
+	ListLabels(ctx context.Context, userID string) ([]storage.Label, error)
+	TrashEvent(ctx context.Context, id string, at time.Time) error
+	UntrashEvent(ctx context.Context, id string) error
+	ListTrash(ctx context.Context, userID string) ([]storage.Event, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	AppendAudit(ctx context.Context, entry storage.AuditEntry) (storage.AuditEntry, error)
+	ListAudit(ctx context.Context, eventID string) ([]storage.AuditEntry, error)
+	Atomic(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateCalendar(ctx context.Context, c storage.Calendar) error
+	UpdateCalendar(ctx context.Context, c storage.Calendar) error
+	DeleteCalendar(ctx context.Context, id string) error
+	GetCalendar(ctx context.Context, id string) (storage.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error)
+	ShareCalendar(ctx context.Context, calendarID, userID string, access storage.Access) error
+	UnshareCalendar(ctx context.Context, calendarID, userID string) error
+	ListCalendarEvents(ctx context.Context, calendarIDs []string, from, to time.Time) ([]storage.Event, error)
+	GetWorkingHours(ctx context.Context, userID string) (storage.WorkingHours, error)
+	SaveWorkingHours(ctx context.Context, w storage.WorkingHours) error
+	DeleteWorkingHours(ctx context.Context, userID string) error
+	Export(ctx context.Context, fn func(storage.Record) error) error
+	ReserveIdempotencyKey(
+		ctx context.Context, k storage.IdempotencyKey, ttl time.Duration,
+	) (storage.IdempotencyKey, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, k storage.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, userID, key string) error
+	DeleteIdempotencyKeysBefore(ctx context.Context, before time.Time) (int, error)
+	TryAcquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, holder string) error
+	Ping(ctx context.Context) error
+}
+
+// Stats - счётчики кэша с запуска, Entries - сколько списков в нём сейчас.
+type Stats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d hits, %d misses, %d entries", s.Hits, s.Misses, s.Entries)
+}
+
+// Storage кэширует списки событий Inner. Сброс не ищет записи в кэше: у каждого
+// пользователя и календаря есть версия, которую увеличивает запись, а запись
+// кэша со старой версией считается промахом и вытесняется LRU со временем.
+type Storage struct {
+	Inner
+	entries *lru.Cache
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu sync.Mutex
+	// versions - версии пользователей ("user:ID") и календарей ("calendar:ID"),
+	// generation сбрасывает всё сразу.
+	versions   map[string]uint64
+	generation uint64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type entry struct {
+	events     []storage.Event
+	versions   []uint64
+	generation uint64
+	expires    time.Time
+}
+
+// txKey - метка транзакции в контексте, в ней копятся затронутые области.
+type txKey struct{}
+
+type txScopes struct {
+	mu     sync.Mutex
+	scopes []string
+	all    bool
+}
+
+// New - кэш не больше size списков, каждый живёт не дольше ttl.
+func New(inner Inner, size int, ttl time.Duration) *Storage {
+	return &Storage{
+		Inner:    inner,
+		entries:  lru.New(size),
+		ttl:      ttl,
+		now:      time.Now,
+		versions: make(map[string]uint64),
+	}
+}
+
+func (s *Storage) Stats() Stats {
+	return Stats{Hits: s.hits.Load(), Misses: s.misses.Load(), Entries: s.entries.Len()}
+}
+
+func (s *Storage) ListEvents(ctx context.Context, userID string, from, to time.Time) ([]storage.Event, error) {
+	key := "events\x00" + userID + "\x00" + window(from, to)
+	return s.list(ctx, key, []string{userScope(userID)}, func() ([]storage.Event, error) {
+		return s.Inner.ListEvents(ctx, userID, from, to)
+	})
+}
+
+func (s *Storage) ListCalendarEvents(
+	ctx context.Context, calendarIDs []string, from, to time.Time,
+) ([]storage.Event, error) {
+	scopes := make([]string, 0, len(calendarIDs))
+	for _, id := range calendarIDs {
+		scopes = append(scopes, calendarScope(id))
+	}
+	key := "calendars\x00" + strings.Join(calendarIDs, "\x00") + "\x00" + window(from, to)
+	return s.list(ctx, key, scopes, func() ([]storage.Event, error) {
+		return s.Inner.ListCalendarEvents(ctx, calendarIDs, from, to)
+	})
+}
+
+// list отдаёт список из кэша или из Inner. В транзакции кэш не используется:
+// она видит свои незафиксированные изменения, которых нет у других.
+func (s *Storage) list(
+	ctx context.Context, key string, scopes []string, load func() ([]storage.Event, error),
+) ([]storage.Event, error) {
+	if ctx.Value(txKey{}) != nil {
+		return load()
+	}
+
+	generation, versions := s.current(scopes)
+	if v, ok := s.entries.Get(key); ok {
+		e := v.(entry)
+		if e.generation == generation && equal(e.versions, versions) && s.now().Before(e.expires) {
+			s.hits.Add(1)
+			return cloneEvents(e.events), nil
+		}
+	}
+	s.misses.Add(1)
+
+	// Версии взяты до чтения: если событие изменится во время чтения,
+	// запись окажется устаревшей и в кэше не задержится.
+	events, err := load()
+	if err != nil {
+		return nil, err
+	}
+	s.entries.Set(key, entry{
+		events:     cloneEvents(events),
+		versions:   versions,
+		generation: generation,
+		expires:    s.now().Add(s.ttl),
+	})
+	return events, nil
+}
+
+func (s *Storage) current(scopes []string) (uint64, []uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions := make([]uint64, 0, len(scopes))
+	for _, scope := range scopes {
+		versions = append(versions, s.versions[scope])
+	}
+	return s.generation, versions
+}
+
+// invalidate сбрасывает списки областей. В транзакции области запоминаются,
+// чтобы сбросить их ещё раз после фиксации.
+func (s *Storage) invalidate(ctx context.Context, scopes ...string) {
+	if tx, ok := ctx.Value(txKey{}).(*txScopes); ok {
+		tx.mu.Lock()
+		tx.scopes = append(tx.scopes, scopes...)
+		tx.mu.Unlock()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, scope := range scopes {
+		s.versions[scope]++
+	}
+}
+
+func (s *Storage) invalidateAll(ctx context.Context) {
+	if tx, ok := ctx.Value(txKey{}).(*txScopes); ok {
+		tx.mu.Lock()
+		tx.all = true
+		tx.mu.Unlock()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+}
+
+func (s *Storage) invalidateEvent(ctx context.Context, e storage.Event) {
+	scopes := []string{userScope(e.UserID)}
+	if e.CalendarID != "" {
+		scopes = append(scopes, calendarScope(e.CalendarID))
+	}
+	s.invalidate(ctx, scopes...)
+}
+
+// Atomic сбрасывает затронутые транзакцией списки и после её завершения: пока она
+// не зафиксирована, другие читатели могли положить в кэш прежние данные.
+func (s *Storage) Atomic(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return s.Inner.Atomic(ctx, fn)
+	}
+	tx := &txScopes{}
+	err := s.Inner.Atomic(context.WithValue(ctx, txKey{}, tx), fn)
+	if tx.all {
+		s.invalidateAll(ctx)
+	}
+	s.invalidate(ctx, tx.scopes...)
+	return err
+}
+
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) error {
+	if err := s.Inner.CreateEvent(ctx, event); err != nil {
+		return err
+	}
+	s.invalidateEvent(ctx, event)
+	return nil
+}
+
+// UpdateEvent сбрасывает списки и прежнего владельца и календаря события.
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+	return s.changeEvent(ctx, id, func() error {
+		if err := s.Inner.UpdateEvent(ctx, id, event); err != nil {
+			return err
+		}
+		s.invalidateEvent(ctx, event)
+		return nil
+	})
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
+	return s.changeEvent(ctx, id, func() error {
+		return s.Inner.DeleteEvent(ctx, id)
+	})
+}
+
+func (s *Storage) TrashEvent(ctx context.Context, id string, at time.Time) error {
+	return s.changeEvent(ctx, id, func() error {
+		return s.Inner.TrashEvent(ctx, id, at)
+	})
+}
+
+func (s *Storage) UntrashEvent(ctx context.Context, id string) error {
+	return s.changeEvent(ctx, id, func() error {
+		return s.Inner.UntrashEvent(ctx, id)
+	})
+}
+
+// changeEvent выполняет change и сбрасывает списки, где событие было до него.
+// Если прежнее событие прочитать не удалось, сбрасывается весь кэш.
+func (s *Storage) changeEvent(ctx context.Context, id string, change func() error) error {
+	before, getErr := s.Inner.GetEvent(ctx, id)
+	if err := change(); err != nil {
+		return err
+	}
+	if getErr != nil {
+		s.invalidateAll(ctx)
+		return nil
+	}
+	s.invalidateEvent(ctx, before)
+	return nil
+}
+
+func (s *Storage) DeleteEventsBefore(ctx context.Context, before time.Time) (int, error) {
+	n, err := s.Inner.DeleteEventsBefore(ctx, before)
+	if n > 0 {
+		s.invalidateAll(ctx)
+	}
+	return n, err
+}
+
+func (s *Storage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	n, err := s.Inner.PurgeTrash(ctx, before)
+	if n > 0 {
+		s.invalidateAll(ctx)
+	}
+	return n, err
+}
+
+// DeleteCalendar сбрасывает списки календаря и его владельца: события удаляются вместе с ним.
+func (s *Storage) DeleteCalendar(ctx context.Context, id string) error {
+	c, getErr := s.Inner.GetCalendar(ctx, id)
+	if err := s.Inner.DeleteCalendar(ctx, id); err != nil {
+		return err
+	}
+	if getErr != nil {
+		s.invalidateAll(ctx)
+		return nil
+	}
+	s.invalidate(ctx, calendarScope(id), userScope(c.OwnerID))
+	return nil
+}
+
+// DeleteLabel снимает метку с событий пользователя, в том числе в его календарях,
+// поэтому сбрасываются списки пользователя и всех календарей, которыми он владеет.
+func (s *Storage) DeleteLabel(ctx context.Context, userID, name string) error {
+	calendars, listErr := s.Inner.ListCalendars(ctx, userID)
+	if err := s.Inner.DeleteLabel(ctx, userID, name); err != nil {
+		return err
+	}
+	if listErr != nil {
+		s.invalidateAll(ctx)
+		return nil
+	}
+	scopes := []string{userScope(userID)}
+	for _, c := range calendars {
+		if c.OwnerID == userID {
+			scopes = append(scopes, calendarScope(c.ID))
+		}
+	}
+	s.invalidate(ctx, scopes...)
+	return nil
+}
+
+func userScope(userID string) string {
+	return "user:" + userID
+}
+
+func calendarScope(id string) string {
+	return "calendar:" + id
+}
+
+// window - ключ интервала. UnixNano не годится: CalDAV просит списки с нулевого времени.
+func window(from, to time.Time) string {
+	return from.UTC().Format(time.RFC3339Nano) + "\x00" + to.UTC().Format(time.RFC3339Nano)
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// cloneEvents копирует и срезы внутри событий: вызывающие вправе менять то, что получили.
+func cloneEvents(events []storage.Event) []storage.Event {
+	res := make([]storage.Event, len(events))
+	for i, e := range events {
+		if e.Labels != nil {
+			e.Labels = append([]string(nil), e.Labels...)
+		}
+		if e.Reminders != nil {
+			e.Reminders = append([]storage.Reminder(nil), e.Reminders...)
+		}
+		res[i] = e
+	}
+	return res
+}
@@ -0,0 +1,177 @@
+package cachestorage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.RunConformance(t, func(t *testing.T) storagetest.Storage {
+		t.Helper()
+		return New(memorystorage.New(), 100, time.Minute)
+	})
+}
+
+// countingStorage считает обращения к спискам событий.
+type countingStorage struct {
+	*memorystorage.Storage
+	lists int
+}
+
+func (s *countingStorage) ListEvents(ctx context.Context, userID string, from, to time.Time) ([]storage.Event, error) {
+	s.lists++
+	return s.Storage.ListEvents(ctx, userID, from, to)
+}
+
+func (s *countingStorage) ListCalendarEvents(
+	ctx context.Context, calendarIDs []string, from, to time.Time,
+) ([]storage.Event, error) {
+	s.lists++
+	return s.Storage.ListCalendarEvents(ctx, calendarIDs, from, to)
+}
+
+var day = time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+func event(id, userID string, hour int) storage.Event {
+	return storage.Event{
+		ID: id, Title: id, UserID: userID, Labels: []string{"work"},
+		Start: day.Add(time.Duration(hour) * time.Hour), End: day.Add(time.Duration(hour+1) * time.Hour),
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingStorage{Storage: memorystorage.New()}
+	s := New(inner, 2, time.Minute)
+	now := day
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.CreateEvent(ctx, event("1", "user-1", 10)))
+	require.NoError(t, s.CreateEvent(ctx, event("2", "user-2", 10)))
+
+	listDay := func(userID string) []storage.Event {
+		t.Helper()
+		events, err := s.ListEvents(ctx, userID, day, day.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		return events
+	}
+
+	t.Run("hit", func(t *testing.T) {
+		events := listDay("user-1")
+		require.Len(t, events, 1)
+		events[0].Labels[0] = "changed"
+		require.Equal(t, []string{"work"}, listDay("user-1")[0].Labels)
+		require.Equal(t, 1, inner.lists)
+		require.Equal(t, Stats{Hits: 1, Misses: 1, Entries: 1}, s.Stats())
+	})
+
+	t.Run("write invalidates only its user", func(t *testing.T) {
+		listDay("user-2")
+		require.NoError(t, s.CreateEvent(ctx, event("3", "user-1", 12)))
+		lists := inner.lists
+
+		require.Len(t, listDay("user-1"), 2)
+		require.Equal(t, lists+1, inner.lists)
+		listDay("user-2")
+		require.Equal(t, lists+1, inner.lists)
+	})
+
+	t.Run("update and trash", func(t *testing.T) {
+		moved := event("3", "user-1", 12)
+		moved.Start, moved.End = moved.Start.AddDate(0, 0, 1), moved.End.AddDate(0, 0, 1)
+		require.NoError(t, s.UpdateEvent(ctx, "3", moved))
+		require.Len(t, listDay("user-1"), 1)
+
+		require.NoError(t, s.TrashEvent(ctx, "1", now))
+		require.Empty(t, listDay("user-1"))
+		require.NoError(t, s.UntrashEvent(ctx, "1"))
+		require.Len(t, listDay("user-1"), 1)
+	})
+
+	t.Run("calendar events", func(t *testing.T) {
+		require.NoError(t, s.CreateCalendar(ctx, storage.Calendar{ID: "team", OwnerID: "user-1", Name: "Team"}))
+		list := func() []storage.Event {
+			events, err := s.ListCalendarEvents(ctx, []string{"team"}, day, day.AddDate(0, 0, 1))
+			require.NoError(t, err)
+			return events
+		}
+		require.Empty(t, list())
+
+		inTeam := event("1", "user-1", 10)
+		inTeam.CalendarID = "team"
+		require.NoError(t, s.UpdateEvent(ctx, "1", inTeam))
+		require.Len(t, list(), 1)
+		require.NoError(t, s.DeleteEvent(ctx, "1"))
+		require.Empty(t, list())
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		listDay("user-2")
+		lists := inner.lists
+		now = now.Add(time.Minute)
+		listDay("user-2")
+		require.Equal(t, lists+1, inner.lists)
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		listDay("user-2")
+		errRollback := errors.New("rollback")
+		err := s.Atomic(ctx, func(ctx context.Context) error {
+			require.NoError(t, s.CreateEvent(ctx, event("4", "user-2", 14)))
+			events, err := s.ListEvents(ctx, "user-2", day, day.AddDate(0, 0, 1))
+			require.NoError(t, err)
+			require.Len(t, events, 2)
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+		require.Len(t, listDay("user-2"), 1)
+
+		require.NoError(t, s.Atomic(ctx, func(ctx context.Context) error {
+			return s.CreateEvent(ctx, event("4", "user-2", 14))
+		}))
+		require.Len(t, listDay("user-2"), 2)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			_, err := s.ListEvents(ctx, "user-1", day.AddDate(0, 0, i), day.AddDate(0, 0, i+1))
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, s.Stats().Entries)
+	})
+}
+
+func TestCacheDeleteLabel(t *testing.T) {
+	ctx := context.Background()
+	s := New(memorystorage.New(), 10, time.Minute)
+	require.NoError(t, s.CreateLabel(ctx, storage.Label{UserID: "user-1", Name: "work", Color: "#ff0000"}))
+	require.NoError(t, s.CreateCalendar(ctx, storage.Calendar{ID: "team", OwnerID: "user-1", Name: "Team"}))
+	inTeam := event("2", "user-1", 12)
+	inTeam.CalendarID = "team"
+	require.NoError(t, s.CreateEvent(ctx, event("1", "user-1", 10)))
+	require.NoError(t, s.CreateEvent(ctx, inTeam))
+
+	labels := func() [][]string {
+		t.Helper()
+		own, err := s.ListEvents(ctx, "user-1", day, day.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		shared, err := s.ListCalendarEvents(ctx, []string{"team"}, day, day.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		res := make([][]string, 0, len(own)+len(shared))
+		for _, e := range append(own, shared...) {
+			res = append(res, e.Labels)
+		}
+		return res
+	}
+	require.Equal(t, [][]string{{"work"}, {"work"}, {"work"}}, labels())
+
+	require.NoError(t, s.DeleteLabel(ctx, "user-1", "work"))
+	require.Equal(t, [][]string{nil, nil, nil}, labels())
+}
@@ -0,0 +1,44 @@
+package storage
+
+import "errors"
+
+var (
+	ErrCalendarNotFound = errors.New("calendar not found")
+	ErrCalendarExists   = errors.New("calendar already exists")
+	ErrShareNotFound    = errors.New("share not found")
+)
+
+// Access - права пользователя на календарь.
+type Access string
+
+const (
+	AccessNone  Access = ""
+	AccessRead  Access = "read"
+	AccessWrite Access = "write"
+	AccessOwner Access = "owner"
+)
+
+func (a Access) CanRead() bool {
+	return a != AccessNone
+}
+
+func (a Access) CanWrite() bool {
+	return a == AccessWrite || a == AccessOwner
+}
+
+// Calendar - именованный календарь владельца, Shares - кому он открыт:
+// ID пользователя и AccessRead или AccessWrite.
+type Calendar struct {
+	ID      string
+	OwnerID string
+	Name    string
+	Shares  map[string]Access
+}
+
+// Access - права userID на календарь.
+func (c Calendar) Access(userID string) Access {
+	if c.OwnerID == userID {
+		return AccessOwner
+	}
+	return c.Shares[userID]
+}
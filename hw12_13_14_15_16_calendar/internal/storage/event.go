@@ -1,7 +1,55 @@
 package storage
 
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrEventNotFound = errors.New("event not found")
+	ErrEventExists   = errors.New("event already exists")
+	// ErrDateBusy - время уже занято другим событием этого пользователя.
+	ErrDateBusy = errors.New("date is busy")
+)
+
 type Event struct {
-	ID    string
-	Title string
-	// TODO
+	ID           string
+	Title        string
+	Start        time.Time
+	End          time.Time
+	Description  string
+	UserID       string
+	NotifyBefore time.Duration
+	// Category - основная метка события, её цветом событие рисуется.
+	Category string
+	Labels   []string
+	// Reminders - дополнительные напоминания, см. Triggers.
+	Reminders []Reminder
+	// CalendarID - календарь события, пустой - личный календарь UserID.
+	// В общем календаре UserID - владелец календаря, а не автор события.
+	CalendarID string
+	// DeletedAt - когда событие перенесено в корзину, нулевое у живых.
+	// Событие в корзине не занимает время и не напоминает о себе.
+	DeletedAt time.Time
+}
+
+func (e Event) Trashed() bool {
+	return !e.DeletedAt.IsZero()
+}
+
+// Overlaps - пересекается ли событие с интервалом [from, to).
+func (e Event) Overlaps(from, to time.Time) bool {
+	return e.Start.Before(to) && e.End.After(from)
+}
+
+func (e Event) HasLabel(name string) bool {
+	if e.Category == name {
+		return true
+	}
+	for _, l := range e.Labels {
+		if l == name {
+			return true
+		}
+	}
+	return false
 }
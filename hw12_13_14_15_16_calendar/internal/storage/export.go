@@ -0,0 +1,13 @@
+package storage
+
+// Record - одна запись полной выгрузки хранилища, заполнено ровно одно поле.
+// Хранилища выгружают сначала календари, метки и рабочее время, затем события,
+// уведомления и журнал изменений: в таком порядке выгрузку можно загрузить обратно.
+type Record struct {
+	Calendar     *Calendar
+	Label        *Label
+	WorkingHours *WorkingHours
+	Event        *Event
+	Notification *Notification
+	Audit        *AuditEntry
+}
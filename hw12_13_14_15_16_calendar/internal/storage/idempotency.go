@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+// IdempotencyKey - сохранённый ответ на запрос с заголовком Idempotency-Key,
+// ключи у каждого пользователя свои. Hash - отпечаток запроса, чтобы не отдать
+// ответ на другой запрос с тем же ключом. Status 0 - запрос ещё выполняется.
+type IdempotencyKey struct {
+	UserID      string
+	Key         string
+	Hash        string
+	Status      int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+func (k IdempotencyKey) Pending() bool {
+	return k.Status == 0
+}
@@ -0,0 +1,15 @@
+package storage
+
+import "errors"
+
+var (
+	ErrLabelNotFound = errors.New("label not found")
+	ErrLabelExists   = errors.New("label already exists")
+)
+
+// Label - метка из набора пользователя, Color в виде "#rrggbb".
+type Label struct {
+	UserID string
+	Name   string
+	Color  string
+}
@@ -0,0 +1,39 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// AppendAudit добавляет запись в журнал события и назначает ей следующую версию.
+func (s *Storage) AppendAudit(ctx context.Context, entry storage.AuditEntry) (storage.AuditEntry, error) {
+	defer s.lock(ctx)()
+
+	entry.Version = len(s.audit[entry.EventID]) + 1
+	entry.Before = cloneSnapshot(entry.Before)
+	entry.After = cloneSnapshot(entry.After)
+	s.audit[entry.EventID] = append(s.audit[entry.EventID], entry)
+	return entry, nil
+}
+
+// ListAudit - журнал события по возрастанию версии.
+func (s *Storage) ListAudit(ctx context.Context, eventID string) ([]storage.AuditEntry, error) {
+	defer s.rlock(ctx)()
+
+	res := make([]storage.AuditEntry, 0, len(s.audit[eventID]))
+	for _, entry := range s.audit[eventID] {
+		entry.Before = cloneSnapshot(entry.Before)
+		entry.After = cloneSnapshot(entry.After)
+		res = append(res, entry)
+	}
+	return res, nil
+}
+
+func cloneSnapshot(e *storage.Event) *storage.Event {
+	if e == nil {
+		return nil
+	}
+	c := clone(*e)
+	return &c
+}
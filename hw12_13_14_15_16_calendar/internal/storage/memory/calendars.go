@@ -0,0 +1,156 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+func (s *Storage) CreateCalendar(ctx context.Context, c storage.Calendar) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.calendars[c.ID]; ok {
+		return storage.ErrCalendarExists
+	}
+	if s.nameTaken(c) {
+		return storage.ErrCalendarExists
+	}
+	s.calendars[c.ID] = cloneCalendar(c)
+	return nil
+}
+
+// UpdateCalendar переименовывает календарь, права меняются через ShareCalendar.
+func (s *Storage) UpdateCalendar(ctx context.Context, c storage.Calendar) error {
+	defer s.lock(ctx)()
+
+	prev, ok := s.calendars[c.ID]
+	if !ok {
+		return storage.ErrCalendarNotFound
+	}
+	prev.Name = c.Name
+	if s.nameTaken(prev) {
+		return storage.ErrCalendarExists
+	}
+	s.calendars[c.ID] = prev
+	return nil
+}
+
+// DeleteCalendar удаляет календарь, его события переходят в личный календарь владельца.
+func (s *Storage) DeleteCalendar(ctx context.Context, id string) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.calendars[id]; !ok {
+		return storage.ErrCalendarNotFound
+	}
+	delete(s.calendars, id)
+	for eventID, e := range s.events {
+		if e.CalendarID == id {
+			e.CalendarID = ""
+			s.events[eventID] = e
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetCalendar(ctx context.Context, id string) (storage.Calendar, error) {
+	defer s.rlock(ctx)()
+
+	c, ok := s.calendars[id]
+	if !ok {
+		return storage.Calendar{}, storage.ErrCalendarNotFound
+	}
+	return cloneCalendar(c), nil
+}
+
+// ListCalendars - календари пользователя и открытые ему, по имени.
+func (s *Storage) ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error) {
+	defer s.rlock(ctx)()
+
+	res := make([]storage.Calendar, 0)
+	for _, c := range s.calendars {
+		if c.Access(userID).CanRead() {
+			res = append(res, cloneCalendar(c))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Name != res[j].Name {
+			return res[i].Name < res[j].Name
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
+
+// ShareCalendar открывает календарь пользователю или меняет его права.
+func (s *Storage) ShareCalendar(ctx context.Context, calendarID, userID string, access storage.Access) error {
+	defer s.lock(ctx)()
+
+	c, ok := s.calendars[calendarID]
+	if !ok {
+		return storage.ErrCalendarNotFound
+	}
+	c = cloneCalendar(c)
+	c.Shares[userID] = access
+	s.calendars[calendarID] = c
+	return nil
+}
+
+func (s *Storage) UnshareCalendar(ctx context.Context, calendarID, userID string) error {
+	defer s.lock(ctx)()
+
+	c, ok := s.calendars[calendarID]
+	if !ok {
+		return storage.ErrCalendarNotFound
+	}
+	if _, ok := c.Shares[userID]; !ok {
+		return storage.ErrShareNotFound
+	}
+	c = cloneCalendar(c)
+	delete(c.Shares, userID)
+	s.calendars[calendarID] = c
+	return nil
+}
+
+// ListCalendarEvents - события календарей, пересекающиеся с [from, to), по времени начала.
+func (s *Storage) ListCalendarEvents(
+	ctx context.Context, calendarIDs []string, from, to time.Time,
+) ([]storage.Event, error) {
+	defer s.rlock(ctx)()
+
+	ids := make(map[string]bool, len(calendarIDs))
+	for _, id := range calendarIDs {
+		ids[id] = true
+	}
+	events := make([]storage.Event, 0)
+	for _, e := range s.events {
+		if e.CalendarID != "" && ids[e.CalendarID] && !e.Trashed() && e.Overlaps(from, to) {
+			events = append(events, clone(e))
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+	return events, nil
+}
+
+// nameTaken вызывается под блокировкой: имена календарей уникальны у владельца.
+func (s *Storage) nameTaken(c storage.Calendar) bool {
+	for _, other := range s.calendars {
+		if other.ID != c.ID && other.OwnerID == c.OwnerID && other.Name == c.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneCalendar копирует права, чтобы их нельзя было поменять в обход хранилища.
+func cloneCalendar(c storage.Calendar) storage.Calendar {
+	shares := make(map[string]storage.Access, len(c.Shares))
+	for userID, access := range c.Shares {
+		shares[userID] = access
+	}
+	c.Shares = shares
+	return c
+}
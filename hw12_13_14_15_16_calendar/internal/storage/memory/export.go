@@ -0,0 +1,93 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// Export передаёт fn всё содержимое хранилища в порядке storage.Record.
+// Записи копируются под блокировкой, а fn вызывается уже без неё.
+func (s *Storage) Export(ctx context.Context, fn func(storage.Record) error) error {
+	for _, r := range s.records(ctx) {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storage) records(ctx context.Context) []storage.Record {
+	defer s.rlock(ctx)()
+
+	calendars := make([]storage.Calendar, 0, len(s.calendars))
+	for _, c := range s.calendars {
+		calendars = append(calendars, cloneCalendar(c))
+	}
+	sort.Slice(calendars, func(i, j int) bool { return calendars[i].ID < calendars[j].ID })
+
+	var labels []storage.Label
+	for _, byName := range s.labels {
+		for _, l := range byName {
+			labels = append(labels, l)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].UserID != labels[j].UserID {
+			return labels[i].UserID < labels[j].UserID
+		}
+		return labels[i].Name < labels[j].Name
+	})
+
+	hours := make([]storage.WorkingHours, 0, len(s.workingHours))
+	for _, w := range s.workingHours {
+		w.Holidays = append([]string(nil), w.Holidays...)
+		hours = append(hours, w)
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].UserID < hours[j].UserID })
+
+	events := make([]storage.Event, 0, len(s.events))
+	for _, e := range s.events {
+		events = append(events, clone(e))
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	notifications := make([]storage.Notification, 0, len(s.notifications))
+	for _, n := range s.notifications {
+		notifications = append(notifications, n)
+	}
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].ID < notifications[j].ID })
+
+	eventIDs := make([]string, 0, len(s.audit))
+	for id := range s.audit {
+		eventIDs = append(eventIDs, id)
+	}
+	sort.Strings(eventIDs)
+
+	res := make([]storage.Record, 0, len(calendars)+len(labels)+len(hours)+len(events)+len(notifications))
+	for i := range calendars {
+		res = append(res, storage.Record{Calendar: &calendars[i]})
+	}
+	for i := range labels {
+		res = append(res, storage.Record{Label: &labels[i]})
+	}
+	for i := range hours {
+		res = append(res, storage.Record{WorkingHours: &hours[i]})
+	}
+	for i := range events {
+		res = append(res, storage.Record{Event: &events[i]})
+	}
+	for i := range notifications {
+		res = append(res, storage.Record{Notification: &notifications[i]})
+	}
+	for _, id := range eventIDs {
+		for _, entry := range s.audit[id] {
+			entry := entry
+			entry.Before = cloneSnapshot(entry.Before)
+			entry.After = cloneSnapshot(entry.After)
+			res = append(res, storage.Record{Audit: &entry})
+		}
+	}
+	return res
+}
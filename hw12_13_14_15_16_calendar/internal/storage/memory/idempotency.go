@@ -0,0 +1,76 @@
+package memorystorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+type idempotencyKey struct {
+	userID string
+	key    string
+}
+
+// ReserveIdempotencyKey занимает ключ на ttl, если он свободен или истёк, и возвращает
+// новую запись с reserved. Иначе возвращает уже сохранённую запись.
+func (s *Storage) ReserveIdempotencyKey(
+	ctx context.Context, k storage.IdempotencyKey, ttl time.Duration,
+) (saved storage.IdempotencyKey, reserved bool, err error) {
+	defer s.lock(ctx)()
+
+	id := idempotencyKey{userID: k.UserID, key: k.Key}
+	now := time.Now()
+	if saved, ok := s.idempotency[id]; ok && saved.ExpiresAt.After(now) {
+		return cloneIdempotencyKey(saved), false, nil
+	}
+	k.Status, k.ContentType, k.Body = 0, "", nil
+	k.ExpiresAt = now.Add(ttl)
+	s.idempotency[id] = k
+	return k, true, nil
+}
+
+// CompleteIdempotencyKey сохраняет ответ в занятый ключ, срок жизни ключа не меняется.
+func (s *Storage) CompleteIdempotencyKey(ctx context.Context, k storage.IdempotencyKey) error {
+	defer s.lock(ctx)()
+
+	id := idempotencyKey{userID: k.UserID, key: k.Key}
+	saved, ok := s.idempotency[id]
+	if !ok {
+		return storage.ErrIdempotencyKeyNotFound
+	}
+	saved.Status, saved.ContentType = k.Status, k.ContentType
+	saved.Body = append([]byte(nil), k.Body...)
+	s.idempotency[id] = saved
+	return nil
+}
+
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, userID, key string) error {
+	defer s.lock(ctx)()
+
+	id := idempotencyKey{userID: userID, key: key}
+	if _, ok := s.idempotency[id]; !ok {
+		return storage.ErrIdempotencyKeyNotFound
+	}
+	delete(s.idempotency, id)
+	return nil
+}
+
+// DeleteIdempotencyKeysBefore удаляет ключи, истёкшие к before.
+func (s *Storage) DeleteIdempotencyKeysBefore(ctx context.Context, before time.Time) (int, error) {
+	defer s.lock(ctx)()
+
+	n := 0
+	for id, k := range s.idempotency {
+		if k.ExpiresAt.Before(before) {
+			delete(s.idempotency, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cloneIdempotencyKey(k storage.IdempotencyKey) storage.IdempotencyKey {
+	k.Body = append([]byte(nil), k.Body...)
+	return k
+}
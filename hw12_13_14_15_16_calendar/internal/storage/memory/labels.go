@@ -0,0 +1,83 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+func (s *Storage) CreateLabel(ctx context.Context, label storage.Label) error {
+	defer s.lock(ctx)()
+
+	labels, ok := s.labels[label.UserID]
+	if !ok {
+		labels = make(map[string]storage.Label)
+		s.labels[label.UserID] = labels
+	}
+	if _, ok := labels[label.Name]; ok {
+		return storage.ErrLabelExists
+	}
+	labels[label.Name] = label
+	return nil
+}
+
+func (s *Storage) UpdateLabel(ctx context.Context, label storage.Label) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.labels[label.UserID][label.Name]; !ok {
+		return storage.ErrLabelNotFound
+	}
+	s.labels[label.UserID][label.Name] = label
+	return nil
+}
+
+// DeleteLabel заодно снимает метку с событий пользователя.
+func (s *Storage) DeleteLabel(ctx context.Context, userID, name string) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.labels[userID][name]; !ok {
+		return storage.ErrLabelNotFound
+	}
+	delete(s.labels[userID], name)
+
+	for id, e := range s.events {
+		if e.UserID != userID || !e.HasLabel(name) {
+			continue
+		}
+		if e.Category == name {
+			e.Category = ""
+		}
+		labels := make([]string, 0, len(e.Labels))
+		for _, l := range e.Labels {
+			if l != name {
+				labels = append(labels, l)
+			}
+		}
+		e.Labels = normalizeLabels(labels)
+		s.events[id] = e
+	}
+	return nil
+}
+
+func (s *Storage) ListLabels(ctx context.Context, userID string) ([]storage.Label, error) {
+	defer s.rlock(ctx)()
+
+	res := make([]storage.Label, 0, len(s.labels[userID]))
+	for _, l := range s.labels[userID] {
+		res = append(res, l)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+// normalizeLabels копирует метки, чтобы вызывающий не мог поменять их в хранилище,
+// пустой список хранится как nil.
+func normalizeLabels(labels []string) []string {
+	if len(labels) == 0 {
+		return nil
+	}
+	return append([]string(nil), labels...)
+}
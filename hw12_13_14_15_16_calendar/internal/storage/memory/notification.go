@@ -0,0 +1,123 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// ListDueNotifications - уведомления по напоминаниям, которые срабатывают в [from, to),
+// в порядке срабатывания.
+func (s *Storage) ListDueNotifications(ctx context.Context, from, to time.Time) ([]storage.Notification, error) {
+	defer s.rlock(ctx)()
+
+	type due struct {
+		n  storage.Notification
+		at time.Time
+	}
+	var list []due
+	for _, e := range s.events {
+		if e.Trashed() {
+			continue
+		}
+		for _, r := range e.Triggers() {
+			if at := r.FireAt(e); !at.Before(from) && at.Before(to) {
+				list = append(list, due{n: dueNotification(e, r), at: at})
+			}
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].at.Equal(list[j].at) {
+			return list[i].at.Before(list[j].at)
+		}
+		return list[i].n.ID < list[j].n.ID
+	})
+
+	res := make([]storage.Notification, 0, len(list))
+	for _, d := range list {
+		res = append(res, d.n)
+	}
+	return res, nil
+}
+
+func dueNotification(e storage.Event, r storage.Reminder) storage.Notification {
+	return storage.Notification{
+		ID:         storage.NotificationID(e.ID, r.ID()),
+		EventID:    e.ID,
+		ReminderID: r.ID(),
+		Title:      e.Title,
+		Start:      e.Start,
+		UserID:     e.UserID,
+	}
+}
+
+// DeleteEventsBefore удаляет события, закончившиеся раньше before.
+func (s *Storage) DeleteEventsBefore(ctx context.Context, before time.Time) (int, error) {
+	defer s.lock(ctx)()
+
+	var n int
+	for id, e := range s.events {
+		if e.End.Before(before) {
+			delete(s.events, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// SaveNotification идемпотентна: повторная доставка того же уведомления его перезаписывает.
+func (s *Storage) SaveNotification(ctx context.Context, n storage.Notification) error {
+	defer s.lock(ctx)()
+
+	s.notifications[n.ID] = n
+	return nil
+}
+
+func (s *Storage) GetNotification(ctx context.Context, id string) (storage.Notification, error) {
+	defer s.rlock(ctx)()
+
+	n, ok := s.notifications[id]
+	if !ok {
+		return storage.Notification{}, storage.ErrNotificationNotFound
+	}
+	return n, nil
+}
+
+// ListSnoozedNotifications - отложенные уведомления, у которых SnoozedUntil попадает в [from, to).
+func (s *Storage) ListSnoozedNotifications(ctx context.Context, from, to time.Time) ([]storage.Notification, error) {
+	defer s.rlock(ctx)()
+
+	res := make([]storage.Notification, 0)
+	for _, n := range s.notifications {
+		if n.Status != storage.NotificationSnoozed {
+			continue
+		}
+		if !n.SnoozedUntil.Before(from) && n.SnoozedUntil.Before(to) {
+			res = append(res, n)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].SnoozedUntil.Before(res[j].SnoozedUntil)
+	})
+	return res, nil
+}
+
+func (s *Storage) ListNotifications(ctx context.Context, userID string) ([]storage.Notification, error) {
+	defer s.rlock(ctx)()
+
+	res := make([]storage.Notification, 0)
+	for _, n := range s.notifications {
+		if n.UserID == userID {
+			res = append(res, n)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Start.Equal(res[j].Start) {
+			return res[i].Start.Before(res[j].Start)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res, nil
+}
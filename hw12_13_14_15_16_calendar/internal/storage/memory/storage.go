@@ -1,14 +1,134 @@
 package memorystorage
 
-import "sync"
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
 
 type Storage struct {
-	// TODO
-	mu sync.RWMutex //nolint:unused
+	mu            sync.RWMutex
+	events        map[string]storage.Event
+	notifications map[string]storage.Notification
+	labels        map[string]map[string]storage.Label
+	calendars     map[string]storage.Calendar
+	audit         map[string][]storage.AuditEntry
+	workingHours  map[string]storage.WorkingHours
+	leases        map[string]lease
+	idempotency   map[idempotencyKey]storage.IdempotencyKey
+}
+
+type lease struct {
+	holder    string
+	expiresAt time.Time
 }
 
 func New() *Storage {
-	return &Storage{}
+	return &Storage{
+		events:        make(map[string]storage.Event),
+		notifications: make(map[string]storage.Notification),
+		labels:        make(map[string]map[string]storage.Label),
+		calendars:     make(map[string]storage.Calendar),
+		audit:         make(map[string][]storage.AuditEntry),
+		workingHours:  make(map[string]storage.WorkingHours),
+		leases:        make(map[string]lease),
+		idempotency:   make(map[idempotencyKey]storage.IdempotencyKey),
+	}
+}
+
+// Ping нужен для единообразия с sql-хранилищем в проверках готовности.
+func (s *Storage) Ping(_ context.Context) error {
+	return nil
+}
+
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.events[event.ID]; ok {
+		return storage.ErrEventExists
+	}
+	if s.busy(event) {
+		return storage.ErrDateBusy
+	}
+	s.events[event.ID] = clone(event)
+	return nil
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.events[id]; !ok {
+		return storage.ErrEventNotFound
+	}
+	event.ID = id
+	if s.busy(event) {
+		return storage.ErrDateBusy
+	}
+	s.events[id] = clone(event)
+	return nil
 }
 
-// TODO
+func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.events[id]; !ok {
+		return storage.ErrEventNotFound
+	}
+	delete(s.events, id)
+	return nil
+}
+
+func (s *Storage) GetEvent(ctx context.Context, id string) (storage.Event, error) {
+	defer s.rlock(ctx)()
+
+	event, ok := s.events[id]
+	if !ok {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	return clone(event), nil
+}
+
+// ListEvents возвращает события пользователя, пересекающиеся с [from, to), по времени начала.
+// События в корзине не возвращаются.
+func (s *Storage) ListEvents(ctx context.Context, userID string, from, to time.Time) ([]storage.Event, error) {
+	defer s.rlock(ctx)()
+
+	events := make([]storage.Event, 0)
+	for _, e := range s.events {
+		if e.UserID == userID && !e.Trashed() && e.Overlaps(from, to) {
+			events = append(events, clone(e))
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+	return events, nil
+}
+
+// busy вызывается под блокировкой, события в корзине время не занимают.
+func (s *Storage) busy(event storage.Event) bool {
+	if event.Trashed() {
+		return false
+	}
+	for _, e := range s.events {
+		if e.ID != event.ID && e.UserID == event.UserID && !e.Trashed() && e.Overlaps(event.Start, event.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// clone копирует срезы события, чтобы вызывающий не мог поменять их в хранилище,
+// пустые срезы становятся nil, как и в sql-хранилище.
+func clone(e storage.Event) storage.Event {
+	e.Labels = normalizeLabels(e.Labels)
+	if len(e.Reminders) == 0 {
+		e.Reminders = nil
+	} else {
+		e.Reminders = append([]storage.Reminder(nil), e.Reminders...)
+	}
+	return e
+}
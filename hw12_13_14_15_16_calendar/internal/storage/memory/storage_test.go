@@ -1,7 +1,14 @@
 package memorystorage
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/storagetest"
+)
 
 func TestStorage(t *testing.T) {
-	// TODO
+	storagetest.RunConformance(t, func(t *testing.T) storagetest.Storage {
+		t.Helper()
+		return New()
+	})
 }
@@ -0,0 +1,73 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// TrashEvent переносит событие в корзину, повторный перенос не меняет время удаления.
+func (s *Storage) TrashEvent(ctx context.Context, id string, at time.Time) error {
+	defer s.lock(ctx)()
+
+	e, ok := s.events[id]
+	if !ok {
+		return storage.ErrEventNotFound
+	}
+	if !e.Trashed() {
+		e.DeletedAt = at
+		s.events[id] = e
+	}
+	return nil
+}
+
+// UntrashEvent достаёт событие из корзины, если его время ещё свободно.
+func (s *Storage) UntrashEvent(ctx context.Context, id string) error {
+	defer s.lock(ctx)()
+
+	e, ok := s.events[id]
+	if !ok || !e.Trashed() {
+		return storage.ErrEventNotFound
+	}
+	e.DeletedAt = time.Time{}
+	if s.busy(e) {
+		return storage.ErrDateBusy
+	}
+	s.events[id] = e
+	return nil
+}
+
+// ListTrash - события пользователя в корзине, сначала удалённые последними.
+func (s *Storage) ListTrash(ctx context.Context, userID string) ([]storage.Event, error) {
+	defer s.rlock(ctx)()
+
+	events := make([]storage.Event, 0)
+	for _, e := range s.events {
+		if e.UserID == userID && e.Trashed() {
+			events = append(events, clone(e))
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].DeletedAt.Equal(events[j].DeletedAt) {
+			return events[i].DeletedAt.After(events[j].DeletedAt)
+		}
+		return events[i].ID < events[j].ID
+	})
+	return events, nil
+}
+
+// PurgeTrash окончательно удаляет события, попавшие в корзину раньше before.
+func (s *Storage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	defer s.lock(ctx)()
+
+	var n int
+	for id, e := range s.events {
+		if e.Trashed() && e.DeletedAt.Before(before) {
+			delete(s.events, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,103 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+type txKey struct{}
+
+// Atomic выполняет fn под эксклюзивной блокировкой хранилища: вызовы с ctx из fn
+// блокировку не берут, а при ошибке fn все изменения откатываются.
+func (s *Storage) Atomic(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.inTx(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.snapshot()
+	if err := fn(context.WithValue(ctx, txKey{}, s)); err != nil {
+		s.restore(saved)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) inTx(ctx context.Context) bool {
+	tx, _ := ctx.Value(txKey{}).(*Storage)
+	return tx == s
+}
+
+// lock берёт блокировку на запись, если её ещё не держит Atomic; результат - функция разблокировки.
+func (s *Storage) lock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *Storage) rlock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+type state struct {
+	events        map[string]storage.Event
+	notifications map[string]storage.Notification
+	labels        map[string]map[string]storage.Label
+	calendars     map[string]storage.Calendar
+	audit         map[string][]storage.AuditEntry
+	workingHours  map[string]storage.WorkingHours
+}
+
+// snapshot копирует карты хранилища. Значения в них не меняются на месте,
+// поэтому копировать сами события и записи журнала не нужно.
+func (s *Storage) snapshot() state {
+	st := state{
+		events:        make(map[string]storage.Event, len(s.events)),
+		notifications: make(map[string]storage.Notification, len(s.notifications)),
+		labels:        make(map[string]map[string]storage.Label, len(s.labels)),
+		calendars:     make(map[string]storage.Calendar, len(s.calendars)),
+		audit:         make(map[string][]storage.AuditEntry, len(s.audit)),
+		workingHours:  make(map[string]storage.WorkingHours, len(s.workingHours)),
+	}
+	for k, v := range s.events {
+		st.events[k] = v
+	}
+	for k, v := range s.notifications {
+		st.notifications[k] = v
+	}
+	for k, v := range s.calendars {
+		st.calendars[k] = v
+	}
+	for user, labels := range s.labels {
+		m := make(map[string]storage.Label, len(labels))
+		for k, v := range labels {
+			m[k] = v
+		}
+		st.labels[user] = m
+	}
+	for k, v := range s.audit {
+		st.audit[k] = v
+	}
+	for k, v := range s.workingHours {
+		st.workingHours[k] = v
+	}
+	return st
+}
+
+func (s *Storage) restore(st state) {
+	s.events = st.events
+	s.notifications = st.notifications
+	s.labels = st.labels
+	s.calendars = st.calendars
+	s.audit = st.audit
+	s.workingHours = st.workingHours
+}
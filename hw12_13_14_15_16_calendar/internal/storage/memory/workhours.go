@@ -0,0 +1,37 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+func (s *Storage) GetWorkingHours(ctx context.Context, userID string) (storage.WorkingHours, error) {
+	defer s.rlock(ctx)()
+
+	w, ok := s.workingHours[userID]
+	if !ok {
+		return storage.WorkingHours{}, storage.ErrWorkingHoursNotFound
+	}
+	w.Holidays = append([]string(nil), w.Holidays...)
+	return w, nil
+}
+
+// SaveWorkingHours создаёт или заменяет рабочее время пользователя.
+func (s *Storage) SaveWorkingHours(ctx context.Context, w storage.WorkingHours) error {
+	defer s.lock(ctx)()
+
+	w.Holidays = append([]string(nil), w.Holidays...)
+	s.workingHours[w.UserID] = w
+	return nil
+}
+
+func (s *Storage) DeleteWorkingHours(ctx context.Context, userID string) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.workingHours[userID]; !ok {
+		return storage.ErrWorkingHoursNotFound
+	}
+	delete(s.workingHours, userID)
+	return nil
+}
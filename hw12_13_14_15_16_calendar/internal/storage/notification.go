@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	// ErrInvalidTransition - уведомление нельзя перевести в запрошенное состояние.
+	ErrInvalidTransition = errors.New("invalid notification state transition")
+)
+
+// NotificationStatus - состояние уведомления:
+//
+//	pending -> sent -> acked
+//	             \-> snoozed -> pending (когда наступит SnoozedUntil)
+//
+// acked конечное, ack допустим из любого другого состояния. Ещё не отправленное
+// уведомление планировщик может сразу отложить до начала рабочего времени.
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending"
+	NotificationSent    NotificationStatus = "sent"
+	NotificationAcked   NotificationStatus = "acked"
+	NotificationSnoozed NotificationStatus = "snoozed"
+)
+
+var transitions = map[NotificationStatus][]NotificationStatus{
+	"":                  {NotificationPending, NotificationSnoozed},
+	NotificationPending: {NotificationSent, NotificationAcked},
+	NotificationSent:    {NotificationAcked, NotificationSnoozed},
+	NotificationSnoozed: {NotificationPending, NotificationAcked, NotificationSnoozed},
+}
+
+// CanTransition сообщает, допустим ли переход из s в to. Пустое s - уведомления ещё нет.
+func (s NotificationStatus) CanTransition(to NotificationStatus) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification - уведомление по напоминанию о событии, которое планировщик кладёт
+// в очередь, а хранитель сохраняет. ID - см. NotificationID.
+// SnoozedUntil задано только в состоянии snoozed.
+type Notification struct {
+	ID           string             `json:"id"`
+	EventID      string             `json:"eventId"`
+	ReminderID   string             `json:"reminderId"`
+	Title        string             `json:"title"`
+	Start        time.Time          `json:"start"`
+	UserID       string             `json:"userId"`
+	Status       NotificationStatus `json:"status,omitempty"`
+	SnoozedUntil time.Time          `json:"snoozedUntil,omitempty"`
+}
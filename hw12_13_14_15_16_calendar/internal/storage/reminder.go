@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ReminderAnchor - от какого края события отсчитывается относительное напоминание.
+type ReminderAnchor string
+
+const (
+	AnchorStart ReminderAnchor = "start"
+	AnchorEnd   ReminderAnchor = "end"
+)
+
+// Reminder - напоминание о событии: либо относительное (Anchor и Offset,
+// отрицательный Offset - раньше края), либо абсолютное (At, Anchor пустой).
+type Reminder struct {
+	Anchor ReminderAnchor
+	Offset time.Duration
+	At     time.Time
+}
+
+// ID стабилен и выводится из условия срабатывания: по нему уведомления
+// дедуплицируются, а одинаковые напоминания у события не допускаются.
+// Формат с точностью до секунды: "start-900s", "end+0s", "at-1704880800".
+func (r Reminder) ID() string {
+	if r.Anchor == "" {
+		return "at-" + strconv.FormatInt(r.At.Unix(), 10)
+	}
+	return fmt.Sprintf("%s%+ds", r.Anchor, int64(r.Offset/time.Second))
+}
+
+// FireAt - когда напоминание срабатывает для события e.
+func (r Reminder) FireAt(e Event) time.Time {
+	switch r.Anchor {
+	case AnchorStart:
+		return e.Start.Add(r.Offset)
+	case AnchorEnd:
+		return e.End.Add(r.Offset)
+	default:
+		return r.At
+	}
+}
+
+// Triggers - все напоминания события. NotifyBefore из ТЗ - сокращение
+// для напоминания относительно начала и добавляется, если такого ещё нет.
+func (e Event) Triggers() []Reminder {
+	res := make([]Reminder, 0, len(e.Reminders)+1)
+	seen := make(map[string]bool, len(e.Reminders)+1)
+	for _, r := range e.Reminders {
+		if !seen[r.ID()] {
+			seen[r.ID()] = true
+			res = append(res, r)
+		}
+	}
+	if e.NotifyBefore > 0 {
+		r := Reminder{Anchor: AnchorStart, Offset: -e.NotifyBefore}
+		if !seen[r.ID()] {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// NotificationID - ID уведомления по напоминанию reminderID события eventID.
+func NotificationID(eventID, reminderID string) string {
+	return eventID + ":" + reminderID
+}
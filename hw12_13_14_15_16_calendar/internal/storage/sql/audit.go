@@ -0,0 +1,116 @@
+package sqlstorage
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+const auditColumns = `event_id, version, user_id, actor, action, at, before, after`
+
+// AppendAudit добавляет запись в журнал события и назначает ей следующую версию.
+func (s *Storage) AppendAudit(ctx context.Context, entry storage.AuditEntry) (_ storage.AuditEntry, err error) {
+	ctx, finish := trace(ctx, "AppendAudit")
+	defer func() { finish(err) }()
+
+	before, err := marshalSnapshot(entry.Before)
+	if err != nil {
+		return storage.AuditEntry{}, err
+	}
+	after, err := marshalSnapshot(entry.After)
+	if err != nil {
+		return storage.AuditEntry{}, err
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return storage.AuditEntry{}, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// Версии одного события назначаются по очереди, иначе параллельные
+	// изменения получат одинаковый номер.
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, entry.EventID); err != nil {
+		return storage.AuditEntry{}, err
+	}
+	err = tx.QueryRowContext(ctx, `
+INSERT INTO event_audit (event_id, version, user_id, actor, action, at, before, after)
+SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5, $6, $7
+FROM event_audit WHERE event_id = $1
+RETURNING version`,
+		entry.EventID, entry.UserID, entry.Actor, string(entry.Action), entry.At, before, after,
+	).Scan(&entry.Version)
+	if err != nil {
+		return storage.AuditEntry{}, err
+	}
+	return entry, tx.Commit()
+}
+
+// ListAudit - журнал события по возрастанию версии.
+func (s *Storage) ListAudit(ctx context.Context, eventID string) (res []storage.AuditEntry, err error) {
+	ctx, finish := trace(ctx, "ListAudit")
+	defer func() { finish(err) }()
+
+	rows, err := s.conn(ctx).QueryContext(ctx, `
+SELECT `+auditColumns+`
+FROM event_audit
+WHERE event_id = $1
+ORDER BY version`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res = make([]storage.AuditEntry, 0)
+	for rows.Next() {
+		entry, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, entry)
+	}
+	return res, rows.Err()
+}
+
+func scanAudit(row scanner) (storage.AuditEntry, error) {
+	var (
+		entry         storage.AuditEntry
+		action        string
+		before, after []byte
+	)
+	err := row.Scan(&entry.EventID, &entry.Version, &entry.UserID, &entry.Actor, &action, &entry.At, &before, &after)
+	if err != nil {
+		return storage.AuditEntry{}, err
+	}
+	entry.Action = storage.AuditAction(action)
+	entry.At = entry.At.UTC()
+	if entry.Before, err = unmarshalSnapshot(before); err != nil {
+		return storage.AuditEntry{}, err
+	}
+	if entry.After, err = unmarshalSnapshot(after); err != nil {
+		return storage.AuditEntry{}, err
+	}
+	return entry, nil
+}
+
+// marshalSnapshot - nil остаётся NULL.
+func marshalSnapshot(e *storage.Event) ([]byte, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return json.Marshal(e)
+}
+
+func unmarshalSnapshot(b []byte) (*storage.Event, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var e storage.Event
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, err
+	}
+	e.Start = e.Start.UTC()
+	e.End = e.End.UTC()
+	return &e, nil
+}
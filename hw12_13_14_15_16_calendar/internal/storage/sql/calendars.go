@@ -0,0 +1,171 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/lib/pq"
+)
+
+func (s *Storage) CreateCalendar(ctx context.Context, c storage.Calendar) (err error) {
+	ctx, finish := trace(ctx, "CreateCalendar")
+	defer func() { finish(err) }()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO calendars (id, owner_id, name) VALUES ($1, $2, $3)`,
+		c.ID, c.OwnerID, c.Name)
+	if err != nil {
+		return mapError(err)
+	}
+	for userID, access := range c.Shares {
+		_, err := tx.ExecContext(ctx, `
+INSERT INTO calendar_shares (calendar_id, user_id, access) VALUES ($1, $2, $3)`, c.ID, userID, string(access))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateCalendar переименовывает календарь, права меняются через ShareCalendar.
+func (s *Storage) UpdateCalendar(ctx context.Context, c storage.Calendar) (err error) {
+	ctx, finish := trace(ctx, "UpdateCalendar")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `UPDATE calendars SET name = $2 WHERE id = $1`, c.ID, c.Name)
+	if err != nil {
+		return mapError(err)
+	}
+	return requireAffected(res, storage.ErrCalendarNotFound)
+}
+
+// DeleteCalendar удаляет календарь, его события переходят в личный календарь владельца.
+func (s *Storage) DeleteCalendar(ctx context.Context, id string) (err error) {
+	ctx, finish := trace(ctx, "DeleteCalendar")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM calendars WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, storage.ErrCalendarNotFound)
+}
+
+func (s *Storage) GetCalendar(ctx context.Context, id string) (c storage.Calendar, err error) {
+	ctx, finish := trace(ctx, "GetCalendar")
+	defer func() { finish(err) }()
+
+	calendars, err := s.queryCalendars(ctx, `SELECT id, owner_id, name FROM calendars WHERE id = $1`, id)
+	if err != nil {
+		return storage.Calendar{}, err
+	}
+	if len(calendars) == 0 {
+		return storage.Calendar{}, storage.ErrCalendarNotFound
+	}
+	return calendars[0], nil
+}
+
+// ListCalendars - календари пользователя и открытые ему, по имени.
+func (s *Storage) ListCalendars(ctx context.Context, userID string) (res []storage.Calendar, err error) {
+	ctx, finish := trace(ctx, "ListCalendars")
+	defer func() { finish(err) }()
+
+	return s.queryCalendars(ctx, `
+SELECT id, owner_id, name FROM calendars
+WHERE owner_id = $1 OR id IN (SELECT calendar_id FROM calendar_shares WHERE user_id = $1)
+ORDER BY name, id`, userID)
+}
+
+// ShareCalendar открывает календарь пользователю или меняет его права.
+func (s *Storage) ShareCalendar(ctx context.Context, calendarID, userID string, access storage.Access) (err error) {
+	ctx, finish := trace(ctx, "ShareCalendar")
+	defer func() { finish(err) }()
+
+	_, err = s.conn(ctx).ExecContext(ctx, `
+INSERT INTO calendar_shares (calendar_id, user_id, access) VALUES ($1, $2, $3)
+ON CONFLICT (calendar_id, user_id) DO UPDATE SET access = EXCLUDED.access`,
+		calendarID, userID, string(access))
+	if err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+func (s *Storage) UnshareCalendar(ctx context.Context, calendarID, userID string) (err error) {
+	ctx, finish := trace(ctx, "UnshareCalendar")
+	defer func() { finish(err) }()
+
+	if _, err := s.GetCalendar(ctx, calendarID); err != nil {
+		return err
+	}
+	res, err := s.conn(ctx).ExecContext(ctx, `
+DELETE FROM calendar_shares WHERE calendar_id = $1 AND user_id = $2`, calendarID, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, storage.ErrShareNotFound)
+}
+
+// ListCalendarEvents - события календарей, пересекающиеся с [from, to), по времени начала.
+func (s *Storage) ListCalendarEvents(
+	ctx context.Context, calendarIDs []string, from, to time.Time,
+) (events []storage.Event, err error) {
+	ctx, finish := trace(ctx, "ListCalendarEvents")
+	defer func() { finish(err) }()
+
+	return s.queryEvents(ctx, `
+SELECT `+eventColumns+` FROM events
+WHERE calendar_id = ANY($1) AND start_at < $3 AND end_at > $2 AND deleted_at IS NULL
+ORDER BY start_at`, pq.StringArray(calendarIDs), from, to)
+}
+
+// queryCalendars загружает календари вместе с правами на них.
+func (s *Storage) queryCalendars(ctx context.Context, query string, args ...interface{}) ([]storage.Calendar, error) {
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]storage.Calendar, 0)
+	byID := make(map[string]int)
+	for rows.Next() {
+		c := storage.Calendar{Shares: map[string]storage.Access{}}
+		if err := rows.Scan(&c.ID, &c.OwnerID, &c.Name); err != nil {
+			return nil, err
+		}
+		byID[c.ID] = len(res)
+		res = append(res, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	ids := make([]string, 0, len(res))
+	for _, c := range res {
+		ids = append(ids, c.ID)
+	}
+	shares, err := s.conn(ctx).QueryContext(ctx, `
+SELECT calendar_id, user_id, access FROM calendar_shares WHERE calendar_id = ANY($1)`, pq.StringArray(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer shares.Close()
+	for shares.Next() {
+		var calendarID, userID, access string
+		if err := shares.Scan(&calendarID, &userID, &access); err != nil {
+			return nil, err
+		}
+		res[byID[calendarID]].Shares[userID] = storage.Access(access)
+	}
+	return res, shares.Err()
+}
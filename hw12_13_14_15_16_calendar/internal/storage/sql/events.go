@@ -0,0 +1,315 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/lib/pq"
+)
+
+const eventColumns = `id, title, start_at, end_at, description, user_id, notify_before, category, labels, reminders,
+	deleted_at, calendar_id`
+
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (err error) {
+	ctx, finish := trace(ctx, "CreateEvent")
+	defer func() { finish(err) }()
+
+	reminders, err := marshalReminders(event.Reminders)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO events (`+eventColumns+`)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		event.ID, event.Title, event.Start, event.End, event.Description, event.UserID,
+		int64(event.NotifyBefore), event.Category, labelsArray(event.Labels), reminders, nullTime(event.DeletedAt),
+		nullString(event.CalendarID))
+	if err != nil {
+		return mapError(err)
+	}
+	if err := saveTriggers(ctx, tx, event); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) (err error) {
+	ctx, finish := trace(ctx, "UpdateEvent")
+	defer func() { finish(err) }()
+
+	reminders, err := marshalReminders(event.Reminders)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	res, err := tx.ExecContext(ctx, `
+UPDATE events
+SET title = $2, start_at = $3, end_at = $4, description = $5, user_id = $6,
+    notify_before = $7, category = $8, labels = $9, reminders = $10, deleted_at = $11, calendar_id = $12
+WHERE id = $1`,
+		id, event.Title, event.Start, event.End, event.Description, event.UserID,
+		int64(event.NotifyBefore), event.Category, labelsArray(event.Labels), reminders, nullTime(event.DeletedAt),
+		nullString(event.CalendarID))
+	if err != nil {
+		return mapError(err)
+	}
+	if err := requireAffected(res, storage.ErrEventNotFound); err != nil {
+		return err
+	}
+
+	event.ID = id
+	if err := saveTriggers(ctx, tx, event); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// saveTriggers пересчитывает время срабатывания напоминаний события.
+func saveTriggers(ctx context.Context, tx querier, event storage.Event) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM event_reminders WHERE event_id = $1`, event.ID)
+	if err != nil {
+		return err
+	}
+	for _, r := range event.Triggers() {
+		_, err := tx.ExecContext(ctx, `
+INSERT INTO event_reminders (event_id, reminder_id, fire_at) VALUES ($1, $2, $3)`,
+			event.ID, r.ID(), r.FireAt(event))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id string) (err error) {
+	ctx, finish := trace(ctx, "DeleteEvent")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM events WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, storage.ErrEventNotFound)
+}
+
+func (s *Storage) GetEvent(ctx context.Context, id string) (event storage.Event, err error) {
+	ctx, finish := trace(ctx, "GetEvent")
+	defer func() { finish(err) }()
+
+	row := s.conn(ctx).QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = $1`, id)
+	event, err = scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	return event, err
+}
+
+// ListEvents возвращает события пользователя, пересекающиеся с [from, to), по времени начала.
+// События в корзине не возвращаются.
+func (s *Storage) ListEvents(
+	ctx context.Context, userID string, from, to time.Time,
+) (events []storage.Event, err error) {
+	ctx, finish := trace(ctx, "ListEvents")
+	defer func() { finish(err) }()
+
+	return s.queryEvents(ctx, `
+SELECT `+eventColumns+` FROM events
+WHERE user_id = $1 AND start_at < $3 AND end_at > $2 AND deleted_at IS NULL
+ORDER BY start_at`, userID, from, to)
+}
+
+// ListDueNotifications - уведомления по напоминаниям, которые срабатывают в [from, to),
+// в порядке срабатывания.
+func (s *Storage) ListDueNotifications(
+	ctx context.Context, from, to time.Time,
+) (res []storage.Notification, err error) {
+	ctx, finish := trace(ctx, "ListDueNotifications")
+	defer func() { finish(err) }()
+
+	rows, err := s.conn(ctx).QueryContext(ctx, `
+SELECT e.id, r.reminder_id, e.title, e.start_at, e.user_id
+FROM event_reminders r
+JOIN events e ON e.id = r.event_id
+WHERE r.fire_at >= $1 AND r.fire_at < $2 AND e.deleted_at IS NULL
+ORDER BY r.fire_at, e.id, r.reminder_id`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res = make([]storage.Notification, 0)
+	for rows.Next() {
+		var n storage.Notification
+		if err := rows.Scan(&n.EventID, &n.ReminderID, &n.Title, &n.Start, &n.UserID); err != nil {
+			return nil, err
+		}
+		n.ID = storage.NotificationID(n.EventID, n.ReminderID)
+		n.Start = n.Start.UTC()
+		res = append(res, n)
+	}
+	return res, rows.Err()
+}
+
+// DeleteEventsBefore удаляет события, закончившиеся раньше before.
+func (s *Storage) DeleteEventsBefore(ctx context.Context, before time.Time) (n int, err error) {
+	ctx, finish := trace(ctx, "DeleteEventsBefore")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM events WHERE end_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
+
+func (s *Storage) queryEvents(ctx context.Context, query string, args ...interface{}) ([]storage.Event, error) {
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]storage.Event, 0)
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEvent(row scanner) (storage.Event, error) {
+	var (
+		e            storage.Event
+		notifyBefore int64
+		labels       pq.StringArray
+		reminders    []byte
+		deletedAt    sql.NullTime
+		calendarID   sql.NullString
+	)
+	err := row.Scan(&e.ID, &e.Title, &e.Start, &e.End, &e.Description, &e.UserID,
+		&notifyBefore, &e.Category, &labels, &reminders, &deletedAt, &calendarID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if e.Reminders, err = unmarshalReminders(reminders); err != nil {
+		return storage.Event{}, err
+	}
+	if len(labels) > 0 {
+		e.Labels = labels
+	}
+	e.Start = e.Start.UTC()
+	e.End = e.End.UTC()
+	e.NotifyBefore = time.Duration(notifyBefore)
+	e.CalendarID = calendarID.String
+	if deletedAt.Valid {
+		e.DeletedAt = deletedAt.Time.UTC()
+	}
+	return e, nil
+}
+
+// labelsArray - в колонке NOT NULL пустой список хранится как '{}'.
+func labelsArray(labels []string) pq.StringArray {
+	if labels == nil {
+		return pq.StringArray{}
+	}
+	return labels
+}
+
+// reminder - напоминание в колонке reminders, At только у абсолютных.
+type reminder struct {
+	Anchor storage.ReminderAnchor `json:"anchor,omitempty"`
+	Offset int64                  `json:"offset,omitempty"`
+	At     *time.Time             `json:"at,omitempty"`
+}
+
+func marshalReminders(reminders []storage.Reminder) ([]byte, error) {
+	res := make([]reminder, 0, len(reminders))
+	for _, r := range reminders {
+		if r.Anchor == "" {
+			at := r.At
+			res = append(res, reminder{At: &at})
+			continue
+		}
+		res = append(res, reminder{Anchor: r.Anchor, Offset: int64(r.Offset)})
+	}
+	return json.Marshal(res)
+}
+
+func unmarshalReminders(b []byte) ([]storage.Reminder, error) {
+	var list []reminder
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	res := make([]storage.Reminder, 0, len(list))
+	for _, r := range list {
+		rem := storage.Reminder{Anchor: r.Anchor, Offset: time.Duration(r.Offset)}
+		if r.At != nil {
+			rem.At = r.At.UTC()
+		}
+		res = append(res, rem)
+	}
+	return res, nil
+}
+
+// requireAffected возвращает notFound, если запрос не затронул ни одной строки.
+func requireAffected(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// mapError переводит нарушения ограничений в бизнес-ошибки хранилища.
+func mapError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		switch pqErr.Table {
+		case "labels":
+			return storage.ErrLabelExists
+		case "calendars":
+			return storage.ErrCalendarExists
+		}
+		return storage.ErrEventExists
+	case "23503": // foreign_key_violation
+		return storage.ErrCalendarNotFound
+	case "23P01": // exclusion_violation
+		return storage.ErrDateBusy
+	}
+	return err
+}
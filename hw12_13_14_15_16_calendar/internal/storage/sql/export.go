@@ -0,0 +1,96 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// Export передаёт fn всё содержимое хранилища в порядке storage.Record. Выгрузка
+// идёт в одной транзакции только для чтения и согласована на момент её начала.
+func (s *Storage) Export(ctx context.Context, fn func(storage.Record) error) (err error) {
+	ctx, finish := trace(ctx, "Export")
+	defer func() { finish(err) }()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+	ctx = context.WithValue(ctx, txKey{}, tx)
+
+	calendars, err := s.queryCalendars(ctx, `SELECT id, owner_id, name FROM calendars ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	for i := range calendars {
+		if err := fn(storage.Record{Calendar: &calendars[i]}); err != nil {
+			return err
+		}
+	}
+
+	tables := []struct {
+		query string
+		scan  func(row scanner) (storage.Record, error)
+	}{
+		{`SELECT user_id, name, color FROM labels ORDER BY user_id, name`, scanLabelRecord},
+		{`SELECT ` + workingHoursColumns + ` FROM working_hours ORDER BY user_id`, scanWorkingHoursRecord},
+		{`SELECT ` + eventColumns + ` FROM events ORDER BY id`, scanEventRecord},
+		{`SELECT ` + notificationColumns + ` FROM notifications ORDER BY id`, scanNotificationRecord},
+		{`SELECT ` + auditColumns + ` FROM event_audit ORDER BY event_id, version`, scanAuditRecord},
+	}
+	for _, t := range tables {
+		if err := s.exportRows(ctx, t.query, t.scan, fn); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Storage) exportRows(
+	ctx context.Context, query string, scan func(row scanner) (storage.Record, error), fn func(storage.Record) error,
+) error {
+	rows, err := s.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func scanLabelRecord(row scanner) (storage.Record, error) {
+	var l storage.Label
+	err := row.Scan(&l.UserID, &l.Name, &l.Color)
+	return storage.Record{Label: &l}, err
+}
+
+func scanWorkingHoursRecord(row scanner) (storage.Record, error) {
+	w, err := scanWorkingHours(row)
+	return storage.Record{WorkingHours: &w}, err
+}
+
+func scanEventRecord(row scanner) (storage.Record, error) {
+	e, err := scanEvent(row)
+	return storage.Record{Event: &e}, err
+}
+
+func scanNotificationRecord(row scanner) (storage.Record, error) {
+	n, err := scanNotification(row)
+	return storage.Record{Notification: &n}, err
+}
+
+func scanAuditRecord(row scanner) (storage.Record, error) {
+	entry, err := scanAudit(row)
+	return storage.Record{Audit: &entry}, err
+}
@@ -0,0 +1,95 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+const idempotencyColumns = `user_id, key, hash, status, content_type, body, expires_at`
+
+// Ключ занимается, только если его нет или он истёк. Время, как и у аренды, из часов БД.
+const reserveIdempotencyQuery = `
+INSERT INTO idempotency_keys (user_id, key, hash, expires_at)
+VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+ON CONFLICT (user_id, key) DO UPDATE
+SET hash = EXCLUDED.hash, status = 0, content_type = '', body = NULL, expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at <= now()
+RETURNING ` + idempotencyColumns
+
+// ReserveIdempotencyKey занимает ключ на ttl, если он свободен или истёк, и возвращает
+// новую запись с reserved. Иначе возвращает уже сохранённую запись.
+func (s *Storage) ReserveIdempotencyKey(
+	ctx context.Context, k storage.IdempotencyKey, ttl time.Duration,
+) (saved storage.IdempotencyKey, reserved bool, err error) {
+	ctx, finish := trace(ctx, "ReserveIdempotencyKey")
+	defer func() { finish(err) }()
+
+	// Между INSERT и SELECT чужой ключ может истечь и удалиться, тогда пробуем снова.
+	for attempt := 0; attempt < 2; attempt++ {
+		row := s.conn(ctx).QueryRowContext(ctx, reserveIdempotencyQuery, k.UserID, k.Key, k.Hash, ttl.Seconds())
+		saved, err = scanIdempotencyKey(row)
+		if err == nil {
+			return saved, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return storage.IdempotencyKey{}, false, err
+		}
+
+		row = s.conn(ctx).QueryRowContext(ctx,
+			`SELECT `+idempotencyColumns+` FROM idempotency_keys WHERE user_id = $1 AND key = $2`, k.UserID, k.Key)
+		saved, err = scanIdempotencyKey(row)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return saved, false, err
+		}
+	}
+	return storage.IdempotencyKey{}, false, err
+}
+
+func scanIdempotencyKey(row scanner) (storage.IdempotencyKey, error) {
+	var k storage.IdempotencyKey
+	err := row.Scan(&k.UserID, &k.Key, &k.Hash, &k.Status, &k.ContentType, &k.Body, &k.ExpiresAt)
+	return k, err
+}
+
+// CompleteIdempotencyKey сохраняет ответ в занятый ключ, срок жизни ключа не меняется.
+func (s *Storage) CompleteIdempotencyKey(ctx context.Context, k storage.IdempotencyKey) (err error) {
+	ctx, finish := trace(ctx, "CompleteIdempotencyKey")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx,
+		`UPDATE idempotency_keys SET status = $3, content_type = $4, body = $5 WHERE user_id = $1 AND key = $2`,
+		k.UserID, k.Key, k.Status, k.ContentType, k.Body)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, storage.ErrIdempotencyKeyNotFound)
+}
+
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, userID, key string) (err error) {
+	ctx, finish := trace(ctx, "DeleteIdempotencyKey")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`, userID, key)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, storage.ErrIdempotencyKeyNotFound)
+}
+
+// DeleteIdempotencyKeysBefore удаляет ключи, истёкшие к before.
+func (s *Storage) DeleteIdempotencyKeysBefore(ctx context.Context, before time.Time) (n int, err error) {
+	ctx, finish := trace(ctx, "DeleteIdempotencyKeysBefore")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
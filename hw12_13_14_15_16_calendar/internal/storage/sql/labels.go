@@ -0,0 +1,79 @@
+package sqlstorage
+
+import (
+	"context"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+func (s *Storage) CreateLabel(ctx context.Context, label storage.Label) (err error) {
+	ctx, finish := trace(ctx, "CreateLabel")
+	defer func() { finish(err) }()
+
+	_, err = s.conn(ctx).ExecContext(ctx, `INSERT INTO labels (user_id, name, color) VALUES ($1, $2, $3)`,
+		label.UserID, label.Name, label.Color)
+	return mapError(err)
+}
+
+func (s *Storage) UpdateLabel(ctx context.Context, label storage.Label) (err error) {
+	ctx, finish := trace(ctx, "UpdateLabel")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `UPDATE labels SET color = $3 WHERE user_id = $1 AND name = $2`,
+		label.UserID, label.Name, label.Color)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, storage.ErrLabelNotFound)
+}
+
+// DeleteLabel заодно снимает метку с событий пользователя.
+func (s *Storage) DeleteLabel(ctx context.Context, userID, name string) (err error) {
+	ctx, finish := trace(ctx, "DeleteLabel")
+	defer func() { finish(err) }()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM labels WHERE user_id = $1 AND name = $2`, userID, name)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(res, storage.ErrLabelNotFound); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+UPDATE events
+SET labels = array_remove(labels, $2), category = CASE WHEN category = $2 THEN '' ELSE category END
+WHERE user_id = $1 AND (category = $2 OR $2 = ANY(labels))`, userID, name)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Storage) ListLabels(ctx context.Context, userID string) (res []storage.Label, err error) {
+	ctx, finish := trace(ctx, "ListLabels")
+	defer func() { finish(err) }()
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		`SELECT user_id, name, color FROM labels WHERE user_id = $1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res = make([]storage.Label, 0)
+	for rows.Next() {
+		var l storage.Label
+		if err := rows.Scan(&l.UserID, &l.Name, &l.Color); err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,38 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+)
+
+// Аренда продлевается, только если она наша или уже истекла.
+// Время берём из часов БД, чтобы расхождение часов экземпляров не влияло на TTL.
+const acquireLeaseQuery = `
+INSERT INTO leases (name, holder, expires_at)
+VALUES ($1, $2, now() + make_interval(secs => $3))
+ON CONFLICT (name) DO UPDATE
+SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+WHERE leases.holder = EXCLUDED.holder OR leases.expires_at < now()`
+
+func (s *Storage) TryAcquire(ctx context.Context, name, holder string, ttl time.Duration) (ok bool, err error) {
+	ctx, finish := trace(ctx, "TryAcquire")
+	defer func() { finish(err) }()
+
+	res, err := s.db.ExecContext(ctx, acquireLeaseQuery, name, holder, ttl.Seconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *Storage) Release(ctx context.Context, name, holder string) (err error) {
+	ctx, finish := trace(ctx, "Release")
+	defer func() { finish(err) }()
+
+	_, err = s.db.ExecContext(ctx, `DELETE FROM leases WHERE name = $1 AND holder = $2`, name, holder)
+	return err
+}
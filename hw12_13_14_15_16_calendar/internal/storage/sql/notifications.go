@@ -0,0 +1,109 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+const notificationColumns = `id, event_id, reminder_id, title, start_at, user_id, status, snoozed_until`
+
+// SaveNotification идемпотентна: повторная доставка того же уведомления его перезаписывает.
+func (s *Storage) SaveNotification(ctx context.Context, n storage.Notification) (err error) {
+	ctx, finish := trace(ctx, "SaveNotification")
+	defer func() { finish(err) }()
+
+	_, err = s.conn(ctx).ExecContext(ctx, `
+INSERT INTO notifications (`+notificationColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (id) DO UPDATE
+SET event_id = EXCLUDED.event_id, reminder_id = EXCLUDED.reminder_id,
+    title = EXCLUDED.title, start_at = EXCLUDED.start_at, user_id = EXCLUDED.user_id,
+    status = EXCLUDED.status, snoozed_until = EXCLUDED.snoozed_until`,
+		n.ID, n.EventID, n.ReminderID, n.Title, n.Start, n.UserID, string(n.Status), nullTime(n.SnoozedUntil))
+	return err
+}
+
+func (s *Storage) GetNotification(ctx context.Context, id string) (n storage.Notification, err error) {
+	ctx, finish := trace(ctx, "GetNotification")
+	defer func() { finish(err) }()
+
+	row := s.conn(ctx).QueryRowContext(ctx, `SELECT `+notificationColumns+` FROM notifications WHERE id = $1`, id)
+	n, err = scanNotification(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Notification{}, storage.ErrNotificationNotFound
+	}
+	return n, err
+}
+
+func (s *Storage) ListNotifications(ctx context.Context, userID string) (res []storage.Notification, err error) {
+	ctx, finish := trace(ctx, "ListNotifications")
+	defer func() { finish(err) }()
+
+	return s.queryNotifications(ctx, `
+SELECT `+notificationColumns+` FROM notifications
+WHERE user_id = $1
+ORDER BY start_at, id`, userID)
+}
+
+// ListSnoozedNotifications - отложенные уведомления, у которых snoozed_until попадает в [from, to).
+func (s *Storage) ListSnoozedNotifications(
+	ctx context.Context, from, to time.Time,
+) (res []storage.Notification, err error) {
+	ctx, finish := trace(ctx, "ListSnoozedNotifications")
+	defer func() { finish(err) }()
+
+	return s.queryNotifications(ctx, `
+SELECT `+notificationColumns+` FROM notifications
+WHERE status = $1 AND snoozed_until >= $2 AND snoozed_until < $3
+ORDER BY snoozed_until`, string(storage.NotificationSnoozed), from, to)
+}
+
+func (s *Storage) queryNotifications(
+	ctx context.Context, query string, args ...interface{},
+) ([]storage.Notification, error) {
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]storage.Notification, 0)
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+	return res, rows.Err()
+}
+
+func scanNotification(row scanner) (storage.Notification, error) {
+	var (
+		n       storage.Notification
+		status  string
+		snoozed sql.NullTime
+	)
+	err := row.Scan(&n.ID, &n.EventID, &n.ReminderID, &n.Title, &n.Start, &n.UserID, &status, &snoozed)
+	if err != nil {
+		return storage.Notification{}, err
+	}
+	n.Start = n.Start.UTC()
+	n.Status = storage.NotificationStatus(status)
+	if snoozed.Valid {
+		n.SnoozedUntil = snoozed.Time.UTC()
+	}
+	return n, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// nullString - пустая строка хранится как NULL, например у событий без календаря.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
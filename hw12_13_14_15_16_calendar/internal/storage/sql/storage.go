@@ -1,20 +1,58 @@
 package sqlstorage
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"errors"
 
-type Storage struct { // TODO
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/tracing"
+	_ "github.com/lib/pq" // postgres driver
+)
+
+type Storage struct {
+	dsn string
+	db  *sql.DB
 }
 
-func New() *Storage {
-	return &Storage{}
+func New(dsn string) *Storage {
+	return &Storage{dsn: dsn}
 }
 
 func (s *Storage) Connect(ctx context.Context) error {
-	// TODO
+	db, err := sql.Open("postgres", s.dsn)
+	if err != nil {
+		return err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return err
+	}
+	s.db = db
 	return nil
 }
 
-func (s *Storage) Close(ctx context.Context) error {
-	// TODO
-	return nil
+func (s *Storage) Close(_ context.Context) error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *Storage) Ping(ctx context.Context) (err error) {
+	ctx, finish := trace(ctx, "Ping")
+	defer func() { finish(err) }()
+
+	if s.db == nil {
+		return errors.New("not connected")
+	}
+	return s.db.PingContext(ctx)
+}
+
+// trace начинает спан вызова хранилища, finish завершает его с ошибкой вызова.
+func trace(ctx context.Context, name string) (context.Context, func(error)) {
+	ctx, span := tracing.Start(ctx, "sqlstorage."+name)
+	return ctx, func(err error) {
+		span.RecordError(err)
+		span.Finish()
+	}
 }
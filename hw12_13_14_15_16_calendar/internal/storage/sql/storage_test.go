@@ -0,0 +1,28 @@
+package sqlstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// Нужна PostgreSQL с применёнными миграциями, см. storagetest.DSN.
+func TestStorage(t *testing.T) {
+	dsn := storagetest.DSN(t)
+
+	ctx := context.Background()
+	s := New(dsn)
+	require.NoError(t, s.Connect(ctx))
+	t.Cleanup(func() { s.Close(ctx) })
+
+	storagetest.RunConformance(t, func(t *testing.T) storagetest.Storage {
+		t.Helper()
+		_, err := s.db.ExecContext(ctx,
+			`TRUNCATE events, event_reminders, notifications, labels, event_audit, calendars, calendar_shares,
+				working_hours, idempotency_keys`)
+		require.NoError(t, err)
+		return s
+	})
+}
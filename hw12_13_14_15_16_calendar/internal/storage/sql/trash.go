@@ -0,0 +1,58 @@
+package sqlstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// TrashEvent переносит событие в корзину, повторный перенос не меняет время удаления.
+func (s *Storage) TrashEvent(ctx context.Context, id string, at time.Time) (err error) {
+	ctx, finish := trace(ctx, "TrashEvent")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `
+UPDATE events SET deleted_at = COALESCE(deleted_at, $2) WHERE id = $1`, id, at)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, storage.ErrEventNotFound)
+}
+
+// UntrashEvent достаёт событие из корзины, если его время ещё свободно.
+func (s *Storage) UntrashEvent(ctx context.Context, id string) (err error) {
+	ctx, finish := trace(ctx, "UntrashEvent")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `
+UPDATE events SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return mapError(err)
+	}
+	return requireAffected(res, storage.ErrEventNotFound)
+}
+
+// ListTrash - события пользователя в корзине, сначала удалённые последними.
+func (s *Storage) ListTrash(ctx context.Context, userID string) (events []storage.Event, err error) {
+	ctx, finish := trace(ctx, "ListTrash")
+	defer func() { finish(err) }()
+
+	return s.queryEvents(ctx, `
+SELECT `+eventColumns+` FROM events
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id`, userID)
+}
+
+// PurgeTrash окончательно удаляет события, попавшие в корзину раньше before.
+func (s *Storage) PurgeTrash(ctx context.Context, before time.Time) (n int, err error) {
+	ctx, finish := trace(ctx, "PurgeTrash")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM events WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
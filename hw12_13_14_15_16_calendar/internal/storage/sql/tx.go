@@ -0,0 +1,77 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// querier - общее у *sql.DB и *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// Atomic выполняет fn в одной транзакции: вызовы хранилища с ctx из fn идут через неё,
+// при ошибке fn транзакция откатывается. Вложенный Atomic использует внешнюю транзакцию.
+func (s *Storage) Atomic(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	ctx, finish := trace(ctx, "Atomic")
+	defer func() { finish(err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn - транзакция из Atomic, если вызов внутри неё, иначе пул соединений.
+func (s *Storage) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return s.db
+}
+
+// callTx - транзакция одного вызова хранилища. Внутри Atomic это внешняя транзакция,
+// и завершает её сам Atomic.
+type callTx struct {
+	querier
+	own *sql.Tx
+}
+
+func (s *Storage) begin(ctx context.Context) (*callTx, error) {
+	if outer, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return &callTx{querier: outer}, nil
+	}
+	own, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &callTx{querier: own, own: own}, nil
+}
+
+func (t *callTx) Commit() error {
+	if t.own == nil {
+		return nil
+	}
+	return t.own.Commit()
+}
+
+func (t *callTx) Rollback() error {
+	if t.own == nil {
+		return nil
+	}
+	return t.own.Rollback()
+}
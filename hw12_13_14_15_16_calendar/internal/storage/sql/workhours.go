@@ -0,0 +1,72 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/lib/pq"
+)
+
+const workingHoursColumns = `user_id, time_zone, week, holidays`
+
+func (s *Storage) GetWorkingHours(ctx context.Context, userID string) (w storage.WorkingHours, err error) {
+	ctx, finish := trace(ctx, "GetWorkingHours")
+	defer func() { finish(err) }()
+
+	row := s.conn(ctx).QueryRowContext(ctx, `SELECT `+workingHoursColumns+` FROM working_hours WHERE user_id = $1`,
+		userID)
+	w, err = scanWorkingHours(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.WorkingHours{}, storage.ErrWorkingHoursNotFound
+	}
+	return w, err
+}
+
+func scanWorkingHours(row scanner) (storage.WorkingHours, error) {
+	var (
+		w    storage.WorkingHours
+		week []byte
+	)
+	if err := row.Scan(&w.UserID, &w.TimeZone, &week, pq.Array(&w.Holidays)); err != nil {
+		return storage.WorkingHours{}, err
+	}
+	if len(w.Holidays) == 0 {
+		w.Holidays = nil
+	}
+	return w, json.Unmarshal(week, &w.Week)
+}
+
+// SaveWorkingHours создаёт или заменяет рабочее время пользователя.
+func (s *Storage) SaveWorkingHours(ctx context.Context, w storage.WorkingHours) (err error) {
+	ctx, finish := trace(ctx, "SaveWorkingHours")
+	defer func() { finish(err) }()
+
+	week, err := json.Marshal(w.Week)
+	if err != nil {
+		return err
+	}
+	holidays := w.Holidays
+	if holidays == nil {
+		holidays = []string{}
+	}
+	_, err = s.conn(ctx).ExecContext(ctx, `
+INSERT INTO working_hours (user_id, time_zone, week, holidays) VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET time_zone = EXCLUDED.time_zone, week = EXCLUDED.week, holidays = EXCLUDED.holidays`,
+		w.UserID, w.TimeZone, week, pq.Array(holidays))
+	return err
+}
+
+func (s *Storage) DeleteWorkingHours(ctx context.Context, userID string) (err error) {
+	ctx, finish := trace(ctx, "DeleteWorkingHours")
+	defer func() { finish(err) }()
+
+	res, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM working_hours WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(res, storage.ErrWorkingHoursNotFound)
+}
@@ -0,0 +1,24 @@
+package storagetest
+
+import (
+	"os"
+	"testing"
+)
+
+// DSN - тестовая PostgreSQL с применёнными миграциями из CALENDAR_TEST_DSN
+// (make sql-tests и make integration-tests-sql поднимают её в Docker). Без неё
+// тест пропускается, а с CALENDAR_TEST_SQL=required падает: так CI не может
+// тихо пропустить прогон на sql.
+func DSN(t *testing.T) string {
+	t.Helper()
+	dsn := os.Getenv("CALENDAR_TEST_DSN")
+	switch {
+	case dsn != "":
+		return dsn
+	case os.Getenv("CALENDAR_TEST_SQL") == "required":
+		t.Fatal("CALENDAR_TEST_DSN is not set, but CALENDAR_TEST_SQL=required")
+	default:
+		t.Skip("CALENDAR_TEST_DSN is not set")
+	}
+	return ""
+}
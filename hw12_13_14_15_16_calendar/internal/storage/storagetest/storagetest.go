@@ -0,0 +1,801 @@
+// Package storagetest - общий набор тестов, которому должна соответствовать
+// каждая реализация хранилища событий.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+type Storage interface {
+	CreateEvent(ctx context.Context, event storage.Event) error
+	UpdateEvent(ctx context.Context, id string, event storage.Event) error
+	DeleteEvent(ctx context.Context, id string) error
+	GetEvent(ctx context.Context, id string) (storage.Event, error)
+	ListEvents(ctx context.Context, userID string, from, to time.Time) ([]storage.Event, error)
+	ListDueNotifications(ctx context.Context, from, to time.Time) ([]storage.Notification, error)
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int, error)
+	SaveNotification(ctx context.Context, n storage.Notification) error
+	ListNotifications(ctx context.Context, userID string) ([]storage.Notification, error)
+	GetNotification(ctx context.Context, id string) (storage.Notification, error)
+	ListSnoozedNotifications(ctx context.Context, from, to time.Time) ([]storage.Notification, error)
+	CreateLabel(ctx context.Context, label storage.Label) error
+	UpdateLabel(ctx context.Context, label storage.Label) error
+	DeleteLabel(ctx context.Context, userID, name string) error
+	ListLabels(ctx context.Context, userID string) ([]storage.Label, error)
+	TrashEvent(ctx context.Context, id string, at time.Time) error
+	UntrashEvent(ctx context.Context, id string) error
+	ListTrash(ctx context.Context, userID string) ([]storage.Event, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	AppendAudit(ctx context.Context, entry storage.AuditEntry) (storage.AuditEntry, error)
+	ListAudit(ctx context.Context, eventID string) ([]storage.AuditEntry, error)
+	Atomic(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateCalendar(ctx context.Context, c storage.Calendar) error
+	UpdateCalendar(ctx context.Context, c storage.Calendar) error
+	DeleteCalendar(ctx context.Context, id string) error
+	GetCalendar(ctx context.Context, id string) (storage.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]storage.Calendar, error)
+	ShareCalendar(ctx context.Context, calendarID, userID string, access storage.Access) error
+	UnshareCalendar(ctx context.Context, calendarID, userID string) error
+	ListCalendarEvents(ctx context.Context, calendarIDs []string, from, to time.Time) ([]storage.Event, error)
+	GetWorkingHours(ctx context.Context, userID string) (storage.WorkingHours, error)
+	SaveWorkingHours(ctx context.Context, w storage.WorkingHours) error
+	DeleteWorkingHours(ctx context.Context, userID string) error
+	Export(ctx context.Context, fn func(storage.Record) error) error
+	ReserveIdempotencyKey(
+		ctx context.Context, k storage.IdempotencyKey, ttl time.Duration,
+	) (storage.IdempotencyKey, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, k storage.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, userID, key string) error
+	DeleteIdempotencyKeysBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// Factory возвращает пустое хранилище для очередного подтеста.
+type Factory func(t *testing.T) Storage
+
+var day = time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+// at - событие пользователя user-1 с startHour до startHour+hours от начала day.
+func at(id string, startHour, hours int) storage.Event {
+	return storage.Event{
+		ID:     id,
+		Title:  "event " + id,
+		Start:  day.Add(time.Duration(startHour) * time.Hour),
+		End:    day.Add(time.Duration(startHour+hours) * time.Hour),
+		UserID: "user-1",
+	}
+}
+
+func RunConformance(t *testing.T, factory Factory) {
+	t.Helper()
+
+	t.Run("crud", func(t *testing.T) { testCRUD(t, factory(t)) })
+	t.Run("list windows", func(t *testing.T) { testListWindows(t, factory(t)) })
+	t.Run("overlap", func(t *testing.T) { testOverlap(t, factory(t)) })
+	t.Run("concurrency", func(t *testing.T) { testConcurrency(t, factory(t)) })
+	t.Run("retention", func(t *testing.T) { testRetention(t, factory(t)) })
+	t.Run("notify window", func(t *testing.T) { testNotifyWindow(t, factory(t)) })
+	t.Run("reminders", func(t *testing.T) { testReminders(t, factory(t)) })
+	t.Run("notifications", func(t *testing.T) { testNotifications(t, factory(t)) })
+	t.Run("notification states", func(t *testing.T) { testNotificationStates(t, factory(t)) })
+	t.Run("labels", func(t *testing.T) { testLabels(t, factory(t)) })
+	t.Run("trash", func(t *testing.T) { testTrash(t, factory(t)) })
+	t.Run("audit", func(t *testing.T) { testAudit(t, factory(t)) })
+	t.Run("atomic", func(t *testing.T) { testAtomic(t, factory(t)) })
+	t.Run("calendars", func(t *testing.T) { testCalendars(t, factory(t)) })
+	t.Run("working hours", func(t *testing.T) { testWorkingHours(t, factory(t)) })
+	t.Run("export", func(t *testing.T) { testExport(t, factory(t)) })
+	t.Run("idempotency keys", func(t *testing.T) { testIdempotencyKeys(t, factory(t)) })
+}
+
+func ids(events []storage.Event) []string {
+	res := make([]string, 0, len(events))
+	for _, e := range events {
+		res = append(res, e.ID)
+	}
+	return res
+}
+
+func testCRUD(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	event := at("1", 10, 1)
+	event.Description = "long text"
+	event.NotifyBefore = 15 * time.Minute
+	require.NoError(t, s.CreateEvent(ctx, event))
+	require.ErrorIs(t, s.CreateEvent(ctx, at("1", 20, 1)), storage.ErrEventExists)
+
+	got, err := s.GetEvent(ctx, "1")
+	require.NoError(t, err)
+	require.Equal(t, event, got)
+
+	updated := at("1", 11, 2)
+	updated.Title = "updated"
+	require.NoError(t, s.UpdateEvent(ctx, "1", updated))
+	got, err = s.GetEvent(ctx, "1")
+	require.NoError(t, err)
+	require.Equal(t, updated, got)
+
+	require.NoError(t, s.DeleteEvent(ctx, "1"))
+	_, err = s.GetEvent(ctx, "1")
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+	require.ErrorIs(t, s.DeleteEvent(ctx, "1"), storage.ErrEventNotFound)
+	require.ErrorIs(t, s.UpdateEvent(ctx, "1", updated), storage.ErrEventNotFound)
+}
+
+func testListWindows(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, e := range []storage.Event{
+		at("previous-day", -2, 2), // заканчивается ровно в начале окна
+		at("late", 22, 3),         // переходит на следующий день
+		at("early", 1, 1),
+		at("day-after", 48, 1), // начинается ровно в конце второго окна
+	} {
+		require.NoError(t, s.CreateEvent(ctx, e))
+	}
+	other := at("other-user", 5, 1)
+	other.UserID = "user-2"
+	require.NoError(t, s.CreateEvent(ctx, other))
+
+	events, err := s.ListEvents(ctx, "user-1", day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []string{"early", "late"}, ids(events))
+
+	events, err = s.ListEvents(ctx, "user-1", day.AddDate(0, 0, 1), day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.Equal(t, []string{"late"}, ids(events))
+
+	events, err = s.ListEvents(ctx, "user-1", day.AddDate(0, 0, -1), day.AddDate(0, 0, 6))
+	require.NoError(t, err)
+	require.Equal(t, []string{"previous-day", "early", "late", "day-after"}, ids(events))
+
+	events, err = s.ListEvents(ctx, "user-3", day, day.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	require.NotNil(t, events)
+	require.Empty(t, events)
+}
+
+func testOverlap(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	require.NoError(t, s.CreateEvent(ctx, at("1", 10, 2)))
+	require.ErrorIs(t, s.CreateEvent(ctx, at("2", 11, 1)), storage.ErrDateBusy)
+	require.ErrorIs(t, s.CreateEvent(ctx, at("2", 9, 4)), storage.ErrDateBusy)
+
+	// Смежные события не пересекаются.
+	require.NoError(t, s.CreateEvent(ctx, at("2", 12, 1)))
+	require.NoError(t, s.CreateEvent(ctx, at("3", 9, 1)))
+
+	require.ErrorIs(t, s.UpdateEvent(ctx, "2", at("2", 11, 2)), storage.ErrDateBusy)
+	// Событие не мешает само себе при переносе.
+	require.NoError(t, s.UpdateEvent(ctx, "1", at("1", 10, 1)))
+	require.NoError(t, s.UpdateEvent(ctx, "2", at("2", 11, 2)))
+
+	other := at("4", 10, 1)
+	other.UserID = "user-2"
+	require.NoError(t, s.CreateEvent(ctx, other))
+}
+
+func testConcurrency(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+	const n = 20
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := strconv.Itoa(i)
+
+			// Все претендуют на одно и то же время.
+			err := s.CreateEvent(ctx, at("same-"+id, 10, 1))
+			if err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			} else if !errors.Is(err, storage.ErrDateBusy) {
+				t.Errorf("create same-%s: %v", id, err)
+			}
+
+			// А эти друг другу не мешают.
+			if err := s.CreateEvent(ctx, at("free-"+id, 24+i, 1)); err != nil {
+				t.Errorf("create free-%s: %v", id, err)
+			}
+			if _, err := s.ListEvents(ctx, "user-1", day, day.AddDate(0, 0, 7)); err != nil {
+				t.Errorf("list: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, 1, created)
+	events, err := s.ListEvents(ctx, "user-1", day, day.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Len(t, events, n+1)
+}
+
+func testRetention(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	require.NoError(t, s.CreateEvent(ctx, at("old", -48, 1)))
+	require.NoError(t, s.CreateEvent(ctx, at("boundary", -2, 2))) // заканчивается ровно в day
+	running := at("running", -1, 2)
+	running.UserID = "user-2"
+	require.NoError(t, s.CreateEvent(ctx, running))
+	require.NoError(t, s.CreateEvent(ctx, at("future", 10, 1)))
+
+	n, err := s.DeleteEventsBefore(ctx, day)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	_, err = s.GetEvent(ctx, "old")
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+	for _, id := range []string{"boundary", "running", "future"} {
+		_, err := s.GetEvent(ctx, id)
+		require.NoError(t, err, id)
+	}
+
+	n, err = s.DeleteEventsBefore(ctx, day)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func testNotifyWindow(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	withNotify := func(e storage.Event, d time.Duration) storage.Event {
+		e.NotifyBefore = d
+		return e
+	}
+	require.NoError(t, s.CreateEvent(ctx, withNotify(at("at-from", 11, 1), time.Hour)))     // 10:00
+	require.NoError(t, s.CreateEvent(ctx, withNotify(at("inside", 12, 1), 90*time.Minute))) // 10:30
+	require.NoError(t, s.CreateEvent(ctx, withNotify(at("at-to", 13, 1), 2*time.Hour)))     // 11:00
+	require.NoError(t, s.CreateEvent(ctx, at("silent", 10, 1)))
+
+	due, err := s.ListDueNotifications(ctx, day.Add(10*time.Hour), day.Add(11*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{"at-from:start-3600s", "inside:start-5400s"}, notificationIDs(due))
+	require.Equal(t, storage.Notification{
+		ID: "at-from:start-3600s", EventID: "at-from", ReminderID: "start-3600s",
+		Title: "event at-from", Start: day.Add(11 * time.Hour), UserID: "user-1",
+	}, due[0])
+}
+
+func testReminders(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	event := at("1", 12, 1) // 12:00-13:00
+	event.NotifyBefore = time.Hour
+	event.Reminders = []storage.Reminder{
+		{Anchor: storage.AnchorStart, Offset: -time.Hour}, // совпадает с NotifyBefore
+		{Anchor: storage.AnchorStart, Offset: -90 * time.Minute},
+		{Anchor: storage.AnchorEnd},
+		{At: day.Add(9 * time.Hour)},
+	}
+	require.NoError(t, s.CreateEvent(ctx, event))
+
+	got, err := s.GetEvent(ctx, "1")
+	require.NoError(t, err)
+	require.Equal(t, event, got)
+
+	due, err := s.ListDueNotifications(ctx, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"1:at-" + strconv.FormatInt(day.Add(9*time.Hour).Unix(), 10),
+		"1:start-5400s",
+		"1:start-3600s",
+		"1:end+0s",
+	}, notificationIDs(due))
+
+	// При переносе события напоминания пересчитываются, лишние пропадают.
+	event.Start = day.Add(14 * time.Hour)
+	event.End = day.Add(15 * time.Hour)
+	event.NotifyBefore = 0
+	event.Reminders = []storage.Reminder{{Anchor: storage.AnchorStart, Offset: -15 * time.Minute}}
+	require.NoError(t, s.UpdateEvent(ctx, "1", event))
+
+	due, err = s.ListDueNotifications(ctx, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []string{"1:start-900s"}, notificationIDs(due))
+
+	require.NoError(t, s.DeleteEvent(ctx, "1"))
+	due, err = s.ListDueNotifications(ctx, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Empty(t, due)
+}
+
+func notificationIDs(list []storage.Notification) []string {
+	res := make([]string, 0, len(list))
+	for _, n := range list {
+		res = append(res, n.ID)
+	}
+	return res
+}
+
+func testNotifications(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	notification := func(eventID, title string, start time.Time, userID string) storage.Notification {
+		return storage.Notification{
+			ID: eventID + ":r", EventID: eventID, ReminderID: "r", Title: title, Start: start, UserID: userID,
+		}
+	}
+	n1 := notification("1", "first", day.Add(time.Hour), "user-1")
+	n2 := notification("2", "second", day, "user-1")
+	other := notification("3", "other", day, "user-2")
+
+	for _, n := range []storage.Notification{n1, n2, n1, other} {
+		require.NoError(t, s.SaveNotification(ctx, n))
+	}
+
+	saved, err := s.ListNotifications(ctx, "user-1")
+	require.NoError(t, err)
+	require.Equal(t, []storage.Notification{n2, n1}, saved)
+
+	saved, err = s.ListNotifications(ctx, "user-3")
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	require.Empty(t, saved)
+}
+
+func testNotificationStates(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := s.GetNotification(ctx, "1:r")
+	require.ErrorIs(t, err, storage.ErrNotificationNotFound)
+
+	sent := storage.Notification{
+		ID: "1:r", EventID: "1", ReminderID: "r", Title: "sent", Start: day, UserID: "user-1",
+		Status: storage.NotificationSent,
+	}
+	early := storage.Notification{
+		ID: "2:r", EventID: "2", ReminderID: "r", Title: "early", Start: day, UserID: "user-1",
+		Status: storage.NotificationSnoozed, SnoozedUntil: day.Add(10 * time.Hour),
+	}
+	late := storage.Notification{
+		ID: "3:r", EventID: "3", ReminderID: "r", Title: "late", Start: day, UserID: "user-2",
+		Status: storage.NotificationSnoozed, SnoozedUntil: day.Add(10*time.Hour + 30*time.Minute),
+	}
+	outside := storage.Notification{
+		ID: "4:r", EventID: "4", ReminderID: "r", Title: "outside", Start: day, UserID: "user-1",
+		Status: storage.NotificationSnoozed, SnoozedUntil: day.Add(11 * time.Hour),
+	}
+	for _, n := range []storage.Notification{late, sent, outside, early} {
+		require.NoError(t, s.SaveNotification(ctx, n))
+	}
+
+	got, err := s.GetNotification(ctx, "2:r")
+	require.NoError(t, err)
+	require.Equal(t, early, got)
+
+	snoozed, err := s.ListSnoozedNotifications(ctx, day.Add(10*time.Hour), day.Add(11*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []storage.Notification{early, late}, snoozed)
+
+	// Повторно отправленное уведомление больше не считается отложенным.
+	early.Status = storage.NotificationPending
+	early.SnoozedUntil = time.Time{}
+	require.NoError(t, s.SaveNotification(ctx, early))
+	snoozed, err = s.ListSnoozedNotifications(ctx, day.Add(10*time.Hour), day.Add(11*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []storage.Notification{late}, snoozed)
+}
+
+func testLabels(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	work := storage.Label{UserID: "user-1", Name: "work", Color: "#0000ff"}
+	urgent := storage.Label{UserID: "user-1", Name: "urgent", Color: "#ff0000"}
+	require.NoError(t, s.CreateLabel(ctx, work))
+	require.NoError(t, s.CreateLabel(ctx, urgent))
+	require.ErrorIs(t, s.CreateLabel(ctx, work), storage.ErrLabelExists)
+	// Имена меток уникальны только в наборе пользователя.
+	require.NoError(t, s.CreateLabel(ctx, storage.Label{UserID: "user-2", Name: "work", Color: "#00ff00"}))
+
+	work.Color = "#000080"
+	require.NoError(t, s.UpdateLabel(ctx, work))
+	require.ErrorIs(t, s.UpdateLabel(ctx, storage.Label{UserID: "user-1", Name: "home"}), storage.ErrLabelNotFound)
+
+	labels, err := s.ListLabels(ctx, "user-1")
+	require.NoError(t, err)
+	require.Equal(t, []storage.Label{urgent, work}, labels)
+
+	event := at("1", 10, 1)
+	event.Category = "work"
+	event.Labels = []string{"work", "urgent"}
+	require.NoError(t, s.CreateEvent(ctx, event))
+	got, err := s.GetEvent(ctx, "1")
+	require.NoError(t, err)
+	require.Equal(t, event, got)
+
+	// Удалённая метка снимается с событий.
+	require.NoError(t, s.DeleteLabel(ctx, "user-1", "work"))
+	require.ErrorIs(t, s.DeleteLabel(ctx, "user-1", "work"), storage.ErrLabelNotFound)
+	got, err = s.GetEvent(ctx, "1")
+	require.NoError(t, err)
+	require.Empty(t, got.Category)
+	require.Equal(t, []string{"urgent"}, got.Labels)
+
+	require.NoError(t, s.DeleteLabel(ctx, "user-1", "urgent"))
+	got, err = s.GetEvent(ctx, "1")
+	require.NoError(t, err)
+	require.Nil(t, got.Labels)
+
+	labels, err = s.ListLabels(ctx, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, labels)
+	require.Empty(t, labels)
+}
+
+func testAudit(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	created := at("1", 10, 1)
+	created.Labels = []string{"work"}
+	created.Reminders = []storage.Reminder{{Anchor: storage.AnchorStart, Offset: -time.Hour}}
+	updated := created
+	updated.Title = "renamed"
+
+	entries := []storage.AuditEntry{
+		{EventID: "1", UserID: "user-1", Actor: "user-1", Action: storage.AuditCreate, At: day, After: &created},
+		{EventID: "2", UserID: "user-1", Actor: "user-1", Action: storage.AuditCreate, At: day, After: &created},
+		{
+			EventID: "1", UserID: "user-1", Actor: "user-2", Action: storage.AuditUpdate, At: day.Add(time.Hour),
+			Before: &created, After: &updated,
+		},
+		{
+			EventID: "1", UserID: "user-1", Actor: "user-1", Action: storage.AuditDelete, At: day.Add(2 * time.Hour),
+			Before: &updated,
+		},
+	}
+	for i := range entries {
+		saved, err := s.AppendAudit(ctx, entries[i])
+		require.NoError(t, err)
+		entries[i].Version = saved.Version
+	}
+	require.Equal(t, []int{1, 1, 2, 3}, []int{
+		entries[0].Version, entries[1].Version, entries[2].Version, entries[3].Version,
+	})
+
+	history, err := s.ListAudit(ctx, "1")
+	require.NoError(t, err)
+	require.Equal(t, []storage.AuditEntry{entries[0], entries[2], entries[3]}, history)
+
+	history, err = s.ListAudit(ctx, "3")
+	require.NoError(t, err)
+	require.NotNil(t, history)
+	require.Empty(t, history)
+}
+
+func testTrash(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	first := at("1", 10, 1)
+	first.NotifyBefore = time.Hour
+	require.NoError(t, s.CreateEvent(ctx, first))
+	require.NoError(t, s.CreateEvent(ctx, at("2", 12, 1)))
+
+	require.ErrorIs(t, s.TrashEvent(ctx, "3", day), storage.ErrEventNotFound)
+	require.NoError(t, s.TrashEvent(ctx, "1", day))
+	require.NoError(t, s.TrashEvent(ctx, "2", day.Add(time.Hour)))
+	// Повторное удаление не сдвигает время попадания в корзину.
+	require.NoError(t, s.TrashEvent(ctx, "1", day.Add(2*time.Hour)))
+
+	got, err := s.GetEvent(ctx, "1")
+	require.NoError(t, err)
+	require.True(t, got.DeletedAt.Equal(day))
+
+	events, err := s.ListEvents(ctx, "user-1", day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Empty(t, events)
+	due, err := s.ListDueNotifications(ctx, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Empty(t, due)
+
+	trash, err := s.ListTrash(ctx, "user-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"2", "1"}, ids(trash))
+
+	// Время события в корзине можно занять, тогда достать его нельзя.
+	require.NoError(t, s.CreateEvent(ctx, at("3", 10, 1)))
+	require.ErrorIs(t, s.UntrashEvent(ctx, "1"), storage.ErrDateBusy)
+	require.NoError(t, s.UntrashEvent(ctx, "2"))
+	require.ErrorIs(t, s.UntrashEvent(ctx, "2"), storage.ErrEventNotFound)
+
+	events, err = s.ListEvents(ctx, "user-1", day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []string{"3", "2"}, ids(events))
+
+	n, err := s.PurgeTrash(ctx, day.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	_, err = s.GetEvent(ctx, "1")
+	require.ErrorIs(t, err, storage.ErrEventNotFound)
+
+	trash, err = s.ListTrash(ctx, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, trash)
+	require.Empty(t, trash)
+}
+
+func testAtomic(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, s.CreateEvent(ctx, at("a", 9, 1)))
+
+	err := s.Atomic(ctx, func(ctx context.Context) error {
+		require.NoError(t, s.CreateEvent(ctx, at("b", 11, 1)))
+		moved := at("a", 13, 1)
+		require.NoError(t, s.UpdateEvent(ctx, "a", moved))
+		_, err := s.AppendAudit(ctx, storage.AuditEntry{EventID: "b", UserID: "user-1", Action: storage.AuditCreate})
+		require.NoError(t, err)
+
+		got, err := s.GetEvent(ctx, "b")
+		require.NoError(t, err)
+		require.Equal(t, "event b", got.Title)
+		return s.CreateEvent(ctx, at("c", 11, 1))
+	})
+	require.ErrorIs(t, err, storage.ErrDateBusy)
+
+	_, err = s.GetEvent(ctx, "b")
+	require.ErrorIs(t, err, storage.ErrEventNotFound, "create is rolled back")
+	got, err := s.GetEvent(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, got.Start.Equal(day.Add(9*time.Hour)), "update is rolled back")
+	history, err := s.ListAudit(ctx, "b")
+	require.NoError(t, err)
+	require.Empty(t, history)
+
+	err = s.Atomic(ctx, func(ctx context.Context) error {
+		if err := s.CreateEvent(ctx, at("b", 11, 1)); err != nil {
+			return err
+		}
+		return s.TrashEvent(ctx, "a", day)
+	})
+	require.NoError(t, err)
+	events, err := s.ListEvents(ctx, "user-1", day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "b", events[0].ID)
+}
+
+func testCalendars(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	team := storage.Calendar{ID: "team", OwnerID: "user-1", Name: "Team"}
+	require.NoError(t, s.CreateCalendar(ctx, team))
+	require.ErrorIs(t, s.CreateCalendar(ctx, team), storage.ErrCalendarExists)
+	require.ErrorIs(t, s.CreateCalendar(ctx, storage.Calendar{ID: "dup", OwnerID: "user-1", Name: "Team"}),
+		storage.ErrCalendarExists)
+	require.NoError(t, s.CreateCalendar(ctx, storage.Calendar{ID: "other", OwnerID: "user-2", Name: "Team"}))
+
+	require.NoError(t, s.ShareCalendar(ctx, "team", "user-2", storage.AccessRead))
+	require.NoError(t, s.ShareCalendar(ctx, "team", "user-2", storage.AccessWrite))
+	require.ErrorIs(t, s.ShareCalendar(ctx, "missing", "user-2", storage.AccessRead), storage.ErrCalendarNotFound)
+
+	got, err := s.GetCalendar(ctx, "team")
+	require.NoError(t, err)
+	require.Equal(t, storage.Calendar{
+		ID: "team", OwnerID: "user-1", Name: "Team", Shares: map[string]storage.Access{"user-2": storage.AccessWrite},
+	}, got)
+	require.Equal(t, storage.AccessOwner, got.Access("user-1"))
+	require.Equal(t, storage.AccessWrite, got.Access("user-2"))
+	require.Equal(t, storage.AccessNone, got.Access("user-3"))
+
+	calendars, err := s.ListCalendars(ctx, "user-2")
+	require.NoError(t, err)
+	require.Len(t, calendars, 2)
+	calendars, err = s.ListCalendars(ctx, "user-3")
+	require.NoError(t, err)
+	require.Empty(t, calendars)
+
+	team.Name = "Team A"
+	require.NoError(t, s.UpdateCalendar(ctx, team))
+	require.ErrorIs(t, s.UpdateCalendar(ctx, storage.Calendar{ID: "missing", Name: "x"}), storage.ErrCalendarNotFound)
+	got, err = s.GetCalendar(ctx, "team")
+	require.NoError(t, err)
+	require.Equal(t, "Team A", got.Name)
+
+	shared := at("shared", 9, 1)
+	shared.CalendarID = "team"
+	require.NoError(t, s.CreateEvent(ctx, shared))
+	require.NoError(t, s.CreateEvent(ctx, at("personal", 11, 1)))
+	trashed := at("trashed", 13, 1)
+	trashed.CalendarID = "team"
+	require.NoError(t, s.CreateEvent(ctx, trashed))
+	require.NoError(t, s.TrashEvent(ctx, "trashed", day))
+
+	events, err := s.ListCalendarEvents(ctx, []string{"team", "other"}, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "shared", events[0].ID)
+	require.Equal(t, "team", events[0].CalendarID)
+
+	require.NoError(t, s.UnshareCalendar(ctx, "team", "user-2"))
+	require.ErrorIs(t, s.UnshareCalendar(ctx, "team", "user-2"), storage.ErrShareNotFound)
+	require.ErrorIs(t, s.UnshareCalendar(ctx, "missing", "user-2"), storage.ErrCalendarNotFound)
+
+	require.NoError(t, s.DeleteCalendar(ctx, "team"))
+	require.ErrorIs(t, s.DeleteCalendar(ctx, "team"), storage.ErrCalendarNotFound)
+	_, err = s.GetCalendar(ctx, "team")
+	require.ErrorIs(t, err, storage.ErrCalendarNotFound)
+	e, err := s.GetEvent(ctx, "shared")
+	require.NoError(t, err)
+	require.Empty(t, e.CalendarID, "event moves to the owner's personal calendar")
+}
+
+func testWorkingHours(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := s.GetWorkingHours(ctx, "user-1")
+	require.ErrorIs(t, err, storage.ErrWorkingHoursNotFound)
+	require.ErrorIs(t, s.DeleteWorkingHours(ctx, "user-1"), storage.ErrWorkingHoursNotFound)
+
+	nineToSix := storage.WorkPeriod{Start: 9 * time.Hour, End: 18 * time.Hour}
+	w := storage.WorkingHours{UserID: "user-1", TimeZone: "Europe/Moscow"}
+	for d := time.Monday; d <= time.Friday; d++ {
+		w.Week[d] = nineToSix
+	}
+	require.NoError(t, s.SaveWorkingHours(ctx, w))
+	got, err := s.GetWorkingHours(ctx, "user-1")
+	require.NoError(t, err)
+	require.Equal(t, w, got)
+
+	// Повторное сохранение заменяет рабочее время целиком.
+	w.Week[time.Friday] = storage.WorkPeriod{Start: 9 * time.Hour, End: 15 * time.Hour}
+	w.Holidays = []string{"2024-01-01", "2024-01-02"}
+	require.NoError(t, s.SaveWorkingHours(ctx, w))
+	got, err = s.GetWorkingHours(ctx, "user-1")
+	require.NoError(t, err)
+	require.Equal(t, w, got)
+
+	_, err = s.GetWorkingHours(ctx, "user-2")
+	require.ErrorIs(t, err, storage.ErrWorkingHoursNotFound)
+
+	require.NoError(t, s.DeleteWorkingHours(ctx, "user-1"))
+	_, err = s.GetWorkingHours(ctx, "user-1")
+	require.ErrorIs(t, err, storage.ErrWorkingHoursNotFound)
+}
+
+func testExport(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	c := storage.Calendar{ID: "cal-1", OwnerID: "user-1", Name: "team", Shares: map[string]storage.Access{
+		"user-2": storage.AccessRead,
+	}}
+	require.NoError(t, s.CreateCalendar(ctx, c))
+	labels := []storage.Label{
+		{UserID: "user-1", Name: "work", Color: "#ff0000"},
+		{UserID: "user-2", Name: "home", Color: "#00ff00"},
+	}
+	for _, l := range labels {
+		require.NoError(t, s.CreateLabel(ctx, l))
+	}
+	w := storage.WorkingHours{UserID: "user-1", TimeZone: "UTC"}
+	w.Week[time.Monday] = storage.WorkPeriod{Start: 9 * time.Hour, End: 18 * time.Hour}
+	require.NoError(t, s.SaveWorkingHours(ctx, w))
+
+	shared := at("1", 10, 1)
+	shared.CalendarID = "cal-1"
+	shared.Labels = []string{"work"}
+	trashed := at("2", 10, 1)
+	trashed.DeletedAt = day
+	for _, e := range []storage.Event{shared, trashed} {
+		require.NoError(t, s.CreateEvent(ctx, e))
+	}
+	n := storage.Notification{
+		ID: "1:start-0s", EventID: "1", ReminderID: "start-0s", Title: "event 1", Start: shared.Start,
+		UserID: "user-1", Status: storage.NotificationSent,
+	}
+	require.NoError(t, s.SaveNotification(ctx, n))
+	entry, err := s.AppendAudit(ctx, storage.AuditEntry{
+		EventID: "1", UserID: "user-1", Actor: "user-1", Action: storage.AuditCreate, At: day, After: &shared,
+	})
+	require.NoError(t, err)
+
+	var got []storage.Record
+	require.NoError(t, s.Export(ctx, func(r storage.Record) error {
+		got = append(got, r)
+		return nil
+	}))
+	require.Equal(t, []storage.Record{
+		{Calendar: &c},
+		{Label: &labels[0]},
+		{Label: &labels[1]},
+		{WorkingHours: &w},
+		{Event: &shared},
+		{Event: &trashed},
+		{Notification: &n},
+		{Audit: &entry},
+	}, got)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = s.Export(ctx, func(storage.Record) error {
+		calls++
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 1, calls)
+}
+
+func testIdempotencyKeys(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	k := storage.IdempotencyKey{UserID: "user-1", Key: "retry-1", Hash: "h1"}
+	saved, reserved, err := s.ReserveIdempotencyKey(ctx, k, time.Hour)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.True(t, saved.Pending())
+	require.WithinDuration(t, time.Now().Add(time.Hour), saved.ExpiresAt, time.Minute)
+
+	// Пока ключ занят, повтор получает ту же запись, а не занимает его снова.
+	other := storage.IdempotencyKey{UserID: "user-1", Key: "retry-1", Hash: "h2"}
+	saved, reserved, err = s.ReserveIdempotencyKey(ctx, other, time.Hour)
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.True(t, saved.Pending())
+	require.Equal(t, "h1", saved.Hash)
+
+	k.Status, k.ContentType, k.Body = 201, "application/json", []byte(`{"id":"1"}`)
+	require.NoError(t, s.CompleteIdempotencyKey(ctx, k))
+	saved, reserved, err = s.ReserveIdempotencyKey(ctx, k, time.Hour)
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.Equal(t, k.Status, saved.Status)
+	require.Equal(t, k.ContentType, saved.ContentType)
+	require.Equal(t, k.Body, saved.Body)
+
+	// Ключи у каждого пользователя свои.
+	other = storage.IdempotencyKey{UserID: "user-2", Key: "retry-1", Hash: "h1"}
+	_, reserved, err = s.ReserveIdempotencyKey(ctx, other, time.Hour)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// Истёкший ключ занимается заново.
+	expired := storage.IdempotencyKey{UserID: "user-1", Key: "retry-2", Hash: "h1"}
+	_, reserved, err = s.ReserveIdempotencyKey(ctx, expired, -time.Second)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	expired.Hash = "h2"
+	saved, reserved, err = s.ReserveIdempotencyKey(ctx, expired, -time.Second)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.Equal(t, "h2", saved.Hash)
+
+	n, err := s.DeleteIdempotencyKeysBefore(ctx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	require.NoError(t, s.DeleteIdempotencyKey(ctx, "user-1", "retry-1"))
+	require.ErrorIs(t, s.DeleteIdempotencyKey(ctx, "user-1", "retry-1"), storage.ErrIdempotencyKeyNotFound)
+	require.ErrorIs(t, s.CompleteIdempotencyKey(ctx, k), storage.ErrIdempotencyKeyNotFound)
+}
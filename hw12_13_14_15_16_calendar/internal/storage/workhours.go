@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrWorkingHoursNotFound = errors.New("working hours not found")
+
+// HolidayLayout - формат дат в WorkingHours.Holidays.
+const HolidayLayout = "2006-01-02"
+
+// maxSearchDays - дальше следующий рабочий период не ищем: год и неделя покрывают
+// любой набор праздников при хотя бы одном рабочем дне недели.
+const maxSearchDays = 366 + 7
+
+// WorkingHours - рабочее время пользователя в часовом поясе TimeZone (имя IANA).
+// Week индексируется time.Weekday, день с пустым периодом - выходной.
+// Holidays - нерабочие даты вида "2006-01-02".
+type WorkingHours struct {
+	UserID   string
+	TimeZone string
+	Week     [7]WorkPeriod
+	Holidays []string
+}
+
+// WorkPeriod - рабочий период дня, Start и End отсчитываются от полуночи, End не больше суток.
+type WorkPeriod struct {
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+func (p WorkPeriod) Off() bool {
+	return p.End <= p.Start
+}
+
+// Interval - промежуток времени [Start, End).
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Location - часовой пояс рабочего времени, неизвестный считается UTC.
+func (w WorkingHours) Location() *time.Location {
+	loc, err := time.LoadLocation(w.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// period - рабочий период дня day (полночь в Location), ok = false для выходных и праздников.
+func (w WorkingHours) period(day time.Time) (Interval, bool) {
+	p := w.Week[day.Weekday()]
+	if p.Off() {
+		return Interval{}, false
+	}
+	date := day.Format(HolidayLayout)
+	for _, h := range w.Holidays {
+		if h == date {
+			return Interval{}, false
+		}
+	}
+	// Через time.Date, чтобы в дни перехода на летнее время 9:00 оставалось 9:00.
+	y, m, d := day.Date()
+	at := func(offset time.Duration) time.Time {
+		return time.Date(y, m, d, 0, 0, int(offset/time.Second), 0, day.Location())
+	}
+	return Interval{Start: at(p.Start), End: at(p.End)}, true
+}
+
+// Periods - рабочие периоды, пересекающиеся с [from, to), обрезанные по его границам.
+func (w WorkingHours) Periods(from, to time.Time) []Interval {
+	loc := w.Location()
+	var res []Interval
+	for day := midnight(from.In(loc)); day.Before(to); day = day.AddDate(0, 0, 1) {
+		p, ok := w.period(day)
+		if !ok || !p.End.After(from) || !p.Start.Before(to) {
+			continue
+		}
+		if p.Start.Before(from) {
+			p.Start = from
+		}
+		if p.End.After(to) {
+			p.End = to
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+// Next - t, если это рабочее время, иначе начало ближайшего рабочего периода.
+// Нулевое время, если рабочих дней нет.
+func (w WorkingHours) Next(t time.Time) time.Time {
+	loc := w.Location()
+	day := midnight(t.In(loc))
+	for i := 0; i < maxSearchDays; i++ {
+		if p, ok := w.period(day); ok && p.End.After(t) {
+			if p.Start.After(t) {
+				return p.Start
+			}
+			return t
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkingHours(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, moscow)
+	}
+
+	// Пн-Пт 9-18, пятница до 15, 12 января (пятница) - праздник.
+	w := WorkingHours{UserID: "u", TimeZone: "Europe/Moscow", Holidays: []string{"2024-01-12"}}
+	for d := time.Monday; d <= time.Friday; d++ {
+		w.Week[d] = WorkPeriod{Start: 9 * time.Hour, End: 18 * time.Hour}
+	}
+	w.Week[time.Friday] = WorkPeriod{Start: 9 * time.Hour, End: 15 * time.Hour}
+
+	t.Run("periods", func(t *testing.T) {
+		// С обеда среды 10 января до утра вторника 16-го.
+		periods := w.Periods(at(10, 13, 0).UTC(), at(16, 10, 0).UTC())
+		require.Equal(t, utc([]Interval{
+			{Start: at(10, 13, 0), End: at(10, 18, 0)},
+			{Start: at(11, 9, 0), End: at(11, 18, 0)},
+			{Start: at(15, 9, 0), End: at(15, 18, 0)},
+			{Start: at(16, 9, 0), End: at(16, 10, 0)},
+		}), utc(periods))
+	})
+
+	t.Run("next", func(t *testing.T) {
+		require.True(t, at(10, 13, 0).Equal(w.Next(at(10, 13, 0))), "inside working hours")
+		require.True(t, at(11, 9, 0).Equal(w.Next(at(10, 18, 0))), "after work")
+		require.True(t, at(10, 9, 0).Equal(w.Next(at(10, 3, 0))), "before work")
+		require.True(t, at(15, 9, 0).Equal(w.Next(at(11, 20, 0))), "holiday and weekend")
+	})
+
+	t.Run("no working days", func(t *testing.T) {
+		require.True(t, WorkingHours{}.Next(at(10, 13, 0)).IsZero())
+		require.Empty(t, WorkingHours{}.Periods(at(10, 0, 0), at(17, 0, 0)))
+	})
+}
+
+func utc(periods []Interval) []Interval {
+	for i := range periods {
+		periods[i].Start = periods[i].Start.UTC()
+		periods[i].End = periods[i].End.UTC()
+	}
+	return periods
+}
@@ -0,0 +1,79 @@
+package storer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// ChatBotNotifier шлёт уведомление сообщением в чат через HTTP API бота
+// в формате Telegram Bot API: POST {url}/bot{token}/sendMessage.
+// chats - ID пользователя -> ID его чата с ботом.
+type ChatBotNotifier struct {
+	url    string
+	token  string
+	chats  map[string]string
+	client *http.Client
+}
+
+func NewChatBotNotifier(url, token string, chats map[string]string, client *http.Client) *ChatBotNotifier {
+	return &ChatBotNotifier{url: strings.TrimRight(url, "/"), token: token, chats: chats, client: client}
+}
+
+// SendMessage - тело sendMessage, имена полей задаёт Bot API.
+type SendMessage struct {
+	ChatID string `json:"chat_id"` //nolint:tagliatelle
+	Text   string `json:"text"`
+}
+
+// botResponse - ответ Bot API: при ok=false причина в description.
+type botResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+func (c *ChatBotNotifier) Notify(ctx context.Context, n storage.Notification) error {
+	chatID, ok := c.chats[n.UserID]
+	if !ok {
+		return fmt.Errorf("chatbot: no chat for user %s", n.UserID)
+	}
+	body, err := json.Marshal(SendMessage{ChatID: chatID, Text: MessageText(n)})
+	if err != nil {
+		return err
+	}
+	resp, err := post(ctx, c.client, c.url+"/bot"+c.token+"/sendMessage", body)
+	if err != nil {
+		return fmt.Errorf("chatbot: send message: %w", hideURL(err))
+	}
+	defer resp.Body.Close()
+
+	var res botResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&res); err != nil {
+		return fmt.Errorf("chatbot: unexpected response %s", resp.Status)
+	}
+	if !res.OK {
+		return fmt.Errorf("chatbot: %s: %s", resp.Status, res.Description)
+	}
+	return nil
+}
+
+// MessageText - текст напоминания для чата.
+func MessageText(n storage.Notification) string {
+	return "Reminder: " + n.Title + " at " + n.Start.UTC().Format("2006-01-02 15:04 MST")
+}
+
+// hideURL убирает из ошибки клиента URL: в нём токен бота, а ошибки попадают в лог.
+func hideURL(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return uerr.Err
+	}
+	return err
+}
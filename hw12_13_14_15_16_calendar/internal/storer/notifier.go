@@ -0,0 +1,46 @@
+package storer
+
+import (
+	"context"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// Notifier доставляет уведомление пользователю по одному каналу. Новый канал -
+// новая реализация, цикл хранителя о каналах не знает. При ошибке уведомление
+// остаётся pending.
+type Notifier interface {
+	Notify(ctx context.Context, n storage.Notification) error
+}
+
+// LogNotifier только пишет уведомление в лог, канал по умолчанию.
+type LogNotifier struct {
+	logger Logger
+}
+
+func NewLogNotifier(logger Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (l *LogNotifier) Notify(_ context.Context, n storage.Notification) error {
+	l.logger.Info("storer: notification " + n.ID + " for " + n.UserID + ": " + n.Title)
+	return nil
+}
+
+// Router выбирает канал по получателю: users - ID пользователя -> канал,
+// остальным уведомления идут в fallback.
+type Router struct {
+	fallback Notifier
+	users    map[string]Notifier
+}
+
+func NewRouter(fallback Notifier, users map[string]Notifier) *Router {
+	return &Router{fallback: fallback, users: users}
+}
+
+func (r *Router) Notify(ctx context.Context, n storage.Notification) error {
+	if notifier, ok := r.users[n.UserID]; ok {
+		return notifier.Notify(ctx, n)
+	}
+	return r.fallback.Notify(ctx, n)
+}
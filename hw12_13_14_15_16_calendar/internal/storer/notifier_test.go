@@ -0,0 +1,102 @@
+package storer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+var notification = storage.Notification{
+	ID: "1:r", EventID: "1", ReminderID: "r", Title: "standup", UserID: "u",
+	Start: time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC),
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got storage.Notification
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	w := NewWebhookNotifier(srv.URL+"/hook", srv.Client())
+	require.NoError(t, w.Notify(context.Background(), notification))
+	require.Equal(t, notification, got)
+
+	status = http.StatusBadGateway
+	require.EqualError(t, w.Notify(context.Background(), notification), "webhook: unexpected status 502 Bad Gateway")
+}
+
+func TestChatBotNotifier(t *testing.T) {
+	var got SendMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botsecret/sendMessage" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"ok":false,"description":"Not Found"}`))
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if got.ChatID == "blocked" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"ok":false,"description":"Forbidden: bot was blocked by the user"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer srv.Close()
+
+	chats := map[string]string{"u": "42", "v": "blocked"}
+	bot := NewChatBotNotifier(srv.URL+"/", "secret", chats, srv.Client())
+	require.NoError(t, bot.Notify(context.Background(), notification))
+	require.Equal(t, SendMessage{ChatID: "42", Text: "Reminder: standup at 2024-01-10 10:00 UTC"}, got)
+
+	blocked := notification
+	blocked.UserID = "v"
+	require.EqualError(t, bot.Notify(context.Background(), blocked),
+		"chatbot: 403 Forbidden: Forbidden: bot was blocked by the user")
+
+	unknown := notification
+	unknown.UserID = "w"
+	require.EqualError(t, bot.Notify(context.Background(), unknown), "chatbot: no chat for user w")
+
+	t.Run("token is not logged", func(t *testing.T) {
+		srv.Close()
+		err := bot.Notify(context.Background(), notification)
+		require.Error(t, err)
+		require.False(t, strings.Contains(err.Error(), "secret"), err)
+	})
+}
+
+type notifierFunc func(ctx context.Context, n storage.Notification) error
+
+func (f notifierFunc) Notify(ctx context.Context, n storage.Notification) error {
+	return f(ctx, n)
+}
+
+func TestRouter(t *testing.T) {
+	var to []string
+	channel := func(name string) Notifier {
+		return notifierFunc(func(_ context.Context, n storage.Notification) error {
+			to = append(to, name+":"+n.UserID)
+			return nil
+		})
+	}
+	r := NewRouter(channel("log"), map[string]Notifier{"u": channel("chatbot")})
+
+	for _, user := range []string{"u", "v"} {
+		n := notification
+		n.UserID = user
+		require.NoError(t, r.Notify(context.Background(), n))
+	}
+	require.Equal(t, []string{"chatbot:u", "log:v"}, to)
+}
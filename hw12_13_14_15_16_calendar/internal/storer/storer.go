@@ -0,0 +1,109 @@
+package storer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/tracing"
+)
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
+
+type Storage interface {
+	SaveNotification(ctx context.Context, n storage.Notification) error
+	GetNotification(ctx context.Context, id string) (storage.Notification, error)
+}
+
+// Storer читает уведомления из топика, сохраняет их в хранилище и доставляет через notifier.
+// Он же ведёт состояние уведомления: pending на время доставки, затем sent.
+type Storer struct {
+	logger   Logger
+	storage  Storage
+	consumer broker.Consumer
+	topic    string
+	notifier Notifier
+}
+
+// New - хранитель, nil notifier - уведомления только пишутся в лог.
+func New(logger Logger, storage Storage, consumer broker.Consumer, topic string, notifier Notifier) *Storer {
+	if notifier == nil {
+		notifier = NewLogNotifier(logger)
+	}
+	return &Storer{
+		logger:   logger,
+		storage:  storage,
+		consumer: consumer,
+		topic:    topic,
+		notifier: notifier,
+	}
+}
+
+func (s *Storer) Run(ctx context.Context) error {
+	return s.consumer.Consume(ctx, s.topic, s.handle)
+}
+
+func (s *Storer) handle(ctx context.Context, msg broker.Message) (err error) {
+	ctx = tracing.Extract(ctx, tracing.MapCarrier(msg.Headers))
+	ctx, span := tracing.Start(ctx, "storer.handle")
+	defer func() {
+		span.RecordError(err)
+		span.Finish()
+	}()
+
+	var n storage.Notification
+	if err := json.Unmarshal(msg.Value, &n); err != nil {
+		// Битое сообщение повторная доставка не исправит.
+		s.logger.Error("storer: bad message " + msg.Key + ": " + err.Error())
+		return nil
+	}
+
+	if n.ID == "" {
+		// Сообщение из очереди, поставленное до появления напоминаний.
+		n.ID = n.EventID
+	}
+
+	prev, err := s.storage.GetNotification(ctx, n.ID)
+	switch {
+	case errors.Is(err, storage.ErrNotificationNotFound):
+	case err != nil:
+		s.logger.Error("storer: get notification " + n.ID + ": " + err.Error())
+		return err
+	case prev.Status == storage.NotificationPending:
+		// Повторная доставка после ошибки или падения хранителя.
+	case prev.Status == storage.NotificationSnoozed && n.Status == storage.NotificationSnoozed &&
+		n.SnoozedUntil.Equal(prev.SnoozedUntil):
+		// Планировщик снова отправил отложенное уведомление, время которого пришло.
+	default:
+		// Дубль уже доставленного, подтверждённое или отложенное, но не этим сообщением.
+		s.logger.Info("storer: skip " + string(prev.Status) + " notification " + n.ID)
+		return nil
+	}
+
+	// Если доставка не удалась, уведомление остаётся pending, а брокер
+	// доставит сообщение повторно.
+	n.SnoozedUntil = time.Time{}
+	if err := s.save(ctx, n, storage.NotificationPending); err != nil {
+		return err
+	}
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		s.logger.Error("storer: deliver notification " + n.ID + ": " + err.Error())
+		return err
+	}
+	return s.save(ctx, n, storage.NotificationSent)
+}
+
+func (s *Storer) save(ctx context.Context, n storage.Notification, status storage.NotificationStatus) error {
+	n.Status = status
+	if err := s.storage.SaveNotification(ctx, n); err != nil {
+		s.logger.Error("storer: save notification " + n.ID + ": " + err.Error())
+		return err
+	}
+	return nil
+}
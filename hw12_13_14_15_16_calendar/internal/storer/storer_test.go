@@ -0,0 +1,161 @@
+package storer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+	memorybroker "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker/memory"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorer(t *testing.T) {
+	b := memorybroker.New()
+	st := memorystorage.New()
+	s := New(logger.Discard(), st, b, "notifications", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	n := storage.Notification{
+		ID: "1:r", EventID: "1", ReminderID: "r", Title: "t", UserID: "u",
+		Start: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+	}
+	value, err := json.Marshal(n)
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish(ctx, "notifications", broker.Message{Key: "bad", Value: []byte("{")}))
+	require.NoError(t, b.Publish(ctx, "notifications", broker.Message{Key: "1", Value: value}))
+	require.NoError(t, b.Publish(ctx, "notifications", broker.Message{Key: "1", Value: value}))
+
+	sent := n
+	sent.Status = storage.NotificationSent
+	require.Eventually(t, func() bool {
+		saved, err := st.ListNotifications(ctx, "u")
+		return err == nil && len(saved) == 1 && saved[0] == sent
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStorerStates(t *testing.T) {
+	ctx := context.Background()
+	st := memorystorage.New()
+	s := New(logger.Discard(), st, nil, "notifications", nil)
+
+	start := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	n := storage.Notification{ID: "1:r", EventID: "1", ReminderID: "r", Title: "t", Start: start, UserID: "u"}
+
+	// Отложенное уведомление после повторной отправки снова sent.
+	snoozed := n
+	snoozed.Status = storage.NotificationSnoozed
+	snoozed.SnoozedUntil = start
+	require.NoError(t, st.SaveNotification(ctx, snoozed))
+	value, err := json.Marshal(snoozed)
+	require.NoError(t, err)
+	require.NoError(t, s.handle(ctx, broker.Message{Key: "1", Value: value}))
+
+	got, err := st.GetNotification(ctx, "1:r")
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationSent, got.Status)
+	require.True(t, got.SnoozedUntil.IsZero())
+
+	// Подтверждённое повторно не доставляется.
+	got.Status = storage.NotificationAcked
+	require.NoError(t, st.SaveNotification(ctx, got))
+	require.NoError(t, s.handle(ctx, broker.Message{Key: "1", Value: value}))
+
+	got, err = st.GetNotification(ctx, "1:r")
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationAcked, got.Status)
+}
+
+func TestStorerDeliveryFailed(t *testing.T) {
+	ctx := context.Background()
+	st := memorystorage.New()
+	failed := errors.New("unavailable")
+	s := New(logger.Discard(), st, nil, "notifications", notifierFunc(func(context.Context, storage.Notification) error {
+		return failed
+	}))
+
+	value, err := json.Marshal(notification)
+	require.NoError(t, err)
+	require.ErrorIs(t, s.handle(ctx, broker.Message{Key: "1", Value: value}), failed)
+
+	got, err := st.GetNotification(ctx, notification.ID)
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationPending, got.Status)
+}
+
+func TestStorerRedelivery(t *testing.T) {
+	b := memorybroker.New()
+	st := memorystorage.New()
+	calls := 0
+	s := New(logger.Discard(), st, b, "notifications", notifierFunc(func(context.Context, storage.Notification) error {
+		calls++
+		if calls == 1 {
+			return errors.New("unavailable")
+		}
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	value, err := json.Marshal(notification)
+	require.NoError(t, err)
+	require.NoError(t, b.Publish(ctx, "notifications", broker.Message{Key: "1", Value: value}))
+
+	// Первая повторная доставка - через broker.Backoff(1).
+	require.Eventually(t, func() bool {
+		got, err := st.GetNotification(ctx, notification.ID)
+		return err == nil && got.Status == storage.NotificationSent
+	}, 3*time.Second, 20*time.Millisecond)
+}
+
+func TestStorerDuplicates(t *testing.T) {
+	ctx := context.Background()
+	st := memorystorage.New()
+	var delivered []storage.Notification
+	notify := func(_ context.Context, n storage.Notification) error {
+		delivered = append(delivered, n)
+		return nil
+	}
+	s := New(logger.Discard(), st, nil, "notifications", notifierFunc(notify))
+
+	value, err := json.Marshal(notification)
+	require.NoError(t, err)
+	msg := broker.Message{Key: notification.ID, Value: value}
+
+	// Дубль и повторная доставка уже отправленного сообщения - без второго уведомления.
+	require.NoError(t, s.handle(ctx, msg))
+	require.NoError(t, s.handle(ctx, msg))
+	require.Len(t, delivered, 1)
+
+	// Пользователь отложил уведомление: запоздавший дубль исходного сообщения его не будит.
+	snoozed, err := st.GetNotification(ctx, notification.ID)
+	require.NoError(t, err)
+	snoozed.Status = storage.NotificationSnoozed
+	snoozed.SnoozedUntil = notification.Start.Add(-time.Minute)
+	require.NoError(t, st.SaveNotification(ctx, snoozed))
+	require.NoError(t, s.handle(ctx, msg))
+	require.Len(t, delivered, 1)
+
+	// Планировщик отправил его снова, когда время пришло, - доставляется один раз.
+	value, err = json.Marshal(snoozed)
+	require.NoError(t, err)
+	resent := broker.Message{Key: notification.ID, Value: value}
+	require.NoError(t, s.handle(ctx, resent))
+	require.NoError(t, s.handle(ctx, resent))
+	require.Len(t, delivered, 2)
+
+	got, err := st.GetNotification(ctx, notification.ID)
+	require.NoError(t, err)
+	require.Equal(t, storage.NotificationSent, got.Status)
+}
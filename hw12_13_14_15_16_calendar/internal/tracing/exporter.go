@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+)
+
+// JSONExporter пишет каждый спан отдельной JSON-строкой в формате,
+// близком к OTLP/JSON, в stdout или файл - коллектор для просмотра не нужен.
+type JSONExporter struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	service string
+}
+
+func NewJSONExporter(w io.Writer, service string) *JSONExporter {
+	return &JSONExporter{enc: json.NewEncoder(w), service: service}
+}
+
+type jsonAttribute struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+type jsonStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type jsonSpan struct {
+	Service      string          `json:"serviceName"`
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitempty"`
+	Name         string          `json:"name"`
+	Start        int64           `json:"startTimeUnixNano,string"`
+	End          int64           `json:"endTimeUnixNano,string"`
+	Attributes   []jsonAttribute `json:"attributes,omitempty"`
+	Status       jsonStatus      `json:"status"`
+}
+
+func (e *JSONExporter) Export(span *Span) {
+	span.mu.Lock()
+	js := jsonSpan{
+		Service: e.service,
+		TraceID: span.Context.TraceID.String(),
+		SpanID:  span.Context.SpanID.String(),
+		Name:    span.Name,
+		Start:   span.Start.UnixNano(),
+		End:     span.End.UnixNano(),
+		Status:  jsonStatus{Code: "STATUS_CODE_OK"},
+	}
+	if span.Parent.IsValid() {
+		js.ParentSpanID = span.Parent.String()
+	}
+	keys := make([]string, 0, len(span.Attributes))
+	for k := range span.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		js.Attributes = append(js.Attributes, jsonAttribute{Key: k, Value: span.Attributes[k]})
+	}
+	if span.Err != nil {
+		js.Status = jsonStatus{Code: "STATUS_CODE_ERROR", Message: span.Err.Error()}
+	}
+	span.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.enc.Encode(js)
+}
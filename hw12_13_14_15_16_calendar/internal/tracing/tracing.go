@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext - то, что передаётся между процессами в traceparent.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Exporter получает завершённые спаны.
+type Exporter interface {
+	Export(span *Span)
+}
+
+// Span - операция внутри трейса, совместима по полям с OTLP.
+type Span struct {
+	Name       string
+	Context    SpanContext
+	Parent     SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+	Err        error
+
+	mu       sync.Mutex
+	exporter Exporter
+	ended    bool
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Err = err
+}
+
+// Finish завершает спан и отдаёт его экспортеру, повторный вызов ничего не делает.
+func (s *Span) Finish() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	if s.exporter != nil && s.Context.Sampled {
+		s.exporter.Export(s)
+	}
+}
+
+type ctxKey int
+
+const (
+	spanKey ctxKey = iota
+	remoteKey
+)
+
+var (
+	mu       sync.RWMutex
+	exporter Exporter
+)
+
+// SetExporter задаёт глобальный экспортер, nil - спаны никуда не пишутся,
+// но контекст трейса всё равно передаётся дальше.
+func SetExporter(e Exporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	exporter = e
+}
+
+// Start начинает дочерний спан текущего (или пришедшего извне) спана,
+// либо новый трейс, если родителя нет.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	mu.RLock()
+	e := exporter
+	mu.RUnlock()
+
+	parent := SpanContextFrom(ctx)
+	span := &Span{
+		Name:       name,
+		Start:      time.Now(),
+		Attributes: make(map[string]interface{}),
+		exporter:   e,
+	}
+	if parent.IsValid() {
+		span.Context = SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+		span.Parent = parent.SpanID
+	} else {
+		rand.Read(span.Context.TraceID[:])
+		span.Context.Sampled = true
+	}
+	rand.Read(span.Context.SpanID[:])
+
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// SpanContextFrom возвращает контекст текущего спана или пришедший из traceparent.
+func SpanContextFrom(ctx context.Context) SpanContext {
+	if span, ok := ctx.Value(spanKey).(*Span); ok {
+		return span.Context
+	}
+	sc, _ := ctx.Value(remoteKey).(SpanContext)
+	return sc
+}
+
+// WithRemote кладёт в контекст родителя, пришедшего из другого процесса.
+func WithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey, sc)
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	spans []*Span
+}
+
+func (r *recorder) Export(span *Span) {
+	r.spans = append(r.spans, span)
+}
+
+func TestTraceparent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := ParseTraceparent(valid)
+	require.NoError(t, err)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	require.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	require.True(t, sc.Sampled)
+	require.Equal(t, valid, FormatTraceparent(sc))
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err := ParseTraceparent(s)
+		require.ErrorIs(t, err, ErrInvalidTraceparent, s)
+	}
+
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	require.NoError(t, err)
+}
+
+func TestPropagation(t *testing.T) {
+	rec := &recorder{}
+	SetExporter(rec)
+	defer SetExporter(nil)
+
+	// Планировщик кладёт traceparent в заголовки сообщения, хранитель продолжает трейс.
+	ctx, producer := Start(context.Background(), "scheduler.send")
+	headers := MapCarrier{}
+	Inject(ctx, headers)
+	producer.Finish()
+
+	_, consumer := Start(Extract(context.Background(), headers), "storer.consume")
+	consumer.RecordError(errors.New("boom"))
+	consumer.Finish()
+	consumer.Finish()
+
+	require.Len(t, rec.spans, 2)
+	require.Equal(t, producer.Context.TraceID, consumer.Context.TraceID)
+	require.Equal(t, producer.Context.SpanID, consumer.Parent)
+	require.NotEqual(t, producer.Context.SpanID, consumer.Context.SpanID)
+}
+
+func TestMiddleware(t *testing.T) {
+	rec := &recorder{}
+	SetExporter(rec)
+	defer SetExporter(nil)
+
+	var inner SpanContext
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "storage.call")
+		inner = span.Context
+		span.Finish()
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/hello?x=1", nil)
+	r.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	require.Len(t, rec.spans, 2)
+	server := rec.spans[1]
+	require.Equal(t, "GET /hello", server.Name)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.Context.TraceID.String())
+	require.Equal(t, "00f067aa0ba902b7", server.Parent.String())
+	require.Equal(t, http.StatusTeapot, server.Attributes["http.status_code"])
+	require.Equal(t, server.Context.SpanID, rec.spans[0].Parent)
+	require.Equal(t, server.Context.TraceID, inner.TraceID)
+}
+
+func TestJSONExporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetExporter(NewJSONExporter(buf, "calendar"))
+	defer SetExporter(nil)
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	child.SetAttribute("db.system", "postgresql")
+	child.RecordError(errors.New("timeout"))
+	child.Finish()
+	parent.Finish()
+
+	dec := json.NewDecoder(buf)
+	var got jsonSpan
+	require.NoError(t, dec.Decode(&got))
+	require.Equal(t, "calendar", got.Service)
+	require.Equal(t, "child", got.Name)
+	require.Equal(t, parent.Context.TraceID.String(), got.TraceID)
+	require.Equal(t, parent.Context.SpanID.String(), got.ParentSpanID)
+	require.Equal(t, []jsonAttribute{{Key: "db.system", Value: "postgresql"}}, got.Attributes)
+	require.Equal(t, jsonStatus{Code: "STATUS_CODE_ERROR", Message: "timeout"}, got.Status)
+
+	var root jsonSpan
+	require.NoError(t, dec.Decode(&root))
+	require.Equal(t, "parent", root.Name)
+	require.Empty(t, root.ParentSpanID)
+}
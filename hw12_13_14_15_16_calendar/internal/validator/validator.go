@@ -0,0 +1,330 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrRule - ошибка в самом теге: неизвестное правило или неверный аргумент.
+var ErrRule = errors.New("invalid validation rule")
+
+// ValidationError - нарушение правила в поле. Field - путь по именам из тега json,
+// например "reminders[1].relativeTo".
+type ValidationError struct {
+	Field string
+	Err   error
+}
+
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	var sb strings.Builder
+	for i, err := range v {
+		sb.WriteString(err.Field + ": " + err.Err.Error())
+		if i < len(v)-1 {
+			sb.WriteString("; ")
+		}
+	}
+	return sb.String()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Validate проверяет структуру по тегам validate вида "required|max:200".
+// Пустые значения проверяет только required, остальные правила их пропускают.
+// Вложенные структуры, указатели на них и их срезы проверяются рекурсивно.
+// Нарушения возвращаются как ValidationErrors, ошибки в тегах - как ErrRule.
+func Validate(v interface{}) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: expected a struct, got %s", ErrRule, val.Kind())
+	}
+
+	var errs ValidationErrors
+	if err := validateStruct(val, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(val reflect.Value, prefix string, errs *ValidationErrors) error {
+	typ := val.Type()
+	for i := 0; i < val.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		field := val.Field(i)
+		name := prefix + fieldName(sf)
+
+		err := checkField(field, val, sf.Tag.Get("validate"))
+		if errors.Is(err, ErrRule) {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err != nil {
+			*errs = append(*errs, ValidationError{Field: name, Err: err})
+			continue
+		}
+		if err := dive(field, name, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dive спускается во вложенные структуры, кроме time.Time.
+func dive(field reflect.Value, name string, errs *ValidationErrors) error {
+	//nolint:exhaustive
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.IsNil() {
+			return nil
+		}
+		return dive(field.Elem(), name, errs)
+	case reflect.Struct:
+		if field.Type() == timeType {
+			return nil
+		}
+		return validateStruct(field, name+".", errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < field.Len(); i++ {
+			if err := dive(field.Index(i), fmt.Sprintf("%s[%d]", name, i), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fieldName - имя поля из тега json, как его видит клиент.
+func fieldName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+type rule struct {
+	name  string
+	value string
+}
+
+// parseTag разбирает правила через "|", поэтому в regexp этот символ недоступен.
+func parseTag(tag string) []rule {
+	if tag == "" {
+		return nil
+	}
+	parts := strings.Split(tag, "|")
+	res := make([]rule, 0, len(parts))
+	for _, p := range parts {
+		name, value, _ := strings.Cut(p, ":")
+		res = append(res, rule{name: name, value: value})
+	}
+	return res
+}
+
+type check func(field, parent reflect.Value, arg string) error
+
+var checks = map[string]check{
+	"min":      validateMin,
+	"max":      validateMax,
+	"in":       validateIn,
+	"regexp":   validateRegexp,
+	"duration": validateDuration,
+	"after":    validateAfter,
+	"within":   validateWithin,
+}
+
+// checkField возвращает первое нарушенное правило поля.
+func checkField(field, parent reflect.Value, tag string) error {
+	for _, r := range parseTag(tag) {
+		if r.name == "required" {
+			if field.IsZero() {
+				return errors.New("is required")
+			}
+			continue
+		}
+		c, ok := checks[r.name]
+		if !ok {
+			return fmt.Errorf("%w: unknown rule %q", ErrRule, r.name)
+		}
+		if field.IsZero() {
+			continue
+		}
+		if err := c(field, parent, r.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// size - длина строки в символах, размер среза или значение числа.
+func size(field reflect.Value) (n float64, unit string, err error) {
+	//nolint:exhaustive
+	switch field.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(field.String())), " characters long", nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(field.Len()), " items", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(field.Int()), "", nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(field.Uint()), "", nil
+	case reflect.Float32, reflect.Float64:
+		return field.Float(), "", nil
+	}
+	return 0, "", fmt.Errorf("%w: %s has no size", ErrRule, field.Kind())
+}
+
+func validateMin(field, _ reflect.Value, arg string) error {
+	return validateSize(field, arg, "at least", func(n, limit float64) bool { return n >= limit })
+}
+
+func validateMax(field, _ reflect.Value, arg string) error {
+	return validateSize(field, arg, "at most", func(n, limit float64) bool { return n <= limit })
+}
+
+func validateSize(field reflect.Value, arg, word string, ok func(n, limit float64) bool) error {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid limit %q", ErrRule, arg)
+	}
+	n, unit, err := size(field)
+	if err != nil {
+		return err
+	}
+	if !ok(n, limit) {
+		return fmt.Errorf("must be %s %s%s", word, arg, unit)
+	}
+	return nil
+}
+
+func stringValue(field reflect.Value, rule string) (string, error) {
+	if field.Kind() != reflect.String {
+		return "", fmt.Errorf("%w: %s needs a string, got %s", ErrRule, rule, field.Kind())
+	}
+	return field.String(), nil
+}
+
+func validateIn(field, _ reflect.Value, arg string) error {
+	s, err := stringValue(field, "in")
+	if err != nil {
+		return err
+	}
+	allowed := strings.Split(arg, ",")
+	for _, v := range allowed {
+		if s == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of: %s", strings.Join(allowed, ", "))
+}
+
+var regexps sync.Map // string -> *regexp.Regexp
+
+func validateRegexp(field, _ reflect.Value, arg string) error {
+	s, err := stringValue(field, "regexp")
+	if err != nil {
+		return err
+	}
+	re, ok := regexps.Load(arg)
+	if !ok {
+		compiled, err := regexp.Compile(arg)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrRule, err.Error())
+		}
+		re, _ = regexps.LoadOrStore(arg, compiled)
+	}
+	if !re.(*regexp.Regexp).MatchString(s) {
+		return errors.New("has invalid format")
+	}
+	return nil
+}
+
+// validateDuration - строка в формате time.ParseDuration в пределах "min,max".
+func validateDuration(field, _ reflect.Value, arg string) error {
+	s, err := stringValue(field, "duration")
+	if err != nil {
+		return err
+	}
+	lo, hi, err := durationRange(arg)
+	if err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New("must be a duration like 15m or 1h30m")
+	}
+	if d < lo || d > hi {
+		return fmt.Errorf("must be between %s and %s", lo, hi)
+	}
+	return nil
+}
+
+func durationRange(arg string) (lo, hi time.Duration, err error) {
+	a, b, _ := strings.Cut(arg, ",")
+	if lo, err = time.ParseDuration(a); err == nil {
+		hi, err = time.ParseDuration(b)
+	}
+	if err != nil || hi < lo {
+		return 0, 0, fmt.Errorf("%w: invalid duration range %q", ErrRule, arg)
+	}
+	return lo, hi, nil
+}
+
+// sibling - время из соседнего поля по имени в Go, для сообщений - его имя в json.
+func sibling(field, parent reflect.Value, name string) (t, other time.Time, otherName string, err error) {
+	sf, ok := parent.Type().FieldByName(name)
+	if !ok || field.Type() != timeType || sf.Type != timeType {
+		return t, other, "", fmt.Errorf("%w: %s needs two time.Time fields", ErrRule, name)
+	}
+	t = field.Interface().(time.Time)
+	other = parent.FieldByIndex(sf.Index).Interface().(time.Time)
+	return t, other, fieldName(sf), nil
+}
+
+// validateAfter - время строго позже поля arg, если оно задано.
+func validateAfter(field, parent reflect.Value, arg string) error {
+	t, other, name, err := sibling(field, parent, arg)
+	if err != nil || other.IsZero() {
+		return err
+	}
+	if !t.After(other) {
+		return fmt.Errorf("must be after %s", name)
+	}
+	return nil
+}
+
+// validateWithin - время не дальше "Field,duration" от другого поля.
+func validateWithin(field, parent reflect.Value, arg string) error {
+	name, window, _ := strings.Cut(arg, ",")
+	d, err := time.ParseDuration(window)
+	if err != nil {
+		return fmt.Errorf("%w: invalid window %q", ErrRule, window)
+	}
+	t, other, otherName, err := sibling(field, parent, name)
+	if err != nil || other.IsZero() {
+		return err
+	}
+	if t.Sub(other) > d {
+		return fmt.Errorf("must be within %s of %s", window, otherName)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	Meeting struct {
+		Title    string    `json:"title" validate:"required|max:5"`
+		Start    time.Time `json:"start" validate:"required"`
+		End      time.Time `json:"end" validate:"after:Start|within:Start,2h"`
+		Owner    string    `json:"owner,omitempty" validate:"regexp:^[a-z0-9-]+$"`
+		Remind   string    `json:"remind" validate:"duration:0s,1h"`
+		Priority int       `validate:"min:1|max:3"`
+		Guests   []Guest   `json:"guests" validate:"max:2"`
+		Room     *Room     `json:"room"`
+		internal string    //nolint:unused
+	}
+
+	Guest struct {
+		Role string `json:"role" validate:"required|in:host,guest"`
+	}
+
+	Room struct {
+		Name string `json:"name" validate:"required"`
+	}
+)
+
+func TestValidate(t *testing.T) {
+	start := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	valid := func() Meeting {
+		return Meeting{
+			Title: "sync", Start: start, End: start.Add(time.Hour), Owner: "user-1", Remind: "15m",
+			Priority: 2, Guests: []Guest{{Role: "host"}}, Room: &Room{Name: "blue"},
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, Validate(valid()))
+		m := valid()
+		require.NoError(t, Validate(&m))
+
+		// пустые необязательные поля правилами не проверяются
+		require.NoError(t, Validate(Meeting{Title: "ок", Start: start}))
+	})
+
+	tests := []struct {
+		name   string
+		change func(m *Meeting)
+		field  string
+		msg    string
+	}{
+		{"required", func(m *Meeting) { m.Title = "" }, "title", "is required"},
+		{"max runes", func(m *Meeting) { m.Title = "встреча" }, "title", "must be at most 5 characters long"},
+		{"after", func(m *Meeting) { m.End = m.Start }, "end", "must be after start"},
+		{"within", func(m *Meeting) { m.End = m.Start.Add(3 * time.Hour) }, "end", "must be within 2h of start"},
+		{"regexp", func(m *Meeting) { m.Owner = "User 1" }, "owner", "has invalid format"},
+		{"duration format", func(m *Meeting) { m.Remind = "soon" }, "remind", "must be a duration like 15m or 1h30m"},
+		{"duration range", func(m *Meeting) { m.Remind = "2h" }, "remind", "must be between 0s and 1h0m0s"},
+		{"min", func(m *Meeting) { m.Priority = -1 }, "Priority", "must be at least 1"},
+		{"max", func(m *Meeting) { m.Priority = 4 }, "Priority", "must be at most 3"},
+		{"max items", func(m *Meeting) { m.Guests = make([]Guest, 3) }, "guests", "must be at most 2 items"},
+		{"in", func(m *Meeting) { m.Guests[0].Role = "boss" }, "guests[0].role", "must be one of: host, guest"},
+		{"pointer", func(m *Meeting) { m.Room.Name = "" }, "room.name", "is required"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			m := valid()
+			tc.change(&m)
+
+			err := Validate(m)
+			var verrs ValidationErrors
+			require.True(t, errors.As(err, &verrs), err)
+			require.Len(t, verrs, 1, err)
+			require.Equal(t, tc.field, verrs[0].Field)
+			require.EqualError(t, verrs[0].Err, tc.msg)
+		})
+	}
+
+	t.Run("all fields are reported", func(t *testing.T) {
+		err := Validate(Meeting{Guests: []Guest{{}, {Role: "host"}}})
+		require.EqualError(t, err, "title: is required; start: is required; guests[0].role: is required")
+	})
+}
+
+// Структуры с ошибками в тегах.
+type (
+	unknownRule struct {
+		A string `validate:"email"`
+	}
+	badLimit struct {
+		A string `validate:"max:many"`
+	}
+	noSize struct {
+		A bool `validate:"max:1"`
+	}
+	badRegexp struct {
+		A string `validate:"regexp:[a-"`
+	}
+	inOnInt struct {
+		A int `validate:"in:1,2"`
+	}
+	badRange struct {
+		A string `validate:"duration:1h,1m"`
+	}
+	badSibling struct {
+		A time.Time `validate:"after:B"`
+	}
+	badWindow struct {
+		A time.Time `validate:"within:B,week"`
+		B time.Time
+	}
+	nestedRule struct {
+		A []unknownRule
+	}
+)
+
+func TestValidateRules(t *testing.T) {
+	now := time.Now()
+	for name, v := range map[string]interface{}{
+		"not a struct": "meeting",
+		"unknown rule": unknownRule{A: "a"},
+		"bad limit":    badLimit{A: "a"},
+		"no size":      noSize{A: true},
+		"bad regexp":   badRegexp{A: "a"},
+		"in on int":    inOnInt{A: 1},
+		"bad range":    badRange{A: "1m"},
+		"bad sibling":  badSibling{A: now},
+		"bad window":   badWindow{A: now, B: now},
+		"nested rule":  nestedRule{A: []unknownRule{{}}},
+	} {
+		require.ErrorIs(t, Validate(v), ErrRule, name)
+	}
+
+	require.NoError(t, Validate((*Meeting)(nil)))
+}
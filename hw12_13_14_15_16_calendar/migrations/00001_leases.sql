@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE leases (
+    name       TEXT PRIMARY KEY,
+    holder     TEXT        NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE leases;
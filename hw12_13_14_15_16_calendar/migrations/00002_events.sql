@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE events (
+    id            TEXT PRIMARY KEY,
+    title         TEXT        NOT NULL,
+    start_at      TIMESTAMPTZ NOT NULL,
+    end_at        TIMESTAMPTZ NOT NULL,
+    description   TEXT        NOT NULL DEFAULT '',
+    user_id       TEXT        NOT NULL,
+    -- time.Duration, наносекунды.
+    notify_before BIGINT      NOT NULL DEFAULT 0,
+    -- start_at - notify_before, NULL если уведомление не нужно.
+    notify_at     TIMESTAMPTZ,
+    CHECK (end_at > start_at),
+    -- События одного пользователя не пересекаются (storage.ErrDateBusy).
+    CONSTRAINT events_no_overlap EXCLUDE USING gist (user_id WITH =, tstzrange(start_at, end_at) WITH &&)
+);
+
+CREATE INDEX events_notify_at_idx ON events (notify_at) WHERE notify_at IS NOT NULL;
+CREATE INDEX events_end_at_idx ON events (end_at);
+
+CREATE TABLE notifications (
+    event_id TEXT PRIMARY KEY,
+    title    TEXT        NOT NULL,
+    start_at TIMESTAMPTZ NOT NULL,
+    user_id  TEXT        NOT NULL
+);
+
+CREATE INDEX notifications_user_id_idx ON notifications (user_id, start_at);
+
+-- +goose Down
+DROP TABLE notifications;
+DROP TABLE events;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE events
+    ADD COLUMN category TEXT   NOT NULL DEFAULT '',
+    ADD COLUMN labels   TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX events_labels_idx ON events USING gin (labels);
+
+CREATE TABLE labels (
+    user_id TEXT NOT NULL,
+    name    TEXT NOT NULL,
+    color   TEXT NOT NULL,
+    PRIMARY KEY (user_id, name)
+);
+
+-- +goose Down
+DROP TABLE labels;
+
+ALTER TABLE events
+    DROP COLUMN labels,
+    DROP COLUMN category;
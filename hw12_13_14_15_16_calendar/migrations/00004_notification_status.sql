@@ -0,0 +1,13 @@
+-- +goose Up
+-- Уже сохранённые уведомления были доставлены.
+ALTER TABLE notifications
+    ADD COLUMN status        TEXT NOT NULL DEFAULT 'sent',
+    ADD COLUMN snoozed_until TIMESTAMPTZ;
+
+CREATE INDEX notifications_snoozed_until_idx ON notifications (snoozed_until) WHERE status = 'snoozed';
+
+-- +goose Down
+DROP INDEX notifications_snoozed_until_idx;
+ALTER TABLE notifications
+    DROP COLUMN snoozed_until,
+    DROP COLUMN status;
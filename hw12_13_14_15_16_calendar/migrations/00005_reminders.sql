@@ -0,0 +1,51 @@
+-- +goose Up
+-- Напоминания события, см. storage.Reminder.
+ALTER TABLE events ADD COLUMN reminders JSONB NOT NULL DEFAULT '[]';
+
+-- Время срабатывания каждого напоминания, включая notify_before,
+-- пересчитывается при каждом изменении события.
+CREATE TABLE event_reminders (
+    event_id    TEXT        NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    reminder_id TEXT        NOT NULL,
+    fire_at     TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (event_id, reminder_id)
+);
+
+CREATE INDEX event_reminders_fire_at_idx ON event_reminders (fire_at);
+
+-- ID как у storage.Reminder.ID: "start-900s".
+INSERT INTO event_reminders (event_id, reminder_id, fire_at)
+SELECT id, 'start-' || (notify_before / 1000000000) || 's', notify_at
+FROM events
+WHERE notify_at IS NOT NULL;
+
+DROP INDEX events_notify_at_idx;
+ALTER TABLE events DROP COLUMN notify_at;
+
+-- Уведомление теперь одно на напоминание. Уже сохранённые получают ID события.
+ALTER TABLE notifications
+    ADD COLUMN id          TEXT,
+    ADD COLUMN reminder_id TEXT NOT NULL DEFAULT '';
+UPDATE notifications SET id = event_id;
+ALTER TABLE notifications
+    ALTER COLUMN id SET NOT NULL,
+    DROP CONSTRAINT notifications_pkey,
+    ADD PRIMARY KEY (id);
+CREATE INDEX notifications_event_id_idx ON notifications (event_id);
+
+-- +goose Down
+DELETE FROM notifications WHERE id <> event_id;
+DROP INDEX notifications_event_id_idx;
+ALTER TABLE notifications
+    DROP CONSTRAINT notifications_pkey,
+    ADD PRIMARY KEY (event_id),
+    DROP COLUMN reminder_id,
+    DROP COLUMN id;
+
+ALTER TABLE events ADD COLUMN notify_at TIMESTAMPTZ;
+UPDATE events SET notify_at = start_at - notify_before * INTERVAL '1 microsecond' / 1000
+WHERE notify_before > 0;
+CREATE INDEX events_notify_at_idx ON events (notify_at) WHERE notify_at IS NOT NULL;
+
+DROP TABLE event_reminders;
+ALTER TABLE events DROP COLUMN reminders;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Журнал изменений событий, см. storage.AuditEntry. Записи удалённых
+-- событий остаются, чтобы событие можно было восстановить.
+CREATE TABLE event_audit (
+    event_id TEXT        NOT NULL,
+    version  INT         NOT NULL,
+    user_id  TEXT        NOT NULL,
+    actor    TEXT        NOT NULL,
+    action   TEXT        NOT NULL,
+    at       TIMESTAMPTZ NOT NULL,
+    before   JSONB,
+    after    JSONB,
+    PRIMARY KEY (event_id, version)
+);
+
+-- +goose Down
+DROP TABLE event_audit;
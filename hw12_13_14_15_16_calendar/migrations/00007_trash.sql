@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- События в корзине время не занимают.
+ALTER TABLE events
+    DROP CONSTRAINT events_no_overlap,
+    ADD CONSTRAINT events_no_overlap
+        EXCLUDE USING gist (user_id WITH =, tstzrange(start_at, end_at) WITH &&) WHERE (deleted_at IS NULL);
+
+CREATE INDEX events_deleted_at_idx ON events (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DELETE FROM events WHERE deleted_at IS NOT NULL;
+DROP INDEX events_deleted_at_idx;
+ALTER TABLE events
+    DROP CONSTRAINT events_no_overlap,
+    ADD CONSTRAINT events_no_overlap
+        EXCLUDE USING gist (user_id WITH =, tstzrange(start_at, end_at) WITH &&);
+ALTER TABLE events DROP COLUMN deleted_at;
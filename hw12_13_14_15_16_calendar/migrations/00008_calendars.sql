@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE calendars (
+    id       TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL,
+    name     TEXT NOT NULL,
+    UNIQUE (owner_id, name)
+);
+
+CREATE TABLE calendar_shares (
+    calendar_id TEXT NOT NULL REFERENCES calendars (id) ON DELETE CASCADE,
+    user_id     TEXT NOT NULL,
+    access      TEXT NOT NULL CHECK (access IN ('read', 'write')),
+    PRIMARY KEY (calendar_id, user_id)
+);
+
+CREATE INDEX calendar_shares_user_idx ON calendar_shares (user_id);
+
+-- Без календаря событие лежит в личном календаре владельца.
+ALTER TABLE events ADD COLUMN calendar_id TEXT REFERENCES calendars (id) ON DELETE SET NULL;
+
+CREATE INDEX events_calendar_idx ON events (calendar_id, start_at) WHERE calendar_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX events_calendar_idx;
+ALTER TABLE events DROP COLUMN calendar_id;
+DROP TABLE calendar_shares;
+DROP TABLE calendars;
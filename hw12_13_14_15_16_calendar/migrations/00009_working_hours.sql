@@ -0,0 +1,12 @@
+-- +goose Up
+-- Рабочее время пользователя, см. storage.WorkingHours. week - семь периодов
+-- с воскресенья, start/end в наносекундах от полуночи.
+CREATE TABLE working_hours (
+    user_id   TEXT   PRIMARY KEY,
+    time_zone TEXT   NOT NULL,
+    week      JSONB  NOT NULL,
+    holidays  TEXT[] NOT NULL DEFAULT '{}'
+);
+
+-- +goose Down
+DROP TABLE working_hours;
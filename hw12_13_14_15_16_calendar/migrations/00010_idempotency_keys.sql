@@ -0,0 +1,18 @@
+-- +goose Up
+-- Ответы на запросы с Idempotency-Key, см. storage.IdempotencyKey.
+-- status 0 - запрос ещё выполняется, body пока пустое.
+CREATE TABLE idempotency_keys (
+    user_id      TEXT        NOT NULL,
+    key          TEXT        NOT NULL,
+    hash         TEXT        NOT NULL,
+    status       INTEGER     NOT NULL DEFAULT 0,
+    content_type TEXT        NOT NULL DEFAULT '',
+    body         BYTEA,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+-- +goose Down
+DROP TABLE idempotency_keys;
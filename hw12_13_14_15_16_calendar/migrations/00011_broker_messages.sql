@@ -0,0 +1,18 @@
+-- +goose Up
+-- Очередь сообщений между планировщиком и хранителем в отдельных процессах, см. sqlbroker.
+-- available_at - когда сообщение можно выдать: сразу, после backoff повторной доставки
+-- или после аренды, взятой читателем, если он упал, не обработав сообщение.
+CREATE TABLE broker_messages (
+    id           BIGSERIAL   PRIMARY KEY,
+    topic        TEXT        NOT NULL,
+    key          TEXT        NOT NULL DEFAULT '',
+    value        BYTEA       NOT NULL,
+    headers      JSONB       NOT NULL DEFAULT '{}',
+    attempts     INTEGER     NOT NULL DEFAULT 0,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX broker_messages_topic_idx ON broker_messages (topic, available_at, id);
+
+-- +goose Down
+DROP TABLE broker_messages;
@@ -0,0 +1,255 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker"
+	memorybroker "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker/memory"
+	sqlbroker "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/broker/sql"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/client"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/scheduler"
+	internalhttp "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/server/http"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/memory"
+	sqlstorage "github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/sql"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storage/storagetest"
+	"github.com/fixme_my_friend/hw12_13_14_15_16_calendar/internal/storer"
+	"github.com/stretchr/testify/require"
+)
+
+const topic = "notifications"
+
+type testLogger struct {
+	t *testing.T
+}
+
+func (l testLogger) Info(msg string)  { l.t.Log(msg) }
+func (l testLogger) Error(msg string) { l.t.Log("ERROR " + msg) }
+
+// Storage - хранилище, общее для календаря, планировщика и хранителя.
+type Storage interface {
+	app.Storage
+	scheduler.Storage
+	storer.Storage
+}
+
+// Broker - очередь между планировщиком и хранителем.
+type Broker interface {
+	broker.Producer
+	broker.Consumer
+}
+
+// backends - хранилища и брокеры, на которых гоняется каждый тест. Для sql нужна
+// PostgreSQL с применёнными миграциями, см. storagetest.DSN: make integration-tests-sql
+// поднимает её и не даёт варианту на sql пропуститься.
+var backends = []struct {
+	name string
+	open func(t *testing.T) (Storage, Broker)
+}{
+	{"memory", func(*testing.T) (Storage, Broker) { return memorystorage.New(), memorybroker.New() }},
+	{"sql", openSQL},
+}
+
+func openSQL(t *testing.T) (Storage, Broker) {
+	t.Helper()
+	dsn := storagetest.DSN(t)
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.ExecContext(ctx, `TRUNCATE events, event_reminders, notifications, labels, event_audit, calendars,
+		calendar_shares, working_hours, idempotency_keys, leases, broker_messages`)
+	require.NoError(t, err)
+
+	st := sqlstorage.New(dsn)
+	require.NoError(t, st.Connect(ctx))
+	t.Cleanup(func() { st.Close(ctx) })
+	b := sqlbroker.New(testLogger{t}, dsn)
+	require.NoError(t, b.Connect(ctx))
+	t.Cleanup(func() { b.Close(ctx) })
+	return st, b
+}
+
+// forEachBackend запускает test на стеке поверх каждого хранилища.
+func forEachBackend(t *testing.T, test func(t *testing.T, s *stack)) {
+	t.Helper()
+	for _, b := range backends {
+		b := b
+		t.Run(b.name, func(t *testing.T) {
+			st, br := b.open(t)
+			test(t, startStack(t, st, br))
+		})
+	}
+}
+
+// stack - календарь, планировщик и хранитель в одном процессе
+// поверх общего хранилища и брокера.
+type stack struct {
+	storage Storage
+	api     *httptest.Server
+}
+
+func startStack(t *testing.T, st Storage, b Broker) *stack {
+	t.Helper()
+	logg := testLogger{t}
+
+	api := httptest.NewServer(internalhttp.NewServer(logg, app.New(logg, st), "").Handler())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sched := scheduler.New(logg, st, b, nil, scheduler.Config{Topic: topic, Interval: 20 * time.Millisecond})
+	go sched.Run(ctx)
+	go storer.New(logg, st, b, topic, nil).Run(ctx)
+
+	t.Cleanup(func() {
+		cancel()
+		api.Close()
+	})
+	return &stack{storage: st, api: api}
+}
+
+func TestEventLifecycle(t *testing.T) {
+	forEachBackend(t, testEventLifecycle)
+}
+
+func testEventLifecycle(t *testing.T, s *stack) {
+	ctx := context.Background()
+	c := client.New(s.api.URL, "alice", "")
+	day := time.Date(2030, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	created, err := c.Create(ctx, internalhttp.Event{
+		Title: "planning", Start: day.Add(10 * time.Hour), End: day.Add(11 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	t.Run("business errors", func(t *testing.T) {
+		_, err := c.Create(ctx, internalhttp.Event{
+			Title: "overlap", Start: day.Add(10*time.Hour + 30*time.Minute), End: day.Add(12 * time.Hour),
+		})
+		requireStatus(t, err, http.StatusConflict)
+
+		_, err = c.Create(ctx, internalhttp.Event{Title: "ends before start", Start: day, End: day.Add(-time.Hour)})
+		requireStatus(t, err, http.StatusBadRequest)
+
+		_, err = client.New(s.api.URL, "bob", "").Get(ctx, created.ID)
+		requireStatus(t, err, http.StatusNotFound)
+	})
+
+	t.Run("listing", func(t *testing.T) {
+		_, err := c.Create(ctx, internalhttp.Event{
+			Title: "later this week", Start: day.AddDate(0, 0, 3), End: day.AddDate(0, 0, 3).Add(time.Hour),
+		})
+		require.NoError(t, err)
+		_, err = c.Create(ctx, internalhttp.Event{
+			Title: "later this month", Start: day.AddDate(0, 0, 20), End: day.AddDate(0, 0, 20).Add(time.Hour),
+		})
+		require.NoError(t, err)
+
+		for period, want := range map[string]int{"day": 1, "week": 2, "month": 3} {
+			events, err := c.List(ctx, period, day, client.Filter{})
+			require.NoError(t, err)
+			require.Len(t, events, want, period)
+		}
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		created.Title = "planning (moved)"
+		created.Start = created.Start.Add(time.Hour)
+		created.End = created.End.Add(time.Hour)
+		_, err := c.Update(ctx, created.ID, created)
+		require.NoError(t, err)
+
+		got, err := c.Get(ctx, created.ID)
+		require.NoError(t, err)
+		require.Equal(t, "planning (moved)", got.Title)
+		require.True(t, got.Start.Equal(day.Add(11*time.Hour)))
+
+		require.NoError(t, c.Delete(ctx, created.ID))
+		_, err = c.Get(ctx, created.ID)
+		requireStatus(t, err, http.StatusNotFound)
+		trash, err := c.Trash(ctx)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		require.Equal(t, created.ID, trash[0].ID)
+
+		history, err := c.History(ctx, created.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		restored, err := c.Restore(ctx, created.ID, 2)
+		require.NoError(t, err)
+		require.Equal(t, "planning (moved)", restored.Title)
+		_, err = c.Get(ctx, created.ID)
+		require.NoError(t, err)
+	})
+}
+
+func TestReminderIsStored(t *testing.T) {
+	forEachBackend(t, testReminderIsStored)
+}
+
+func testReminderIsStored(t *testing.T, s *stack) {
+	ctx := context.Background()
+	c := client.New(s.api.URL, "alice", "")
+
+	// Уведомление уходит через ~200ms после создания события.
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	notifyBefore := time.Until(start) - 200*time.Millisecond
+	created, err := c.Create(ctx, internalhttp.Event{
+		Title: "reminder", Start: start, End: start.Add(time.Hour), NotifyBefore: notifyBefore.String(),
+	})
+	require.NoError(t, err)
+
+	requireNotificationStatus(t, s, "alice", storage.NotificationSent)
+
+	saved, err := s.storage.ListNotifications(ctx, "alice")
+	require.NoError(t, err)
+	reminderID := storage.Reminder{Anchor: storage.AnchorStart, Offset: -notifyBefore}.ID()
+	require.Equal(t, storage.Notification{
+		ID: storage.NotificationID(created.ID, reminderID), EventID: created.ID, ReminderID: reminderID,
+		Title: "reminder", Start: saved[0].Start, UserID: "alice", Status: storage.NotificationSent,
+	}, saved[0])
+	require.True(t, saved[0].Start.Equal(start))
+
+	t.Run("snooze and ack", func(t *testing.T) {
+		snoozed, err := c.SnoozeNotification(ctx, saved[0].ID, 100*time.Millisecond)
+		require.NoError(t, err)
+		require.Equal(t, "snoozed", snoozed.Status)
+
+		// Планировщик отправляет отложенное уведомление снова, хранитель отмечает его sent.
+		requireNotificationStatus(t, s, "alice", storage.NotificationSent)
+
+		acked, err := c.AckNotification(ctx, saved[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, "acked", acked.Status)
+
+		_, err = c.SnoozeNotification(ctx, saved[0].ID, time.Minute)
+		requireStatus(t, err, http.StatusConflict)
+
+		list, err := c.Notifications(ctx)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.Equal(t, "acked", list[0].Status)
+	})
+}
+
+func requireNotificationStatus(t *testing.T, s *stack, userID string, status storage.NotificationStatus) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		saved, err := s.storage.ListNotifications(context.Background(), userID)
+		return err == nil && len(saved) == 1 && saved[0].Status == status
+	}, 3*time.Second, 20*time.Millisecond)
+}
+
+func requireStatus(t *testing.T, err error, code int) {
+	t.Helper()
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr), "expected API error, got %v", err)
+	require.Equal(t, code, apiErr.Code, apiErr.Message)
+}